@@ -0,0 +1,303 @@
+package asanatest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/octoberswimmer/utka/client"
+	"github.com/octoberswimmer/utka/events"
+	"github.com/octoberswimmer/utka/tasks"
+	"github.com/octoberswimmer/utka/webhooks"
+)
+
+func seed(t *testing.T) (*Server, Workspace, Project) {
+	t.Helper()
+	srv := NewServer()
+	t.Cleanup(srv.Close)
+
+	srv.AddUser(User{Name: "Ada Lovelace", Email: "ada@example.com"})
+	ws := srv.AddWorkspace(Workspace{Name: "Acme"})
+	project := srv.AddProject(Project{Name: "Launch", Workspace: ws.GID})
+	return srv, ws, project
+}
+
+func TestTasksPagination(t *testing.T) {
+	srv, _, project := seed(t)
+	for i := range 250 {
+		srv.AddTask(Task{Name: fmt.Sprintf("Task %d", i), Memberships: []Membership{{Project: project.GID}}})
+	}
+	srv.AddTask(Task{Name: "Done", Completed: true, Memberships: []Membership{{Project: project.GID}}})
+
+	c := srv.Client()
+
+	list, err := tasks.NewTaskManager(c).ListByProject(project.GID, 0, 0)
+	if err != nil {
+		t.Fatalf("ListByProject() error = %v", err)
+	}
+	if len(list) != 250 {
+		t.Fatalf("Expected 250 incomplete tasks, got %d", len(list))
+	}
+	if list[0].Name != "Task 0" || list[249].Name != "Task 249" {
+		t.Errorf("Tasks out of order: first %q, last %q", list[0].Name, list[249].Name)
+	}
+
+	if _, err := c.Get("/tasks", url.Values{"project": {project.GID}, "limit": {"101"}}); err == nil {
+		t.Error("Expected an error for a limit over 100")
+	}
+}
+
+func TestTaskUpdateEvents(t *testing.T) {
+	srv, _, project := seed(t)
+	task := srv.AddTask(Task{Name: "Draft", Memberships: []Membership{{Project: project.GID}}})
+
+	c := srv.Client()
+	em := events.NewEventManager(c)
+
+	// The first request has no sync token and gets one from the 412
+	initial, err := em.InitializeSync(project.GID)
+	if err != nil {
+		t.Fatalf("InitializeSync() error = %v", err)
+	}
+	if initial.Sync == "" {
+		t.Fatal("Expected a sync token")
+	}
+
+	name := "Final"
+	if _, err := tasks.NewTaskManager(c).Update(task.GID, &tasks.TaskUpdate{Name: &name}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := srv.UpdateTask(task.GID, func(t *Task) { t.Completed = true }); err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+
+	resp, err := em.GetByResource(project.GID, initial.Sync)
+	if err != nil {
+		t.Fatalf("GetByResource() error = %v", err)
+	}
+	if len(resp.Data) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(resp.Data))
+	}
+	for i, field := range []string{"name", "completed"} {
+		event := resp.Data[i]
+		if event.Action != "changed" || event.Change == nil || event.Change.Field != field || event.Resource.GID != task.GID {
+			t.Errorf("Event %d = %+v, want a change to %s", i, event, field)
+		}
+	}
+
+	got, _ := srv.Task(task.GID)
+	if got.Name != "Final" || !got.Completed || got.CompletedAt.IsZero() {
+		t.Errorf("Task = %+v", got)
+	}
+
+	// Nothing new since the last token
+	resp, err = em.GetByResource(project.GID, resp.Sync)
+	if err != nil || len(resp.Data) != 0 {
+		t.Errorf("GetByResource() = %v, %v; want no events", resp, err)
+	}
+
+	srv.ExpireSyncTokens()
+	_, err = em.GetByResource(project.GID, resp.Sync)
+	if !client.IsSyncTokenExpired(err) {
+		t.Errorf("Expected a 412 after the tokens expired, got %v", err)
+	}
+}
+
+func TestEventPaging(t *testing.T) {
+	srv, _, project := seed(t)
+	c := srv.Client()
+	em := events.NewEventManager(c)
+
+	initial, err := em.InitializeSync(project.GID)
+	if err != nil {
+		t.Fatalf("InitializeSync() error = %v", err)
+	}
+	for i := range 150 {
+		srv.AddTask(Task{Name: fmt.Sprintf("Task %d", i), Memberships: []Membership{{Project: project.GID}}})
+	}
+
+	// GetByResource follows has_more across both pages
+	resp, err := em.GetByResource(project.GID, initial.Sync)
+	if err != nil {
+		t.Fatalf("GetByResource() error = %v", err)
+	}
+	if len(resp.Data) != 150 || resp.HasMore {
+		t.Errorf("Expected 150 events on completion, got %d (has_more %v)", len(resp.Data), resp.HasMore)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	srv, ws, _ := seed(t)
+	c := srv.Client()
+
+	srv.RateLimitNext(2, 0)
+	if _, err := c.Get("/workspaces/"+ws.GID, nil); err != nil {
+		t.Fatalf("Expected the client to retry past the rate limit, got %v", err)
+	}
+
+	srv.SetRateLimit(1, time.Minute)
+	if _, err := c.Get("/users/me", nil); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", srv.URL+"/users/me", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "60" {
+		t.Errorf("Expected a 429 with Retry-After 60, got %d %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+}
+
+func TestBatchUpdate(t *testing.T) {
+	srv, _, project := seed(t)
+	first := srv.AddTask(Task{Name: "One", Memberships: []Membership{{Project: project.GID}}})
+	second := srv.AddTask(Task{Name: "Two", Memberships: []Membership{{Project: project.GID}}})
+
+	completed := true
+	results, err := tasks.NewTaskManager(srv.Client()).UpdateMany(context.Background(), []string{first.GID, "404", second.GID}, &tasks.TaskUpdate{Completed: &completed})
+	if err != nil {
+		t.Fatalf("UpdateMany() error = %v", err)
+	}
+	if results[0].Err != nil || results[2].Err != nil || !results[0].Task.Completed {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+	if !client.IsNotFound(results[1].Err) {
+		t.Errorf("Expected a not found error for the unknown task, got %v", results[1].Err)
+	}
+	for _, task := range srv.Tasks() {
+		if !task.Completed {
+			t.Errorf("Task %s not completed", task.Name)
+		}
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	srv, ws, project := seed(t)
+	wm := webhooks.NewWebhookManager(srv.Client())
+
+	created, err := wm.Create(project.GID, "https://example.com/hook", []webhooks.WebhookFilter{{ResourceType: "task", Action: "changed"}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !created.Active || created.Resource.GID != project.GID {
+		t.Errorf("Created webhook = %+v", created)
+	}
+
+	list, err := wm.List(ws.GID, "")
+	if err != nil || len(list) != 1 {
+		t.Fatalf("List() = %v, %v", list, err)
+	}
+
+	if err := wm.Delete(created.GID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := wm.Get(created.GID); !client.IsNotFound(err) {
+		t.Errorf("Expected the webhook to be gone, got %v", err)
+	}
+}
+
+func TestLoadFixture(t *testing.T) {
+	fixture, err := LoadFixture("testdata/fixture.yaml")
+	if err != nil {
+		t.Fatalf("LoadFixture() error = %v", err)
+	}
+	srv := NewServer()
+	defer srv.Close()
+	if err := srv.Load(fixture); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	c := srv.Client()
+	task, err := tasks.NewTaskManager(c).Get("1000")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if task.Name != "Draft announcement" || task.Assignee == nil || task.Assignee.Name != "Ada Lovelace" || task.NumSubtasks != 1 {
+		t.Errorf("Task = %+v", task)
+	}
+
+	body, err := c.Get("/users/me", nil)
+	if err != nil || !strings.Contains(string(body), "Ada Lovelace") {
+		t.Errorf("Expected me to be Ada, got %s, %v", body, err)
+	}
+
+	subtasks := srv.Tasks()
+	if len(subtasks) != 2 || subtasks[1].Workspace != "1" {
+		t.Errorf("Expected the subtask to inherit the workspace, got %+v", subtasks)
+	}
+	if len(srv.Events()) != 0 {
+		t.Errorf("Expected no events from seeding, got %d", len(srv.Events()))
+	}
+
+	if err := NewFake().Load(&Fixture{Projects: []Project{{Name: "Orphan", Workspace: "404"}}}); err == nil {
+		t.Error("Expected an error for an unknown workspace")
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	srv, _, project := seed(t)
+	srv.DeliverWebhooks(t.Context(), nil)
+
+	deliveries := make(chan *http.Request, 10)
+	bodies := make(chan []byte, 10)
+	var secret string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s := r.Header.Get("X-Hook-Secret"); s != "" {
+			secret = s
+			w.Header().Set("X-Hook-Secret", s)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- r
+		bodies <- body
+	}))
+	defer target.Close()
+
+	wm := webhooks.NewWebhookManager(srv.Client())
+	if _, err := wm.Create(project.GID, target.URL, []webhooks.WebhookFilter{{ResourceType: "task", Action: "changed", Fields: []string{"completed"}}}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if secret == "" || srv.Webhooks()[0].Secret != secret {
+		t.Fatal("Expected the handshake to set the secret")
+	}
+
+	task := srv.AddTask(Task{Name: "Ship it", Memberships: []Membership{{Project: project.GID}}})
+	srv.UpdateTask(task.GID, func(t *Task) { t.Name = "Ship it now" })
+	srv.UpdateTask(task.GID, func(t *Task) { t.Completed = true })
+
+	select {
+	case r := <-deliveries:
+		body := <-bodies
+		if r.Header.Get("X-Hook-Signature") != Sign(secret, body) {
+			t.Errorf("Bad signature %q for %s", r.Header.Get("X-Hook-Signature"), body)
+		}
+		var payload struct{ Events []events.Event }
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Fatalf("Invalid delivery body %s: %v", body, err)
+		}
+		// Only the completed change passes the filter
+		if len(payload.Events) != 1 || payload.Events[0].Change.Field != "completed" {
+			t.Errorf("Delivered events = %s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("No delivery received")
+	}
+
+	// A target that fails the handshake is rejected
+	refusing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer refusing.Close()
+	if _, err := wm.Create(project.GID, refusing.URL, nil); err == nil {
+		t.Error("Expected the handshake to fail without the secret echoed")
+	}
+}
@@ -0,0 +1,142 @@
+package asanatest
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// eventPageSize is the most events returned by a single /events request.
+const eventPageSize = 100
+
+// Event is a change recorded by the fake, in the shape returned by /events.
+type Event struct {
+	User      *Ref    `json:"user,omitempty"`
+	CreatedAt string  `json:"created_at"`
+	Action    string  `json:"action"`
+	Resource  *Ref    `json:"resource"`
+	Parent    *Ref    `json:"parent"`
+	Change    *Change `json:"change,omitempty"`
+	Type      string  `json:"type"`
+
+	// scopes are the GIDs of the resources whose event streams include the
+	// event.
+	scopes []string
+}
+
+// Ref is the compact representation of a resource.
+type Ref struct {
+	GID             string `json:"gid"`
+	ResourceType    string `json:"resource_type"`
+	Name            string `json:"name,omitempty"`
+	ResourceSubtype string `json:"resource_subtype,omitempty"`
+}
+
+type Change struct {
+	Field    string      `json:"field"`
+	Action   string      `json:"action"`
+	NewValue interface{} `json:"new_value"`
+}
+
+// syncCursor is the position in the event log a sync token stands for.
+type syncCursor struct {
+	resource string
+	position int
+}
+
+// ref returns the compact representation of a resource, or nil if gid is empty.
+func (f *Fake) ref(resourceType, gid string) *Ref {
+	if gid == "" {
+		return nil
+	}
+
+	ref := &Ref{GID: gid, ResourceType: resourceType}
+	switch resourceType {
+	case "workspace":
+		if w := f.workspace(gid); w != nil {
+			ref.Name = w.Name
+		}
+	case "user":
+		if u := f.user(gid); u != nil {
+			ref.GID, ref.Name = u.GID, u.Name
+		}
+	case "team":
+		if t := f.team(gid); t != nil {
+			ref.Name = t.Name
+		}
+	case "project":
+		if p := f.project(gid); p != nil {
+			ref.Name = p.Name
+		}
+	case "section":
+		if s := f.section(gid); s != nil {
+			ref.Name = s.Name
+		}
+	case "task":
+		if t := f.task(gid); t != nil {
+			ref.Name = t.Name
+			ref.ResourceSubtype = t.ResourceSubtype
+		}
+	}
+	return ref
+}
+
+func eventFor(f *Fake, action, resourceType, gid string, parent *Ref, change *Change) *Event {
+	return &Event{
+		User:      f.ref("user", f.me),
+		CreatedAt: f.now().Format(time.RFC3339Nano),
+		Action:    action,
+		Resource:  f.ref(resourceType, gid),
+		Parent:    parent,
+		Change:    change,
+		Type:      resourceType,
+	}
+}
+
+func (f *Fake) recordEvent(event *Event, scopes ...string) {
+	event.scopes = scopes
+	f.events = append(f.events, event)
+	if f.onEvent != nil {
+		f.onEvent(event)
+	}
+}
+
+// Events returns copies of all events recorded so far, oldest first.
+func (f *Fake) Events() []Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return copyAll(f.events)
+}
+
+// ExpireSyncTokens invalidates every sync token issued so far, so the next
+// /events request with one of them fails with 412 Precondition Failed.
+func (f *Fake) ExpireSyncTokens() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	clear(f.syncTokens)
+}
+
+// newSyncToken issues a token for the current end of the event log.
+func (f *Fake) newSyncToken(resource string, position int) string {
+	f.nextSync++
+	token := fmt.Sprintf("sync-%d-%d", f.nextSync, position)
+	f.syncTokens[token] = syncCursor{resource: resource, position: position}
+	return token
+}
+
+// eventsSince returns up to eventPageSize events for the resource after the
+// cursor position, the position after the last one returned and whether
+// more remain.
+func (f *Fake) eventsSince(resource string, position int) ([]*Event, int, bool) {
+	var events []*Event
+	for i := position; i < len(f.events); i++ {
+		if !slices.Contains(f.events[i].scopes, resource) {
+			continue
+		}
+		if len(events) == eventPageSize {
+			return events, i, true
+		}
+		events = append(events, f.events[i])
+	}
+	return events, len(f.events), false
+}
@@ -0,0 +1,525 @@
+// Package asanatest provides an in-memory fake of the Asana API for
+// integration tests, in the spirit of net/http/httptest.
+//
+// A Fake holds workspaces, users, teams, projects, sections, tasks and
+// webhooks, serves the endpoints utka uses, and records an event for every
+// change so that event polling can be tested end to end:
+//
+//	srv := asanatest.NewServer()
+//	defer srv.Close()
+//
+//	ws := srv.AddWorkspace(asanatest.Workspace{Name: "Acme"})
+//	project := srv.AddProject(asanatest.Project{Name: "Launch", Workspace: ws.GID})
+//
+//	tm := tasks.NewTaskManager(srv.Client())
+package asanatest
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Workspace struct {
+	GID            string   `json:"gid"`
+	Name           string   `json:"name"`
+	IsOrganization bool     `json:"is_organization,omitempty"`
+	EmailDomains   []string `json:"email_domains,omitempty"`
+}
+
+type User struct {
+	GID   string `json:"gid"`
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	// Workspaces lists the GIDs of the user's workspaces. A user without
+	// workspaces belongs to all of them.
+	Workspaces []string `json:"workspaces,omitempty"`
+}
+
+type Team struct {
+	GID       string `json:"gid"`
+	Name      string `json:"name"`
+	Workspace string `json:"workspace"`
+}
+
+type Project struct {
+	GID       string    `json:"gid"`
+	Name      string    `json:"name"`
+	Notes     string    `json:"notes,omitempty"`
+	Color     string    `json:"color,omitempty"`
+	Archived  bool      `json:"archived,omitempty"`
+	Public    bool      `json:"public,omitempty"`
+	Workspace string    `json:"workspace"`
+	Team      string    `json:"team,omitempty"`
+	Owner     string    `json:"owner,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+}
+
+type Section struct {
+	GID     string `json:"gid"`
+	Name    string `json:"name"`
+	Project string `json:"project"`
+}
+
+// Membership places a task in a project, and optionally in one of its sections.
+type Membership struct {
+	Project string `json:"project"`
+	Section string `json:"section,omitempty"`
+}
+
+type Task struct {
+	GID             string       `json:"gid"`
+	Name            string       `json:"name"`
+	Notes           string       `json:"notes,omitempty"`
+	ResourceSubtype string       `json:"resource_subtype,omitempty"`
+	Completed       bool         `json:"completed,omitempty"`
+	CompletedAt     time.Time    `json:"completed_at,omitzero"`
+	Assignee        string       `json:"assignee,omitempty"`
+	DueOn           string       `json:"due_on,omitempty"`
+	DueAt           string       `json:"due_at,omitempty"`
+	StartOn         string       `json:"start_on,omitempty"`
+	Workspace       string       `json:"workspace,omitempty"`
+	Memberships     []Membership `json:"memberships,omitempty"`
+	Parent          string       `json:"parent,omitempty"`
+	Followers       []string     `json:"followers,omitempty"`
+	CreatedAt       time.Time    `json:"created_at,omitzero"`
+	ModifiedAt      time.Time    `json:"modified_at,omitzero"`
+}
+
+// Projects returns the GIDs of the projects the task is in.
+func (t *Task) Projects() []string {
+	projects := make([]string, 0, len(t.Memberships))
+	for _, m := range t.Memberships {
+		projects = append(projects, m.Project)
+	}
+	return projects
+}
+
+type Webhook struct {
+	GID       string          `json:"gid"`
+	Resource  string          `json:"resource"`
+	Target    string          `json:"target"`
+	Active    bool            `json:"active"`
+	Filters   []WebhookFilter `json:"filters,omitempty"`
+	CreatedAt time.Time       `json:"created_at,omitzero"`
+	// Secret signs deliveries. It is set by the handshake for webhooks
+	// created through the API.
+	Secret string `json:"secret,omitempty"`
+
+	LastSuccessAt      time.Time `json:"last_success_at,omitzero"`
+	LastFailureAt      time.Time `json:"last_failure_at,omitzero"`
+	LastFailureContent string    `json:"last_failure_content,omitempty"`
+	DeliveryRetryCount int       `json:"delivery_retry_count,omitempty"`
+}
+
+type WebhookFilter struct {
+	ResourceType    string   `json:"resource_type"`
+	ResourceSubtype string   `json:"resource_subtype,omitempty"`
+	Action          string   `json:"action,omitempty"`
+	Fields          []string `json:"fields,omitempty"`
+}
+
+// Fake is an in-memory Asana API. It implements http.Handler, serving paths
+// with or without the /api/1.0 prefix. Its methods seed and mutate state
+// directly, as if another user changed it, and are safe for concurrent use
+// with requests.
+type Fake struct {
+	// Token, if set, is the only bearer token accepted. Otherwise any
+	// non-empty token is.
+	Token string
+
+	// Now returns the current time, for timestamps on resources and events.
+	Now func() time.Time
+
+	mu         sync.Mutex
+	mux        *http.ServeMux
+	nextGID    int64
+	me         string
+	workspaces []*Workspace
+	users      []*User
+	teams      []*Team
+	projects   []*Project
+	sections   []*Section
+	tasks      []*Task
+	webhooks   []*Webhook
+
+	events     []*Event
+	syncTokens map[string]syncCursor
+	nextSync   int
+
+	rateLimit rateLimit
+
+	// delivery is set once DeliverWebhooks is called.
+	delivery *delivery
+
+	// onEvent is called with each event as it is recorded, with mu held.
+	onEvent func(*Event)
+}
+
+func NewFake() *Fake {
+	f := &Fake{
+		Now:        time.Now,
+		nextGID:    1200000000000000,
+		syncTokens: map[string]syncCursor{},
+	}
+	f.routes()
+	return f
+}
+
+func (f *Fake) newGID() string {
+	f.nextGID++
+	return fmt.Sprint(f.nextGID)
+}
+
+func (f *Fake) now() time.Time {
+	return f.Now().UTC()
+}
+
+// AddWorkspace adds a workspace, assigning a GID if it has none.
+func (f *Fake) AddWorkspace(w Workspace) Workspace {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if w.GID == "" {
+		w.GID = f.newGID()
+	}
+	f.workspaces = append(f.workspaces, &w)
+	return w
+}
+
+// AddUser adds a user. The first user added is the authenticated user ("me")
+// unless SetMe is called.
+func (f *Fake) AddUser(u User) User {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if u.GID == "" {
+		u.GID = f.newGID()
+	}
+	f.users = append(f.users, &u)
+	if f.me == "" {
+		f.me = u.GID
+	}
+	return u
+}
+
+// SetMe makes the user the authenticated user, who is returned by /users/me
+// and recorded as the author of changes.
+func (f *Fake) SetMe(userGID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.me = userGID
+}
+
+func (f *Fake) AddTeam(t Team) Team {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if t.GID == "" {
+		t.GID = f.newGID()
+	}
+	f.teams = append(f.teams, &t)
+	return t
+}
+
+func (f *Fake) AddProject(p Project) Project {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if p.GID == "" {
+		p.GID = f.newGID()
+	}
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = f.now()
+	}
+	f.projects = append(f.projects, &p)
+	f.recordEvent(eventFor(f, "added", "project", p.GID, f.ref("workspace", p.Workspace), nil), p.GID, p.Workspace)
+	return p
+}
+
+func (f *Fake) AddSection(s Section) Section {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if s.GID == "" {
+		s.GID = f.newGID()
+	}
+	f.sections = append(f.sections, &s)
+	f.recordEvent(eventFor(f, "added", "section", s.GID, f.ref("project", s.Project), nil), s.GID, s.Project)
+	return s
+}
+
+// AddTask adds a task, recording an "added" event for each of its projects.
+// The workspace defaults to that of the task's first project or its parent.
+func (f *Fake) AddTask(t Task) Task {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return *f.addTask(t)
+}
+
+func (f *Fake) addTask(t Task) *Task {
+	if t.GID == "" {
+		t.GID = f.newGID()
+	}
+	if t.ResourceSubtype == "" {
+		t.ResourceSubtype = "default_task"
+	}
+	if t.Workspace == "" && len(t.Memberships) > 0 {
+		if p := f.project(t.Memberships[0].Project); p != nil {
+			t.Workspace = p.Workspace
+		}
+	}
+	if t.Workspace == "" && t.Parent != "" {
+		if parent := f.task(t.Parent); parent != nil {
+			t.Workspace = parent.Workspace
+		}
+	}
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = f.now()
+	}
+	t.ModifiedAt = t.CreatedAt
+	if t.Completed && t.CompletedAt.IsZero() {
+		t.CompletedAt = t.CreatedAt
+	}
+
+	task := &t
+	f.tasks = append(f.tasks, task)
+
+	if t.Parent != "" {
+		f.recordEvent(eventFor(f, "added", "task", t.GID, f.ref("task", t.Parent), nil), f.taskScopes(task)...)
+	}
+	for _, m := range t.Memberships {
+		f.recordEvent(eventFor(f, "added", "task", t.GID, f.ref("project", m.Project), nil), f.taskScopes(task)...)
+	}
+	if t.Parent == "" && len(t.Memberships) == 0 {
+		f.recordEvent(eventFor(f, "added", "task", t.GID, nil, nil), f.taskScopes(task)...)
+	}
+	return task
+}
+
+// UpdateTask changes a task with fn and records a "changed" event for every
+// field that changed.
+func (f *Fake) UpdateTask(gid string, fn func(t *Task)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	task := f.task(gid)
+	if task == nil {
+		return fmt.Errorf("task %s not found", gid)
+	}
+	f.updateTask(task, fn)
+	return nil
+}
+
+func (f *Fake) updateTask(task *Task, fn func(t *Task)) {
+	before := *task
+	before.Memberships = slices.Clone(task.Memberships)
+	before.Followers = slices.Clone(task.Followers)
+
+	fn(task)
+
+	if task.Completed && !before.Completed {
+		task.CompletedAt = f.now()
+	} else if !task.Completed {
+		task.CompletedAt = time.Time{}
+	}
+
+	changes := []struct {
+		field    string
+		changed  bool
+		newValue interface{}
+	}{
+		{"name", task.Name != before.Name, task.Name},
+		{"notes", task.Notes != before.Notes, task.Notes},
+		{"completed", task.Completed != before.Completed, task.Completed},
+		{"assignee", task.Assignee != before.Assignee, f.ref("user", task.Assignee)},
+		{"due_on", task.DueOn != before.DueOn, nullable(task.DueOn)},
+		{"due_at", task.DueAt != before.DueAt, nullable(task.DueAt)},
+		{"start_on", task.StartOn != before.StartOn, nullable(task.StartOn)},
+		{"followers", !slices.Equal(task.Followers, before.Followers), nil},
+	}
+
+	modified := false
+	for _, c := range changes {
+		if !c.changed {
+			continue
+		}
+		modified = true
+		change := &Change{Field: c.field, Action: "changed", NewValue: c.newValue}
+		f.recordEvent(eventFor(f, "changed", "task", task.GID, nil, change), f.taskScopes(task)...)
+	}
+	if modified {
+		task.ModifiedAt = f.now()
+	}
+}
+
+// DeleteTask removes a task, recording a "deleted" event.
+func (f *Fake) DeleteTask(gid string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.task(gid) == nil {
+		return fmt.Errorf("task %s not found", gid)
+	}
+	f.deleteTask(gid)
+	return nil
+}
+
+func (f *Fake) deleteTask(gid string) {
+	task := f.task(gid)
+	scopes := f.taskScopes(task)
+	event := eventFor(f, "deleted", "task", gid, nil, nil)
+	f.tasks = slices.DeleteFunc(f.tasks, func(t *Task) bool { return t.GID == gid })
+	f.recordEvent(event, scopes...)
+}
+
+// AddWebhook registers a webhook directly, without a handshake.
+func (f *Fake) AddWebhook(w Webhook) Webhook {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if w.GID == "" {
+		w.GID = f.newGID()
+	}
+	if w.CreatedAt.IsZero() {
+		w.CreatedAt = f.now()
+	}
+	f.webhooks = append(f.webhooks, &w)
+	return w
+}
+
+// Task returns a copy of the task with the given GID.
+func (f *Fake) Task(gid string) (Task, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	task := f.task(gid)
+	if task == nil {
+		return Task{}, false
+	}
+	return *task, true
+}
+
+// Tasks returns copies of all tasks in the order they were added.
+func (f *Fake) Tasks() []Task {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return copyAll(f.tasks)
+}
+
+func (f *Fake) Webhooks() []Webhook {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return copyAll(f.webhooks)
+}
+
+func copyAll[T any](items []*T) []T {
+	copies := make([]T, len(items))
+	for i, item := range items {
+		copies[i] = *item
+	}
+	return copies
+}
+
+func find[T any](items []*T, gid func(*T) string, want string) *T {
+	for _, item := range items {
+		if gid(item) == want {
+			return item
+		}
+	}
+	return nil
+}
+
+func (f *Fake) workspace(gid string) *Workspace {
+	return find(f.workspaces, func(w *Workspace) string { return w.GID }, gid)
+}
+
+// user looks a user up by GID, by email or as "me", like GET /users/{gid}.
+func (f *Fake) user(gid string) *User {
+	if gid == "me" {
+		gid = f.me
+	}
+	if strings.Contains(gid, "@") {
+		return find(f.users, func(u *User) string { return strings.ToLower(u.Email) }, strings.ToLower(gid))
+	}
+	return find(f.users, func(u *User) string { return u.GID }, gid)
+}
+
+func (f *Fake) team(gid string) *Team {
+	return find(f.teams, func(t *Team) string { return t.GID }, gid)
+}
+
+func (f *Fake) project(gid string) *Project {
+	return find(f.projects, func(p *Project) string { return p.GID }, gid)
+}
+
+func (f *Fake) section(gid string) *Section {
+	return find(f.sections, func(s *Section) string { return s.GID }, gid)
+}
+
+func (f *Fake) task(gid string) *Task {
+	return find(f.tasks, func(t *Task) string { return t.GID }, gid)
+}
+
+func (f *Fake) webhook(gid string) *Webhook {
+	return find(f.webhooks, func(w *Webhook) string { return w.GID }, gid)
+}
+
+// resourceType returns the type of the resource with the given GID, or "" if
+// there is none.
+func (f *Fake) resourceType(gid string) string {
+	switch {
+	case f.workspace(gid) != nil:
+		return "workspace"
+	case f.user(gid) != nil:
+		return "user"
+	case f.project(gid) != nil:
+		return "project"
+	case f.section(gid) != nil:
+		return "section"
+	case f.task(gid) != nil:
+		return "task"
+	}
+	return ""
+}
+
+// workspaceOf returns the workspace GID of a resource.
+func (f *Fake) workspaceOf(gid string) string {
+	switch {
+	case f.workspace(gid) != nil:
+		return gid
+	case f.project(gid) != nil:
+		return f.project(gid).Workspace
+	case f.section(gid) != nil:
+		return f.workspaceOf(f.section(gid).Project)
+	case f.task(gid) != nil:
+		return f.task(gid).Workspace
+	}
+	return ""
+}
+
+// taskScopes returns the resources whose event streams include changes to
+// the task: the task itself, its parent, projects and sections.
+func (f *Fake) taskScopes(t *Task) []string {
+	scopes := []string{t.GID}
+	if t.Parent != "" {
+		scopes = append(scopes, t.Parent)
+	}
+	for _, m := range t.Memberships {
+		scopes = append(scopes, m.Project)
+		if m.Section != "" {
+			scopes = append(scopes, m.Section)
+		}
+	}
+	return scopes
+}
+
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,156 @@
+package asanatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture is the initial state of a Fake, as loaded from a JSON or YAML
+// file. Resources reference each other by GID, so every resource that is
+// referenced needs an explicit gid.
+//
+//	workspaces:
+//	  - {gid: "1", name: Acme}
+//	users:
+//	  - {gid: "10", name: Ada Lovelace, email: ada@example.com}
+//	projects:
+//	  - {gid: "100", name: Launch, workspace: "1"}
+//	tasks:
+//	  - name: Draft announcement
+//	    assignee: "10"
+//	    memberships: [{project: "100"}]
+type Fixture struct {
+	// Me is the GID or email of the authenticated user. It defaults to the
+	// first user.
+	Me         string      `json:"me,omitempty"`
+	Workspaces []Workspace `json:"workspaces,omitempty"`
+	Users      []User      `json:"users,omitempty"`
+	Projects   []Project   `json:"projects,omitempty"`
+	Sections   []Section   `json:"sections,omitempty"`
+	Tasks      []Task      `json:"tasks,omitempty"`
+	Webhooks   []Webhook   `json:"webhooks,omitempty"`
+}
+
+// LoadFixture reads a fixture from a JSON or YAML file.
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	// YAML is a superset of JSON. Converting through JSON lets one set of
+	// struct tags serve both formats.
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	data, err = json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	return &fixture, nil
+}
+
+// Load adds the fixture's resources to a new fake, before any sync tokens
+// are issued or webhooks delivered; seeding records no events. References to
+// unknown resources are reported as errors, with the resources before them
+// already added.
+func (f *Fake) Load(fixture *Fixture) error {
+	for _, w := range fixture.Workspaces {
+		f.AddWorkspace(w)
+	}
+	for _, u := range fixture.Users {
+		f.AddUser(u)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if fixture.Me != "" {
+		me := find(f.users, func(u *User) string { return u.Email }, fixture.Me)
+		if me == nil {
+			me = f.user(fixture.Me)
+		}
+		if me == nil {
+			return fmt.Errorf("me: unknown user %s", fixture.Me)
+		}
+		f.me = me.GID
+	}
+
+	for _, p := range fixture.Projects {
+		if f.workspace(p.Workspace) == nil {
+			return fmt.Errorf("project %q: unknown workspace %s", p.Name, p.Workspace)
+		}
+		if p.GID == "" {
+			p.GID = f.newGID()
+		}
+		if p.CreatedAt.IsZero() {
+			p.CreatedAt = f.now()
+		}
+		f.projects = append(f.projects, &p)
+	}
+
+	for _, s := range fixture.Sections {
+		if f.project(s.Project) == nil {
+			return fmt.Errorf("section %q: unknown project %s", s.Name, s.Project)
+		}
+		if s.GID == "" {
+			s.GID = f.newGID()
+		}
+		f.sections = append(f.sections, &s)
+	}
+
+	for _, t := range fixture.Tasks {
+		if err := f.checkTask(&t); err != nil {
+			return err
+		}
+		f.addTask(t)
+	}
+
+	for _, w := range fixture.Webhooks {
+		if f.resourceType(w.Resource) == "" {
+			return fmt.Errorf("webhook for %s: unknown resource", w.Resource)
+		}
+		if w.GID == "" {
+			w.GID = f.newGID()
+		}
+		if w.CreatedAt.IsZero() {
+			w.CreatedAt = f.now()
+		}
+		f.webhooks = append(f.webhooks, &w)
+	}
+
+	// Seeding is not a change anyone should sync
+	f.events = nil
+	return nil
+}
+
+// checkTask verifies the references of a fixture task.
+func (f *Fake) checkTask(t *Task) error {
+	for _, m := range t.Memberships {
+		if f.project(m.Project) == nil {
+			return fmt.Errorf("task %q: unknown project %s", t.Name, m.Project)
+		}
+		if m.Section != "" && f.section(m.Section) == nil {
+			return fmt.Errorf("task %q: unknown section %s", t.Name, m.Section)
+		}
+	}
+	if t.Assignee != "" && f.user(t.Assignee) == nil {
+		return fmt.Errorf("task %q: unknown assignee %s", t.Name, t.Assignee)
+	}
+	if t.Parent != "" && f.task(t.Parent) == nil {
+		return fmt.Errorf("task %q: unknown parent %s", t.Name, t.Parent)
+	}
+	if t.Workspace == "" && len(t.Memberships) == 0 && t.Parent == "" {
+		return fmt.Errorf("task %q: needs a workspace, project or parent", t.Name)
+	}
+	return nil
+}
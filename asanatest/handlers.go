@@ -0,0 +1,1062 @@
+package asanatest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPageSize is the largest limit Asana accepts on list endpoints.
+const maxPageSize = 100
+
+func (f *Fake) routes() {
+	f.mux = http.NewServeMux()
+
+	f.mux.HandleFunc("GET /workspaces", f.listWorkspaces)
+	f.mux.HandleFunc("GET /workspaces/{gid}", f.getWorkspace)
+	f.mux.HandleFunc("GET /workspaces/{gid}/users", f.listWorkspaceUsers)
+	f.mux.HandleFunc("GET /workspaces/{gid}/typeahead", f.typeahead)
+
+	f.mux.HandleFunc("GET /users/{gid}", f.getUser)
+
+	f.mux.HandleFunc("GET /projects", f.listProjects)
+	f.mux.HandleFunc("GET /projects/{gid}", f.getProject)
+	f.mux.HandleFunc("GET /projects/{gid}/sections", f.listSections)
+	f.mux.HandleFunc("GET /sections/{gid}", f.getSection)
+	f.mux.HandleFunc("POST /sections/{gid}/addTask", f.addTaskToSection)
+
+	f.mux.HandleFunc("GET /tasks", f.listTasks)
+	f.mux.HandleFunc("POST /tasks", f.createTask)
+	f.mux.HandleFunc("GET /tasks/{gid}", f.getTask)
+	f.mux.HandleFunc("PUT /tasks/{gid}", f.updateTaskHandler)
+	f.mux.HandleFunc("DELETE /tasks/{gid}", f.deleteTaskHandler)
+	f.mux.HandleFunc("POST /tasks/{gid}/addProject", f.addProject)
+	f.mux.HandleFunc("POST /tasks/{gid}/removeProject", f.removeProject)
+
+	f.mux.HandleFunc("GET /webhooks", f.listWebhooks)
+	f.mux.HandleFunc("POST /webhooks", f.createWebhook)
+	f.mux.HandleFunc("GET /webhooks/{gid}", f.getWebhook)
+	f.mux.HandleFunc("PUT /webhooks/{gid}", f.updateWebhook)
+	f.mux.HandleFunc("DELETE /webhooks/{gid}", f.deleteWebhook)
+
+	f.mux.HandleFunc("GET /events", f.getEvents)
+
+	f.mux.HandleFunc("POST /batch", f.batch)
+
+	f.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "No matching route for request")
+	})
+}
+
+func (f *Fake) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if rest, ok := strings.CutPrefix(r.URL.Path, "/api/1.0"); ok {
+		r = r.Clone(r.Context())
+		r.URL.Path = rest
+		r.URL.RawPath = ""
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" || (f.Token != "" && token != f.Token) {
+		writeError(w, http.StatusUnauthorized, "Not Authorized")
+		return
+	}
+
+	if retryAfter, limited := f.rateLimited(); limited {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, "You have made too many requests recently. Please, be chill.")
+		return
+	}
+
+	f.mux.ServeHTTP(w, r)
+}
+
+type errorResponse struct {
+	Errors []errorDetail `json:"errors"`
+	// Sync is set on 412 responses from /events.
+	Sync string `json:"sync,omitempty"`
+}
+
+type errorDetail struct {
+	Message string `json:"message"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeData(w http.ResponseWriter, status int, data interface{}) {
+	writeJSON(w, status, map[string]interface{}{"data": data})
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Errors: []errorDetail{{Message: message}}})
+}
+
+// writePage writes a list response. Without a limit parameter every item is
+// returned; with one, the items are paginated with an opaque offset like the
+// real API.
+func writePage[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	query := r.URL.Query()
+	if query.Get("limit") == "" {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": items, "next_page": nil})
+		return
+	}
+
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 || limit > maxPageSize {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("limit: Must be between 1 and %d", maxPageSize))
+		return
+	}
+
+	start := 0
+	if offset := query.Get("offset"); offset != "" {
+		start, err = decodeOffset(offset)
+		if err != nil || start > len(items) {
+			writeError(w, http.StatusBadRequest, "offset: Your pagination token is invalid.")
+			return
+		}
+	}
+
+	end := min(start+limit, len(items))
+	var nextPage interface{}
+	if end < len(items) {
+		offset := encodeOffset(end)
+		next := *r.URL
+		nextQuery := next.Query()
+		nextQuery.Set("offset", offset)
+		next.RawQuery = nextQuery.Encode()
+		nextPage = map[string]string{
+			"offset": offset,
+			"path":   next.Path + "?" + next.RawQuery,
+			"uri":    "https://app.asana.com/api/1.0" + next.Path + "?" + next.RawQuery,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": items[start:end], "next_page": nextPage})
+}
+
+func encodeOffset(index int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(index)))
+}
+
+func decodeOffset(offset string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(offset)
+	if err != nil {
+		return 0, err
+	}
+	index, ok := strings.CutPrefix(string(data), "offset:")
+	if !ok {
+		return 0, fmt.Errorf("invalid offset")
+	}
+	return strconv.Atoi(index)
+}
+
+// readData decodes the "data" object of a JSON request body.
+func readData(r *http.Request) (map[string]json.RawMessage, error) {
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("Could not parse request data, invalid JSON")
+	}
+	if body.Data == nil {
+		return nil, fmt.Errorf("data: Missing input")
+	}
+	return body.Data, nil
+}
+
+func stringField(data map[string]json.RawMessage, name string) (string, bool, error) {
+	raw, ok := data[name]
+	if !ok {
+		return "", false, nil
+	}
+	if string(raw) == "null" {
+		return "", true, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return "", true, fmt.Errorf("%s: Not a string", name)
+	}
+	return s, true, nil
+}
+
+// Workspaces
+
+func (f *Fake) workspaceJSON(w *Workspace) map[string]interface{} {
+	return map[string]interface{}{
+		"gid":             w.GID,
+		"resource_type":   "workspace",
+		"name":            w.Name,
+		"is_organization": w.IsOrganization,
+		"email_domains":   w.EmailDomains,
+	}
+}
+
+func (f *Fake) listWorkspaces(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	items := make([]map[string]interface{}, 0, len(f.workspaces))
+	for _, ws := range f.workspaces {
+		items = append(items, f.workspaceJSON(ws))
+	}
+	writePage(w, r, items)
+}
+
+func (f *Fake) getWorkspace(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ws := f.workspace(r.PathValue("gid"))
+	if ws == nil {
+		writeError(w, http.StatusNotFound, "workspace: Unknown object: "+r.PathValue("gid"))
+		return
+	}
+	writeData(w, http.StatusOK, f.workspaceJSON(ws))
+}
+
+// typeahead matches the query case-insensitively against names, and emails
+// for users. Asana ranks results fuzzily; the fake returns substring matches
+// in the order the resources were added.
+func (f *Fake) typeahead(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	gid := r.PathValue("gid")
+	if f.workspace(gid) == nil {
+		writeError(w, http.StatusNotFound, "workspace: Unknown object: "+gid)
+		return
+	}
+
+	query := r.URL.Query()
+	count := 20
+	if c := query.Get("count"); c != "" {
+		var err error
+		if count, err = strconv.Atoi(c); err != nil || count < 1 || count > maxPageSize {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("count: Must be between 1 and %d", maxPageSize))
+			return
+		}
+	}
+	text := strings.ToLower(query.Get("query"))
+	matches := func(values ...string) bool {
+		for _, value := range values {
+			if strings.Contains(strings.ToLower(value), text) {
+				return true
+			}
+		}
+		return false
+	}
+
+	items := []*Ref{}
+	switch resourceType := query.Get("resource_type"); resourceType {
+	case "user", "":
+		for _, u := range f.users {
+			if (len(u.Workspaces) == 0 || slices.Contains(u.Workspaces, gid)) && matches(u.Name, u.Email) {
+				items = append(items, f.ref("user", u.GID))
+			}
+		}
+	case "team":
+		for _, t := range f.teams {
+			if t.Workspace == gid && matches(t.Name) {
+				items = append(items, f.ref("team", t.GID))
+			}
+		}
+	case "project":
+		for _, p := range f.projects {
+			if p.Workspace == gid && matches(p.Name) {
+				items = append(items, f.ref("project", p.GID))
+			}
+		}
+	case "task":
+		for _, t := range f.tasks {
+			if t.Workspace == gid && matches(t.Name) {
+				items = append(items, f.ref("task", t.GID))
+			}
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "resource_type: Not supported by the fake: "+resourceType)
+		return
+	}
+	writeData(w, http.StatusOK, items[:min(count, len(items))])
+}
+
+// Users
+
+func (f *Fake) userJSON(u *User) map[string]interface{} {
+	workspaces := []*Ref{}
+	for _, ws := range f.workspaces {
+		if len(u.Workspaces) == 0 || slices.Contains(u.Workspaces, ws.GID) {
+			workspaces = append(workspaces, f.ref("workspace", ws.GID))
+		}
+	}
+	return map[string]interface{}{
+		"gid":           u.GID,
+		"resource_type": "user",
+		"name":          u.Name,
+		"email":         u.Email,
+		"workspaces":    workspaces,
+	}
+}
+
+func (f *Fake) listWorkspaceUsers(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	gid := r.PathValue("gid")
+	if f.workspace(gid) == nil {
+		writeError(w, http.StatusNotFound, "workspace: Unknown object: "+gid)
+		return
+	}
+
+	items := []map[string]interface{}{}
+	for _, u := range f.users {
+		if len(u.Workspaces) == 0 || slices.Contains(u.Workspaces, gid) {
+			items = append(items, f.userJSON(u))
+		}
+	}
+	writePage(w, r, items)
+}
+
+func (f *Fake) getUser(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	u := f.user(r.PathValue("gid"))
+	if u == nil {
+		writeError(w, http.StatusNotFound, "user: Unknown object: "+r.PathValue("gid"))
+		return
+	}
+	writeData(w, http.StatusOK, f.userJSON(u))
+}
+
+// Projects and sections
+
+func (f *Fake) projectJSON(p *Project) map[string]interface{} {
+	return map[string]interface{}{
+		"gid":           p.GID,
+		"resource_type": "project",
+		"name":          p.Name,
+		"notes":         p.Notes,
+		"color":         p.Color,
+		"archived":      p.Archived,
+		"public":        p.Public,
+		"created_at":    p.CreatedAt.Format(time.RFC3339Nano),
+		"workspace":     f.ref("workspace", p.Workspace),
+		"team":          f.ref("team", p.Team),
+		"owner":         f.ref("user", p.Owner),
+		"permalink_url": "https://app.asana.com/0/" + p.GID + "/list",
+	}
+}
+
+func (f *Fake) listProjects(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	query := r.URL.Query()
+	workspace, team := query.Get("workspace"), query.Get("team")
+	if workspace == "" && team == "" {
+		writeError(w, http.StatusBadRequest, "workspace: Missing input")
+		return
+	}
+
+	items := []map[string]interface{}{}
+	for _, p := range f.projects {
+		if workspace != "" && p.Workspace != workspace {
+			continue
+		}
+		if team != "" && p.Team != team {
+			continue
+		}
+		if archived := query.Get("archived"); archived != "" && strconv.FormatBool(p.Archived) != archived {
+			continue
+		}
+		items = append(items, f.projectJSON(p))
+	}
+	writePage(w, r, items)
+}
+
+func (f *Fake) getProject(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p := f.project(r.PathValue("gid"))
+	if p == nil {
+		writeError(w, http.StatusNotFound, "project: Unknown object: "+r.PathValue("gid"))
+		return
+	}
+	writeData(w, http.StatusOK, f.projectJSON(p))
+}
+
+func (f *Fake) sectionJSON(s *Section) map[string]interface{} {
+	return map[string]interface{}{
+		"gid":           s.GID,
+		"resource_type": "section",
+		"name":          s.Name,
+		"project":       f.ref("project", s.Project),
+	}
+}
+
+func (f *Fake) listSections(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	gid := r.PathValue("gid")
+	if f.project(gid) == nil {
+		writeError(w, http.StatusNotFound, "project: Unknown object: "+gid)
+		return
+	}
+
+	items := []map[string]interface{}{}
+	for _, s := range f.sections {
+		if s.Project == gid {
+			items = append(items, f.sectionJSON(s))
+		}
+	}
+	writePage(w, r, items)
+}
+
+func (f *Fake) getSection(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s := f.section(r.PathValue("gid"))
+	if s == nil {
+		writeError(w, http.StatusNotFound, "section: Unknown object: "+r.PathValue("gid"))
+		return
+	}
+	writeData(w, http.StatusOK, f.sectionJSON(s))
+}
+
+func (f *Fake) addTaskToSection(w http.ResponseWriter, r *http.Request) {
+	data, err := readData(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	section := f.section(r.PathValue("gid"))
+	if section == nil {
+		writeError(w, http.StatusNotFound, "section: Unknown object: "+r.PathValue("gid"))
+		return
+	}
+	taskGID, _, _ := stringField(data, "task")
+	task := f.task(taskGID)
+	if task == nil {
+		writeError(w, http.StatusBadRequest, "task: Unknown object: "+taskGID)
+		return
+	}
+
+	membership := Membership{Project: section.Project, Section: section.GID}
+	if i := slices.IndexFunc(task.Memberships, func(m Membership) bool { return m.Project == section.Project }); i >= 0 {
+		task.Memberships[i] = membership
+	} else {
+		task.Memberships = append(task.Memberships, membership)
+	}
+	f.recordEvent(eventFor(f, "added", "task", task.GID, f.ref("section", section.GID), nil), f.taskScopes(task)...)
+
+	writeData(w, http.StatusOK, map[string]interface{}{})
+}
+
+// Tasks
+
+func (f *Fake) taskJSON(t *Task) map[string]interface{} {
+	projects := []*Ref{}
+	memberships := []map[string]*Ref{}
+	for _, m := range t.Memberships {
+		projects = append(projects, f.ref("project", m.Project))
+		membership := map[string]*Ref{"project": f.ref("project", m.Project)}
+		if m.Section != "" {
+			membership["section"] = f.ref("section", m.Section)
+		}
+		memberships = append(memberships, membership)
+	}
+
+	followers := []*Ref{}
+	for _, gid := range t.Followers {
+		followers = append(followers, f.ref("user", gid))
+	}
+
+	subtasks := 0
+	for _, other := range f.tasks {
+		if other.Parent == t.GID {
+			subtasks++
+		}
+	}
+
+	return map[string]interface{}{
+		"gid":              t.GID,
+		"resource_type":    "task",
+		"name":             t.Name,
+		"notes":            t.Notes,
+		"resource_subtype": t.ResourceSubtype,
+		"completed":        t.Completed,
+		"completed_at":     timestamp(t.CompletedAt),
+		"created_at":       t.CreatedAt.Format(time.RFC3339Nano),
+		"modified_at":      t.ModifiedAt.Format(time.RFC3339Nano),
+		"due_on":           nullable(t.DueOn),
+		"due_at":           nullable(t.DueAt),
+		"start_on":         nullable(t.StartOn),
+		"assignee":         f.ref("user", t.Assignee),
+		"workspace":        f.ref("workspace", t.Workspace),
+		"parent":           f.ref("task", t.Parent),
+		"projects":         projects,
+		"memberships":      memberships,
+		"followers":        followers,
+		"num_subtasks":     subtasks,
+		"tags":             []interface{}{},
+		"custom_fields":    []interface{}{},
+	}
+}
+
+func (f *Fake) listTasks(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	query := r.URL.Query()
+	project, section := query.Get("project"), query.Get("section")
+	assignee, workspace := query.Get("assignee"), query.Get("workspace")
+
+	switch {
+	case project != "":
+		if f.project(project) == nil {
+			writeError(w, http.StatusNotFound, "project: Unknown object: "+project)
+			return
+		}
+	case section != "":
+		if f.section(section) == nil {
+			writeError(w, http.StatusNotFound, "section: Unknown object: "+section)
+			return
+		}
+	case assignee != "" && workspace != "":
+		if u := f.user(assignee); u != nil {
+			assignee = u.GID
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "Must specify exactly one of project, tag, section, user task list, or assignee + workspace")
+		return
+	}
+
+	var completedSince time.Time
+	if since := query.Get("completed_since"); since == "now" {
+		completedSince = f.now()
+	} else if since != "" {
+		var err error
+		completedSince, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "completed_since: Not a valid date-time")
+			return
+		}
+	}
+
+	items := []map[string]interface{}{}
+	for _, t := range f.tasks {
+		switch {
+		case project != "" && !slices.Contains(t.Projects(), project):
+			continue
+		case section != "" && !slices.ContainsFunc(t.Memberships, func(m Membership) bool { return m.Section == section }):
+			continue
+		case assignee != "" && (t.Assignee != assignee || t.Workspace != workspace):
+			continue
+		case !completedSince.IsZero() && t.Completed && t.CompletedAt.Before(completedSince):
+			continue
+		}
+		items = append(items, f.taskJSON(t))
+	}
+	writePage(w, r, items)
+}
+
+func (f *Fake) getTask(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := f.task(r.PathValue("gid"))
+	if t == nil {
+		writeError(w, http.StatusNotFound, "task: Unknown object: "+r.PathValue("gid"))
+		return
+	}
+	writeData(w, http.StatusOK, f.taskJSON(t))
+}
+
+func (f *Fake) createTask(w http.ResponseWriter, r *http.Request) {
+	data, err := readData(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var task Task
+	var projects []string
+	if raw, ok := data["projects"]; ok {
+		if err := json.Unmarshal(raw, &projects); err != nil {
+			writeError(w, http.StatusBadRequest, "projects: Not an array of GIDs")
+			return
+		}
+	}
+	task.Workspace, _, _ = stringField(data, "workspace")
+	task.Parent, _, _ = stringField(data, "parent")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, gid := range projects {
+		if f.project(gid) == nil {
+			writeError(w, http.StatusBadRequest, "projects: Unknown object: "+gid)
+			return
+		}
+		task.Memberships = append(task.Memberships, Membership{Project: gid})
+	}
+	if task.Parent != "" {
+		parent := f.task(task.Parent)
+		if parent == nil {
+			writeError(w, http.StatusBadRequest, "parent: Unknown object: "+task.Parent)
+			return
+		}
+		if task.Workspace == "" {
+			task.Workspace = parent.Workspace
+		}
+	}
+	if task.Workspace == "" && len(projects) == 0 {
+		writeError(w, http.StatusBadRequest, "You should specify one of workspace, parent, projects")
+		return
+	}
+
+	if err := applyTaskFields(f, &task, data); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	created := f.addTask(task)
+	writeData(w, http.StatusCreated, f.taskJSON(created))
+}
+
+func (f *Fake) updateTaskHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := readData(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	task := f.task(r.PathValue("gid"))
+	if task == nil {
+		writeError(w, http.StatusNotFound, "task: Unknown object: "+r.PathValue("gid"))
+		return
+	}
+
+	// Validate on a copy so a bad request changes nothing
+	updated := *task
+	if err := applyTaskFields(f, &updated, data); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	f.updateTask(task, func(t *Task) { *t = updated })
+
+	writeData(w, http.StatusOK, f.taskJSON(task))
+}
+
+// applyTaskFields sets the writable task fields present in a request.
+func applyTaskFields(f *Fake, task *Task, data map[string]json.RawMessage) error {
+	for _, field := range []struct {
+		name string
+		dest *string
+		date bool
+	}{
+		{"name", &task.Name, false},
+		{"notes", &task.Notes, false},
+		{"resource_subtype", &task.ResourceSubtype, false},
+		{"due_on", &task.DueOn, true},
+		{"due_at", &task.DueAt, false},
+		{"start_on", &task.StartOn, true},
+	} {
+		value, ok, err := stringField(data, field.name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if field.date && value != "" {
+			if _, err := time.Parse(time.DateOnly, value); err != nil {
+				return fmt.Errorf("%s: Invalid date: %q", field.name, value)
+			}
+		}
+		*field.dest = value
+	}
+
+	if assignee, ok, err := stringField(data, "assignee"); err != nil {
+		return err
+	} else if ok {
+		if assignee != "" {
+			u := f.user(assignee)
+			if u == nil {
+				return fmt.Errorf("assignee: Unknown object: %s", assignee)
+			}
+			assignee = u.GID
+		}
+		task.Assignee = assignee
+	}
+
+	if raw, ok := data["completed"]; ok {
+		if err := json.Unmarshal(raw, &task.Completed); err != nil {
+			return fmt.Errorf("completed: Not a boolean")
+		}
+	}
+
+	if raw, ok := data["followers"]; ok {
+		var followers []string
+		if err := json.Unmarshal(raw, &followers); err != nil {
+			return fmt.Errorf("followers: Not an array of GIDs")
+		}
+		task.Followers = followers
+	}
+
+	return nil
+}
+
+func (f *Fake) deleteTaskHandler(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.task(r.PathValue("gid")) == nil {
+		writeError(w, http.StatusNotFound, "task: Unknown object: "+r.PathValue("gid"))
+		return
+	}
+	f.deleteTask(r.PathValue("gid"))
+	writeData(w, http.StatusOK, map[string]interface{}{})
+}
+
+func (f *Fake) addProject(w http.ResponseWriter, r *http.Request) {
+	f.changeProject(w, r, true)
+}
+
+func (f *Fake) removeProject(w http.ResponseWriter, r *http.Request) {
+	f.changeProject(w, r, false)
+}
+
+func (f *Fake) changeProject(w http.ResponseWriter, r *http.Request, add bool) {
+	data, err := readData(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	task := f.task(r.PathValue("gid"))
+	if task == nil {
+		writeError(w, http.StatusNotFound, "task: Unknown object: "+r.PathValue("gid"))
+		return
+	}
+	projectGID, _, _ := stringField(data, "project")
+	if f.project(projectGID) == nil {
+		writeError(w, http.StatusBadRequest, "project: Unknown object: "+projectGID)
+		return
+	}
+	sectionGID, _, _ := stringField(data, "section")
+
+	inProject := func(m Membership) bool { return m.Project == projectGID }
+	switch {
+	case add && !slices.ContainsFunc(task.Memberships, inProject):
+		task.Memberships = append(task.Memberships, Membership{Project: projectGID, Section: sectionGID})
+		f.recordEvent(eventFor(f, "added", "task", task.GID, f.ref("project", projectGID), nil), f.taskScopes(task)...)
+	case !add && slices.ContainsFunc(task.Memberships, inProject):
+		// Record with the old scopes so the project's stream sees the removal
+		event := eventFor(f, "removed", "task", task.GID, f.ref("project", projectGID), nil)
+		scopes := f.taskScopes(task)
+		task.Memberships = slices.DeleteFunc(task.Memberships, inProject)
+		f.recordEvent(event, scopes...)
+	}
+
+	writeData(w, http.StatusOK, map[string]interface{}{})
+}
+
+// Webhooks
+
+func (f *Fake) webhookJSON(wh *Webhook) map[string]interface{} {
+	filters := wh.Filters
+	if filters == nil {
+		filters = []WebhookFilter{}
+	}
+	return map[string]interface{}{
+		"gid":                  wh.GID,
+		"resource_type":        "webhook",
+		"resource":             f.ref(f.resourceType(wh.Resource), wh.Resource),
+		"target":               wh.Target,
+		"active":               wh.Active,
+		"created_at":           wh.CreatedAt.Format(time.RFC3339Nano),
+		"filters":              filters,
+		"last_success_at":      timestamp(wh.LastSuccessAt),
+		"last_failure_at":      timestamp(wh.LastFailureAt),
+		"last_failure_content": wh.LastFailureContent,
+		"delivery_retry_count": wh.DeliveryRetryCount,
+	}
+}
+
+// timestamp formats t, or returns nil for the zero time.
+func timestamp(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+func (f *Fake) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	query := r.URL.Query()
+	workspace := query.Get("workspace")
+	if workspace == "" {
+		writeError(w, http.StatusBadRequest, "workspace: Missing input")
+		return
+	}
+
+	items := []map[string]interface{}{}
+	for _, wh := range f.webhooks {
+		if f.workspaceOf(wh.Resource) != workspace {
+			continue
+		}
+		if resource := query.Get("resource"); resource != "" && wh.Resource != resource {
+			continue
+		}
+		items = append(items, f.webhookJSON(wh))
+	}
+	writePage(w, r, items)
+}
+
+func (f *Fake) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var webhook Webhook
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		data, err := readData(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		webhook.Resource, _, _ = stringField(data, "resource")
+		webhook.Target, _, _ = stringField(data, "target")
+		if raw, ok := data["filters"]; ok {
+			if err := json.Unmarshal(raw, &webhook.Filters); err != nil {
+				writeError(w, http.StatusBadRequest, "filters: Invalid filters")
+				return
+			}
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			writeError(w, http.StatusBadRequest, "Could not parse request data")
+			return
+		}
+		webhook.Resource = r.PostForm.Get("resource")
+		webhook.Target = r.PostForm.Get("target")
+		if filters := r.PostForm.Get("filters"); filters != "" {
+			if err := json.Unmarshal([]byte(filters), &webhook.Filters); err != nil {
+				writeError(w, http.StatusBadRequest, "filters: Invalid filters")
+				return
+			}
+		}
+	}
+
+	if webhook.Resource == "" {
+		writeError(w, http.StatusBadRequest, "resource: Missing input")
+		return
+	}
+	if target, err := url.Parse(webhook.Target); err != nil || target.Scheme != "https" && target.Scheme != "http" {
+		writeError(w, http.StatusBadRequest, "target: Invalid URL")
+		return
+	}
+
+	f.mu.Lock()
+	known := f.resourceType(webhook.Resource) != ""
+	delivery := f.delivery
+	f.mu.Unlock()
+
+	if !known {
+		writeError(w, http.StatusBadRequest, "resource: Unknown object: "+webhook.Resource)
+		return
+	}
+
+	// The handshake happens without the lock, as the target may call back
+	// into the API while answering it
+	if delivery != nil {
+		secret, err := handshake(r.Context(), delivery.client, webhook.Target)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Could not complete activation handshake with target URL: "+err.Error())
+			return
+		}
+		webhook.Secret = secret
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	webhook.GID = f.newGID()
+	webhook.Active = true
+	webhook.CreatedAt = f.now()
+	f.webhooks = append(f.webhooks, &webhook)
+
+	writeData(w, http.StatusCreated, f.webhookJSON(&webhook))
+}
+
+func (f *Fake) getWebhook(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	wh := f.webhook(r.PathValue("gid"))
+	if wh == nil {
+		writeError(w, http.StatusNotFound, "webhook: Unknown object: "+r.PathValue("gid"))
+		return
+	}
+	writeData(w, http.StatusOK, f.webhookJSON(wh))
+}
+
+func (f *Fake) updateWebhook(w http.ResponseWriter, r *http.Request) {
+	data, err := readData(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	wh := f.webhook(r.PathValue("gid"))
+	if wh == nil {
+		writeError(w, http.StatusNotFound, "webhook: Unknown object: "+r.PathValue("gid"))
+		return
+	}
+	if raw, ok := data["filters"]; ok {
+		var filters []WebhookFilter
+		if err := json.Unmarshal(raw, &filters); err != nil {
+			writeError(w, http.StatusBadRequest, "filters: Invalid filters")
+			return
+		}
+		wh.Filters = filters
+	}
+	writeData(w, http.StatusOK, f.webhookJSON(wh))
+}
+
+func (f *Fake) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	gid := r.PathValue("gid")
+	if f.webhook(gid) == nil {
+		writeError(w, http.StatusNotFound, "webhook: Unknown object: "+gid)
+		return
+	}
+	f.webhooks = slices.DeleteFunc(f.webhooks, func(wh *Webhook) bool { return wh.GID == gid })
+	writeData(w, http.StatusOK, map[string]interface{}{})
+}
+
+// Events
+
+func (f *Fake) getEvents(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	query := r.URL.Query()
+	resource := query.Get("resource")
+	if resource == "" {
+		writeError(w, http.StatusBadRequest, "resource: Missing input")
+		return
+	}
+	if f.resourceType(resource) == "" {
+		writeError(w, http.StatusNotFound, "resource: Unknown object: "+resource)
+		return
+	}
+
+	cursor, ok := f.syncTokens[query.Get("sync")]
+	if !ok || cursor.resource != resource {
+		// Like Asana, a missing or unknown token yields a fresh one that
+		// starts at the current end of the stream
+		writeJSON(w, http.StatusPreconditionFailed, errorResponse{
+			Errors: []errorDetail{{Message: "Sync token invalid or too old. If you are attempting to keep resources in sync, you must fetch the full dataset for this query now and use the new sync token for the next sync."}},
+			Sync:   f.newSyncToken(resource, len(f.events)),
+		})
+		return
+	}
+
+	events, position, hasMore := f.eventsSince(resource, cursor.position)
+	data := make([]Event, len(events))
+	for i, event := range events {
+		data[i] = *event
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":     data,
+		"sync":     f.newSyncToken(resource, position),
+		"has_more": hasMore,
+	})
+}
+
+// Batch
+
+type batchAction struct {
+	RelativePath string          `json:"relative_path"`
+	Method       string          `json:"method"`
+	Data         json.RawMessage `json:"data,omitempty"`
+	Options      struct {
+		Fields []string `json:"fields,omitempty"`
+	} `json:"options,omitempty"`
+}
+
+type batchResult struct {
+	StatusCode int               `json:"status_code"`
+	Headers    map[string]string `json:"headers"`
+	Body       json.RawMessage   `json:"body"`
+}
+
+// batch runs each action through the fake's routes. The batch counts as a
+// single request for rate limiting.
+func (f *Fake) batch(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Data struct {
+			Actions []batchAction `json:"actions"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Could not parse request data, invalid JSON")
+		return
+	}
+	if len(body.Data.Actions) > 10 {
+		writeError(w, http.StatusBadRequest, "actions: Too many actions, maximum is 10")
+		return
+	}
+
+	results := make([]batchResult, len(body.Data.Actions))
+	for i, action := range body.Data.Actions {
+		var actionBody []byte
+		if len(action.Data) > 0 && string(action.Data) != "null" {
+			actionBody, _ = json.Marshal(map[string]json.RawMessage{"data": action.Data})
+		}
+
+		req := httptest.NewRequestWithContext(r.Context(), strings.ToUpper(action.Method), action.RelativePath, bytes.NewReader(actionBody))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		f.mux.ServeHTTP(rec, req)
+
+		results[i] = batchResult{
+			StatusCode: rec.Code,
+			Headers:    map[string]string{},
+			Body:       rec.Body.Bytes(),
+		}
+	}
+
+	writeData(w, http.StatusOK, results)
+}
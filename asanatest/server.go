@@ -0,0 +1,103 @@
+package asanatest
+
+import (
+	"net/http/httptest"
+	"time"
+
+	"github.com/octoberswimmer/utka/client"
+)
+
+// Server is a Fake served over HTTP on a local port.
+type Server struct {
+	*Fake
+
+	// URL is the base URL of the fake API, to be passed to
+	// client.Client.SetBaseURL.
+	URL string
+
+	server *httptest.Server
+}
+
+// NewServer starts a Server. The caller should call Close when finished.
+func NewServer() *Server {
+	fake := NewFake()
+	server := httptest.NewServer(fake)
+	return &Server{Fake: fake, URL: server.URL, server: server}
+}
+
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns a client for the server. Retries back off for at most a few
+// milliseconds so tests of rate limiting and failures stay fast.
+func (s *Server) Client() *client.Client {
+	c := client.NewClient("asanatest-token")
+	c.SetBaseURL(s.URL)
+
+	policy := client.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 10 * time.Millisecond
+	c.SetRetryPolicy(policy)
+	return c
+}
+
+// rateLimit rejects requests with 429 Too Many Requests, either when more
+// than limit requests arrive within a window or for a fixed number of
+// upcoming requests.
+type rateLimit struct {
+	limit       int
+	window      time.Duration
+	windowStart time.Time
+	count       int
+
+	rejectNext int
+	retryAfter time.Duration
+}
+
+// SetRateLimit allows at most limit requests per window. A limit of zero
+// removes the limit.
+func (f *Fake) SetRateLimit(limit int, window time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rateLimit.limit = limit
+	f.rateLimit.window = window
+	f.rateLimit.windowStart = time.Time{}
+	f.rateLimit.count = 0
+}
+
+// RateLimitNext rejects the next n requests with 429 Too Many Requests and
+// the given Retry-After, which is rounded up to whole seconds.
+func (f *Fake) RateLimitNext(n int, retryAfter time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rateLimit.rejectNext = n
+	f.rateLimit.retryAfter = retryAfter
+}
+
+// rateLimited counts a request against the rate limit and reports whether it
+// must be rejected, and for how long the client should wait.
+func (f *Fake) rateLimited() (time.Duration, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rl := &f.rateLimit
+	if rl.rejectNext > 0 {
+		rl.rejectNext--
+		return rl.retryAfter, true
+	}
+
+	if rl.limit <= 0 {
+		return 0, false
+	}
+	now := f.now()
+	if rl.windowStart.IsZero() || now.Sub(rl.windowStart) >= rl.window {
+		rl.windowStart = now
+		rl.count = 0
+	}
+	rl.count++
+	if rl.count > rl.limit {
+		return rl.windowStart.Add(rl.window).Sub(now), true
+	}
+	return 0, false
+}
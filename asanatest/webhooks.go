@@ -0,0 +1,249 @@
+package asanatest
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// deliveryAttempts is the number of times a batch of events is sent to a
+	// target before it is given up.
+	deliveryAttempts = 3
+
+	// deliveryBackoff is the delay before the first retry of a delivery. It
+	// doubles on every attempt.
+	deliveryBackoff = 500 * time.Millisecond
+)
+
+// delivery queues events for webhook targets and sends them from a single
+// goroutine, so targets see events in the order they happened.
+type delivery struct {
+	client *http.Client
+
+	mu      sync.Mutex
+	pending []*Event
+	wake    chan struct{}
+}
+
+// DeliverWebhooks makes the fake behave like Asana towards webhook targets
+// until ctx is done:
+//
+//   - Creating a webhook through the API first sends the target an empty
+//     POST with an X-Hook-Secret header, which the target must echo back
+//     with a 2xx response. The secret is kept to sign deliveries.
+//   - Every event in a webhook's resource that matches its filters is POSTed
+//     to the target as {"events": [...]}, with an X-Hook-Signature header
+//     holding the hex HMAC-SHA256 of the body keyed with the secret.
+//
+// Failed deliveries are retried a few times and then recorded on the
+// webhook's last_failure_at and last_failure_content. httpClient is used for
+// all requests to targets; nil means http.DefaultClient.
+func (f *Fake) DeliverWebhooks(ctx context.Context, httpClient *http.Client) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	d := &delivery{client: httpClient, wake: make(chan struct{}, 1)}
+
+	f.mu.Lock()
+	f.delivery = d
+	f.onEvent = d.enqueue
+	f.mu.Unlock()
+
+	go f.deliver(ctx, d)
+}
+
+func (d *delivery) enqueue(event *Event) {
+	d.mu.Lock()
+	d.pending = append(d.pending, event)
+	d.mu.Unlock()
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (f *Fake) deliver(ctx context.Context, d *delivery) {
+	for {
+		select {
+		case <-ctx.Done():
+			f.mu.Lock()
+			if f.delivery == d {
+				f.delivery = nil
+				f.onEvent = nil
+			}
+			f.mu.Unlock()
+			return
+		case <-d.wake:
+		}
+
+		d.mu.Lock()
+		pending := d.pending
+		d.pending = nil
+		d.mu.Unlock()
+
+		for _, batch := range f.batchesFor(pending) {
+			f.send(ctx, d.client, batch)
+		}
+	}
+}
+
+// webhookBatch is the events bound for one webhook.
+type webhookBatch struct {
+	webhook Webhook
+	events  []Event
+}
+
+// batchesFor groups events by the webhooks that should receive them.
+func (f *Fake) batchesFor(events []*Event) []webhookBatch {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var batches []webhookBatch
+	for _, wh := range f.webhooks {
+		if !wh.Active {
+			continue
+		}
+		batch := webhookBatch{webhook: *wh}
+		for _, event := range events {
+			if slices.Contains(event.scopes, wh.Resource) && matchesFilters(event, wh.Filters) {
+				batch.events = append(batch.events, *event)
+			}
+		}
+		if len(batch.events) > 0 {
+			batches = append(batches, batch)
+		}
+	}
+	return batches
+}
+
+// matchesFilters reports whether an event passes any of a webhook's
+// filters. A webhook without filters receives every event.
+func matchesFilters(event *Event, filters []WebhookFilter) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, filter := range filters {
+		if filter.ResourceType != "" && filter.ResourceType != event.Resource.ResourceType {
+			continue
+		}
+		if filter.ResourceSubtype != "" && filter.ResourceSubtype != event.Resource.ResourceSubtype {
+			continue
+		}
+		if filter.Action != "" && filter.Action != event.Action {
+			continue
+		}
+		if len(filter.Fields) > 0 && (event.Change == nil || !slices.Contains(filter.Fields, event.Change.Field)) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// send POSTs a batch of events to its target, retrying with backoff, and
+// records the outcome on the webhook.
+func (f *Fake) send(ctx context.Context, httpClient *http.Client, batch webhookBatch) {
+	body, err := json.Marshal(map[string][]Event{"events": batch.events})
+	if err != nil {
+		return
+	}
+
+	var failure error
+	for attempt := range deliveryAttempts {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(deliveryBackoff << (attempt - 1)):
+			}
+		}
+
+		_, failure = request(ctx, httpClient, batch.webhook.Target, body, map[string]string{
+			"X-Hook-Signature": Sign(batch.webhook.Secret, body),
+		})
+		if failure == nil {
+			break
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	wh := f.webhook(batch.webhook.GID)
+	if wh == nil {
+		return
+	}
+	if failure != nil {
+		wh.LastFailureAt = f.now()
+		wh.LastFailureContent = failure.Error()
+		wh.DeliveryRetryCount++
+		return
+	}
+	wh.LastSuccessAt = f.now()
+	wh.DeliveryRetryCount = 0
+}
+
+// handshake performs the X-Hook-Secret exchange with a new webhook's target
+// and returns the secret.
+func handshake(ctx context.Context, httpClient *http.Client, target string) (string, error) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	secret := hex.EncodeToString(key)
+
+	resp, err := request(ctx, httpClient, target, nil, map[string]string{"X-Hook-Secret": secret})
+	if err != nil {
+		return "", err
+	}
+	if resp.Header.Get("X-Hook-Secret") != secret {
+		return "", fmt.Errorf("target did not echo the X-Hook-Secret header")
+	}
+	return secret, nil
+}
+
+// request POSTs to a webhook target and fails unless it answers with a 2xx
+// status.
+func request(ctx context.Context, httpClient *http.Client, target string, body []byte, headers map[string]string) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("target responded with %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// Sign returns the X-Hook-Signature for a delivery body: the hex-encoded
+// HMAC-SHA256 of the body keyed with the webhook's secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,264 @@
+// Package cassette records Asana API interactions to files and replays them,
+// so commands can be tested deterministically without network access.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/octoberswimmer/utka/client"
+	"gopkg.in/yaml.v3"
+)
+
+// recordedHeaders are the response headers kept in a cassette. Everything else,
+// including all request headers and therefore the Authorization header, is
+// dropped.
+var recordedHeaders = []string{"Content-Type", "Retry-After", "X-Request-Id"}
+
+// Cassette is a sequence of recorded HTTP interactions.
+type Cassette struct {
+	Interactions []*Interaction `yaml:"interactions"`
+
+	path      string
+	sanitizer *sanitizer
+	mu        sync.Mutex
+}
+
+type Interaction struct {
+	Request  Request  `yaml:"request"`
+	Response Response `yaml:"response"`
+
+	replayed bool
+}
+
+type Request struct {
+	Method string `yaml:"method"`
+	// URL is the request path and query, without scheme and host, so a
+	// cassette replays against any base URL with the same path.
+	URL  string `yaml:"url"`
+	Body string `yaml:"body,omitempty"`
+}
+
+type Response struct {
+	Status  int               `yaml:"status"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    string            `yaml:"body,omitempty"`
+}
+
+// New returns an empty cassette that will be written to path.
+func New(path string) *Cassette {
+	return &Cassette{path: path, sanitizer: newSanitizer()}
+}
+
+// Load reads a recorded cassette.
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	c := New(path)
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Save writes the cassette to its file.
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.save()
+}
+
+func (c *Cassette) save() error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// Recorder returns middleware that passes requests through and appends each
+// interaction to the cassette, sanitized, saving the file after every one so
+// that a recording survives the process exiting early.
+func (c *Cassette) Recorder() client.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return client.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			reqBody, err := readRequestBody(req)
+			if err != nil {
+				return nil, err
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+
+			respBody, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			interaction := &Interaction{
+				Request: Request{
+					Method: req.Method,
+					URL:    c.sanitizer.text(req.URL.RequestURI()),
+					Body:   c.sanitizer.body(reqBody, req.Header.Get("Content-Type")),
+				},
+				Response: Response{
+					Status: resp.StatusCode,
+					Body:   c.sanitizer.body(respBody, resp.Header.Get("Content-Type")),
+				},
+			}
+			for _, name := range recordedHeaders {
+				if value := resp.Header.Get(name); value != "" {
+					if interaction.Response.Headers == nil {
+						interaction.Response.Headers = map[string]string{}
+					}
+					interaction.Response.Headers[name] = value
+				}
+			}
+
+			c.Interactions = append(c.Interactions, interaction)
+			if err := c.save(); err != nil {
+				return nil, err
+			}
+
+			return resp, nil
+		})
+	}
+}
+
+// Player returns middleware that answers requests from the cassette instead
+// of sending them. Each request is sanitized as it would have been recorded
+// and matched to the first interaction not yet replayed with the same method,
+// URL and body; requests without a match fail.
+func (c *Cassette) Player() client.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return client.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			reqBody, err := readRequestBody(req)
+			if err != nil {
+				return nil, err
+			}
+
+			// Requests are compared in the form they were recorded in
+			c.mu.Lock()
+			uri := c.sanitizer.text(req.URL.RequestURI())
+			body := c.sanitizer.body(reqBody, req.Header.Get("Content-Type"))
+			interaction := c.match(req.Method, uri, body)
+			c.mu.Unlock()
+
+			if interaction == nil {
+				return nil, fmt.Errorf("no recorded interaction for %s %s in cassette %s", req.Method, req.URL.RequestURI(), c.path)
+			}
+
+			header := http.Header{}
+			for name, value := range interaction.Response.Headers {
+				header.Set(name, value)
+			}
+
+			return &http.Response{
+				Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+				StatusCode:    interaction.Response.Status,
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				Header:        header,
+				Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+				ContentLength: int64(len(interaction.Response.Body)),
+				Request:       req,
+			}, nil
+		})
+	}
+}
+
+func (c *Cassette) match(method, uri, body string) *Interaction {
+	for _, interaction := range c.Interactions {
+		if interaction.replayed || interaction.Request.Method != method {
+			continue
+		}
+		if !sameURL(interaction.Request.URL, uri) || !sameBody(interaction.Request.Body, body) {
+			continue
+		}
+		interaction.replayed = true
+		return interaction
+	}
+	return nil
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// sameURL compares sanitized request URIs ignoring the order of query
+// parameters and the numbers of placeholders.
+func sameURL(recorded, uri string) bool {
+	recordedPath, recordedQuery, _ := strings.Cut(anyPlaceholder(recorded), "?")
+	path, query, _ := strings.Cut(anyPlaceholder(uri), "?")
+	return recordedPath == path && normalizeQuery(recordedQuery) == normalizeQuery(query)
+}
+
+// sameBody compares sanitized request bodies ignoring the numbers of
+// placeholders, treating JSON bodies as equal when they decode to the same
+// value.
+func sameBody(recorded, body string) bool {
+	recorded, body = anyPlaceholder(recorded), anyPlaceholder(body)
+	if recorded == body {
+		return true
+	}
+
+	var a, b interface{}
+	if json.Unmarshal([]byte(recorded), &a) != nil || json.Unmarshal([]byte(body), &b) != nil {
+		return false
+	}
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return bytes.Equal(ja, jb)
+}
+
+func normalizeQuery(query string) string {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return query
+	}
+	return values.Encode()
+}
@@ -0,0 +1,162 @@
+package cassette
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/octoberswimmer/utka/client"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "req-1")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/tasks/1":
+			w.Write([]byte(`{"data":{"gid":"1","name":"Write report","assignee":{"gid":"2","name":"Jane Doe","resource_type":"user"},"notes":"Ping jane@acme.io"}}`))
+		case r.Method == "GET" && r.URL.Path == "/users/me":
+			w.Write([]byte(`{"data":{"gid":"2","name":"Jane Doe","email":"jane@acme.io"}}`))
+		case r.Method == "PUT" && r.URL.Path == "/tasks/1":
+			w.Write([]byte(`{"data":{"gid":"1","completed":true}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"message":"Not Found"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassettes", "tasks.yaml")
+
+	recording := New(path)
+	c := client.NewClient("secret_token")
+	c.SetBaseURL(server.URL)
+	c.Use(recording.Recorder())
+
+	if _, err := c.Get("/tasks/1", url.Values{"opt_fields": {"name,assignee.name"}, "limit": {"1"}}); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if _, err := c.Get("/users/me", nil); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if _, err := c.Put("/tasks/1", map[string]interface{}{"data": map[string]bool{"completed": true}}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if _, err := c.Get("/missing", nil); !client.IsNotFound(err) {
+		t.Fatalf("Expected a 404 error, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Cassette not written: %v", err)
+	}
+	for _, secret := range []string{"secret_token", "Jane Doe", "jane@acme.io"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Cassette contains %q:\n%s", secret, data)
+		}
+	}
+	// The same person gets the same placeholder in every interaction
+	if strings.Count(string(data), "User 1") != 2 || strings.Count(string(data), "user1@example.com") != 2 {
+		t.Errorf("Expected consistent placeholders:\n%s", data)
+	}
+	server.Close()
+
+	replay, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	c = client.NewClient("other_token")
+	c.SetBaseURL(server.URL)
+	c.SetRetryPolicy(nil)
+	c.Use(replay.Player())
+
+	// Query parameters in a different order still match
+	body, err := c.Get("/tasks/1", url.Values{"limit": {"1"}, "opt_fields": {"name,assignee.name"}})
+	if err != nil {
+		t.Fatalf("Replayed Get() error = %v", err)
+	}
+	if !strings.Contains(string(body), "Write report") || !strings.Contains(string(body), "User 1") {
+		t.Errorf("Replayed body = %s", body)
+	}
+
+	if _, err := c.Put("/tasks/1", map[string]interface{}{"data": map[string]bool{"completed": false}}); err == nil {
+		t.Error("Expected no match for a request with a different body")
+	}
+	if _, err := c.Put("/tasks/1", map[string]interface{}{"data": map[string]bool{"completed": true}}); err != nil {
+		t.Errorf("Replayed Put() error = %v", err)
+	}
+	if _, err := c.Get("/missing", nil); !client.IsNotFound(err) {
+		t.Errorf("Expected the recorded 404, got %v", err)
+	}
+
+	// Each interaction is replayed once
+	if _, err := c.Get("/users/me", nil); err != nil {
+		t.Errorf("Replayed Get() error = %v", err)
+	}
+	if _, err := c.Get("/users/me", nil); err == nil {
+		t.Error("Expected an error once the interaction was used up")
+	}
+}
+
+func TestReplaySanitizedRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/users/me":
+			w.Write([]byte(`{"data":{"gid":"1","name":"Bob Roe","email":"bob@acme.io"}}`))
+		default:
+			w.Write([]byte(`{"data":{"gid":"2"}}`))
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "users.yaml")
+	recording := New(path)
+	c := client.NewClient("secret_token")
+	c.SetBaseURL(server.URL)
+	c.Use(recording.Recorder())
+
+	// Bob is seen first, in a response, so Jane gets the second placeholder
+	// while recording but would get the first on replay
+	requests := func(c *client.Client) error {
+		if _, err := c.Get("/users/me", nil); err != nil {
+			return err
+		}
+		if _, err := c.Get("/users/jane@acme.io", url.Values{"workspace": {"9"}}); err != nil {
+			return err
+		}
+		_, err := c.Post("/tasks", map[string]interface{}{"data": map[string]interface{}{
+			"notes":    "Ask jane@acme.io",
+			"assignee": map[string]string{"gid": "2", "name": "Jane Doe", "resource_type": "user"},
+		}})
+		return err
+	}
+	if err := requests(c); err != nil {
+		t.Fatalf("Recording error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "jane@acme.io") || strings.Contains(string(data), "Jane Doe") {
+		t.Fatalf("Cassette contains personal data:\n%s", data)
+	}
+	server.Close()
+
+	replay, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	c = client.NewClient("other_token")
+	c.SetBaseURL(server.URL)
+	c.SetRetryPolicy(nil)
+	c.Use(replay.Player())
+	if err := requests(c); err != nil {
+		t.Errorf("Replay error = %v", err)
+	}
+}
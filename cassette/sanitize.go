@@ -0,0 +1,143 @@
+package cassette
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/octoberswimmer/utka/client"
+)
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+// placeholderPattern matches the placeholders the sanitizer writes, also in
+// URL-encoded form.
+var placeholderPattern = regexp.MustCompile(`\b(user)\d+((?:@|%40)example\.com)\b|\b(User) \d+\b`)
+
+// sanitizer scrubs personal data and secrets from recorded interactions. Each
+// distinct email address and user name is replaced by the same placeholder
+// throughout a cassette, so relationships between records survive.
+type sanitizer struct {
+	emails map[string]string
+	names  map[string]string
+}
+
+func newSanitizer() *sanitizer {
+	return &sanitizer{emails: map[string]string{}, names: map[string]string{}}
+}
+
+// text replaces email addresses in s.
+func (s *sanitizer) text(str string) string {
+	str = emailPattern.ReplaceAllStringFunc(str, s.email)
+
+	// Emails may also appear URL-encoded in query strings
+	if strings.Contains(str, "%40") {
+		if unescaped, err := url.QueryUnescape(str); err == nil && emailPattern.MatchString(unescaped) {
+			for _, email := range emailPattern.FindAllString(unescaped, -1) {
+				str = strings.ReplaceAll(str, url.QueryEscape(email), url.QueryEscape(s.email(email)))
+			}
+		}
+	}
+	return str
+}
+
+func (s *sanitizer) email(email string) string {
+	if strings.HasSuffix(email, "@example.com") {
+		return email
+	}
+	if placeholder, ok := s.emails[email]; ok {
+		return placeholder
+	}
+	placeholder := fmt.Sprintf("user%d@example.com", len(s.emails)+1)
+	s.emails[email] = placeholder
+	return placeholder
+}
+
+func (s *sanitizer) name(name string) string {
+	if placeholder, ok := s.names[name]; ok {
+		return placeholder
+	}
+	placeholder := fmt.Sprintf("User %d", len(s.names)+1)
+	s.names[name] = placeholder
+	return placeholder
+}
+
+// body sanitizes a request or response body. Secrets such as OAuth tokens
+// and webhook secrets are redacted, the names of users (objects in user
+// fields, with resource_type "user" or with an email) are replaced, and
+// emails are replaced anywhere.
+func (s *sanitizer) body(body []byte, contentType string) string {
+	if len(body) == 0 {
+		return ""
+	}
+	body = client.RedactBody(body, contentType)
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return s.text(string(body))
+	}
+	s.value(v, false)
+
+	// Indented so cassettes are easy to read and edit by hand
+	clean, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return s.text(string(body))
+	}
+	return string(clean)
+}
+
+// userFields hold users (or lists of users) in Asana objects, whose compact
+// form may carry only a gid and name.
+var userFields = map[string]bool{
+	"assignee":     true,
+	"completed_by": true,
+	"created_by":   true,
+	"followers":    true,
+	"members":      true,
+	"owner":        true,
+	"user":         true,
+}
+
+// value sanitizes a decoded JSON value in place. user is set for values held
+// in a user field.
+func (s *sanitizer) value(v interface{}, user bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		_, hasEmail := v["email"]
+		isUser := user || hasEmail || v["resource_type"] == "user"
+
+		// Visit keys in order so placeholders are numbered deterministically
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if name, ok := v[key].(string); ok && key == "name" && isUser && name != "" {
+				v[key] = s.name(name)
+				continue
+			}
+			v[key] = s.value(v[key], userFields[key])
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = s.value(v[i], user)
+		}
+		return v
+	case string:
+		return s.text(v)
+	}
+	return v
+}
+
+// anyPlaceholder removes the numbers from placeholders. They follow the order
+// values were first seen while recording, including in responses, so a
+// replayed request may get a different number for the same value.
+func anyPlaceholder(str string) string {
+	return placeholderPattern.ReplaceAllString(str, "$1$2$3")
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaxBatchActions is the most actions Asana accepts in a single /batch request.
+const MaxBatchActions = 10
+
+type BatchAction struct {
+	RelativePath string                 `json:"relative_path"`
+	Method       string                 `json:"method"`
+	Data         interface{}            `json:"data,omitempty"`
+	Options      map[string]interface{} `json:"options,omitempty"`
+}
+
+// BatchResult is the outcome of a single action. Err is set when the action
+// failed, either because Asana rejected it or because its batch request
+// could not be sent.
+type BatchResult struct {
+	Action     BatchAction       `json:"-"`
+	StatusCode int               `json:"status_code"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       json.RawMessage   `json:"body,omitempty"`
+	Err        error             `json:"-"`
+}
+
+// Batch accumulates actions for Asana's /batch endpoint.
+type Batch struct {
+	client  *Client
+	actions []BatchAction
+}
+
+func (c *Client) NewBatch() *Batch {
+	return &Batch{client: c}
+}
+
+// Add queues an action. relativePath is relative to the API base URL, e.g.
+// "/tasks/123", and data is sent as the action's "data" object.
+func (b *Batch) Add(method, relativePath string, data interface{}) *Batch {
+	return b.AddAction(BatchAction{
+		RelativePath: relativePath,
+		Method:       strings.ToLower(method),
+		Data:         data,
+	})
+}
+
+func (b *Batch) AddAction(action BatchAction) *Batch {
+	b.actions = append(b.actions, action)
+	return b
+}
+
+func (b *Batch) Len() int {
+	return len(b.actions)
+}
+
+// Execute sends the queued actions in chunks of MaxBatchActions and returns
+// one result per action, in the order the actions were added. A failing
+// action or chunk does not stop the remaining chunks from being sent; the
+// failure is recorded on the affected results instead. The returned error is
+// only set when ctx is done before every chunk was sent.
+func (b *Batch) Execute(ctx context.Context) ([]BatchResult, error) {
+	results := make([]BatchResult, 0, len(b.actions))
+
+	for start := 0; start < len(b.actions); start += MaxBatchActions {
+		end := min(start+MaxBatchActions, len(b.actions))
+		chunk := b.actions[start:end]
+
+		if err := ctx.Err(); err != nil {
+			for _, action := range b.actions[start:] {
+				results = append(results, BatchResult{Action: action, Err: err})
+			}
+			return results, err
+		}
+
+		results = append(results, b.executeChunk(ctx, chunk)...)
+	}
+
+	return results, nil
+}
+
+func (b *Batch) executeChunk(ctx context.Context, chunk []BatchAction) []BatchResult {
+	results := make([]BatchResult, len(chunk))
+	for i, action := range chunk {
+		results[i].Action = action
+	}
+
+	reqBody := map[string]interface{}{
+		"data": map[string]interface{}{
+			"actions": chunk,
+		},
+	}
+
+	respBody, err := b.client.PostContext(ctx, "/batch", reqBody)
+	if err != nil {
+		for i := range results {
+			results[i].Err = fmt.Errorf("batch request failed: %w", err)
+		}
+		return results
+	}
+
+	var response struct {
+		Data []BatchResult `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		for i := range results {
+			results[i].Err = fmt.Errorf("failed to parse batch response: %w", err)
+		}
+		return results
+	}
+
+	for i := range results {
+		if i >= len(response.Data) {
+			results[i].Err = fmt.Errorf("missing result for batch action %d", i)
+			continue
+		}
+
+		result := response.Data[i]
+		result.Action = chunk[i]
+		if result.StatusCode >= 400 {
+			result.Err = newAPIError(strings.ToUpper(result.Action.Method), result.Action.RelativePath, result.StatusCode, result.Headers["X-Request-Id"], result.Body)
+		}
+		results[i] = result
+	}
+
+	return results
+}
+
+// Decode unmarshals the "data" object of a successful action's body into v.
+func (r *BatchResult) Decode(v interface{}) error {
+	if r.Err != nil {
+		return r.Err
+	}
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(r.Body, &envelope); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -2,12 +2,13 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
+	"sync"
 	"time"
 )
 
@@ -15,10 +16,22 @@ const (
 	BaseURL = "https://app.asana.com/api/1.0"
 )
 
+// TokenRefresher obtains a new access token, for example by exchanging an
+// OAuth refresh token. It is called when a request is rejected with 401.
+type TokenRefresher func(ctx context.Context) (string, error)
+
 type Client struct {
-	httpClient  *http.Client
-	accessToken string
-	baseURL     string
+	httpClient     *http.Client
+	accessToken    string
+	baseURL        string
+	retryPolicy    *RetryPolicy
+	tokenRefresher TokenRefresher
+	middleware     []Middleware
+	limiter        *Limiter
+
+	// tokenMu guards accessToken, which may be replaced by a refresh while
+	// other goroutines are sending requests.
+	tokenMu sync.Mutex
 }
 
 func NewClient(accessToken string) *Client {
@@ -28,113 +41,162 @@ func NewClient(accessToken string) *Client {
 		},
 		accessToken: accessToken,
 		baseURL:     BaseURL,
+		retryPolicy: DefaultRetryPolicy(),
+		limiter:     NewLimiter(DefaultLimits()),
 	}
 }
 
-func (c *Client) doRequest(method, endpoint string, params url.Values, body interface{}) ([]byte, error) {
-	fullURL := c.baseURL + endpoint
-	if params != nil && len(params) > 0 {
-		fullURL = fullURL + "?" + params.Encode()
-	}
-
-	var reqBody io.Reader
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, params url.Values, body interface{}) ([]byte, error) {
+	var payload []byte
 	if body != nil {
 		jsonBody, err := json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		reqBody = bytes.NewBuffer(jsonBody)
+		payload = jsonBody
 	}
 
-	req, err := http.NewRequest(method, fullURL, reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	contentType := ""
 	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
+		contentType = "application/json"
 	}
-	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
+	return c.do(ctx, method, endpoint, params, payload, contentType)
+}
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+// do sends the request, retrying it according to the client's retry policy.
+// The payload is kept as bytes so that it can be replayed on every attempt.
+// Waiting between attempts is abandoned as soon as ctx is done.
+func (c *Client) do(ctx context.Context, method, endpoint string, params url.Values, payload []byte, contentType string) ([]byte, error) {
+	fullURL := c.baseURL + endpoint
+	if params != nil && len(params) > 0 {
+		fullURL = fullURL + "?" + params.Encode()
 	}
 
-	if resp.StatusCode >= 400 {
-		var errorResp struct {
-			Errors []struct {
-				Message string `json:"message"`
-			} `json:"errors"`
+	start := time.Now()
+	refreshed := false
+	for attempt := 1; ; attempt++ {
+		var reqBody io.Reader
+		if payload != nil {
+			reqBody = bytes.NewReader(payload)
 		}
-		if err := json.Unmarshal(respBody, &errorResp); err == nil && len(errorResp.Errors) > 0 {
-			return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errorResp.Errors[0].Message)
+
+		req, err := http.NewRequestWithContext(ctx, method, fullURL, reqBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
 		}
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
-	}
 
-	return respBody, nil
-}
+		token := c.GetAccessToken()
+		req.Header.Set("Authorization", "Bearer "+token)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("Accept", "application/json")
 
-func (c *Client) Get(endpoint string, params url.Values) ([]byte, error) {
-	return c.doRequest("GET", endpoint, params, nil)
-}
+		var release func()
+		if c.limiter != nil {
+			if release, err = c.limiter.Wait(ctx, method, endpoint, payload); err != nil {
+				return nil, err
+			}
+		}
+		resp, respBody, err := c.send(req)
+		if release != nil {
+			release()
+		}
+		if err == nil && resp.StatusCode < 400 {
+			return respBody, nil
+		}
+		if err == nil {
+			err = newAPIError(method, endpoint, resp.StatusCode, resp.Header.Get("X-Request-Id"), respBody)
+		}
 
-func (c *Client) Post(endpoint string, body interface{}) ([]byte, error) {
-	return c.doRequest("POST", endpoint, nil, body)
-}
+		// An expired access token is refreshed once per request and the
+		// request is sent again straight away
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized && !refreshed && c.tokenRefresher != nil {
+			refreshed = true
+			if refreshErr := c.refreshAccessToken(ctx, token); refreshErr != nil {
+				return nil, fmt.Errorf("%w (token refresh failed: %v)", err, refreshErr)
+			}
+			continue
+		}
 
-func (c *Client) PostForm(endpoint string, formData url.Values) ([]byte, error) {
-	fullURL := c.baseURL + endpoint
+		// Other requests with the same token would be rejected too
+		if resp != nil && resp.StatusCode == http.StatusTooManyRequests && c.limiter != nil {
+			if retryAfter, ok := ParseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				c.limiter.Pause(retryAfter)
+			}
+		}
 
-	req, err := http.NewRequest("POST", fullURL, strings.NewReader(formData.Encode()))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+		delay, retry := c.retryPolicy.backoff(method, attempt, time.Since(start), resp)
+		if !retry || ctx.Err() != nil {
+			return nil, err
+		}
 
-	req.Header.Set("Authorization", "Bearer "+c.accessToken)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Accept", "application/json")
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
 
-	resp, err := c.httpClient.Do(req)
+// send performs a single HTTP round trip and reads the whole response body.
+// A nil response is returned when the request never produced a usable reply.
+func (c *Client) send(req *http.Request) (*http.Response, []byte, error) {
+	resp, err := c.transport().Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	if resp.StatusCode >= 400 {
-		var errorResp struct {
-			Errors []struct {
-				Message string `json:"message"`
-			} `json:"errors"`
-		}
-		if err := json.Unmarshal(respBody, &errorResp); err == nil && len(errorResp.Errors) > 0 {
-			return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errorResp.Errors[0].Message)
-		}
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
-	}
+	return resp, respBody, nil
+}
+
+func (c *Client) Get(endpoint string, params url.Values) ([]byte, error) {
+	return c.GetContext(context.Background(), endpoint, params)
+}
+
+func (c *Client) GetContext(ctx context.Context, endpoint string, params url.Values) ([]byte, error) {
+	return c.doRequest(ctx, "GET", endpoint, params, nil)
+}
 
-	return respBody, nil
+func (c *Client) Post(endpoint string, body interface{}) ([]byte, error) {
+	return c.PostContext(context.Background(), endpoint, body)
+}
+
+func (c *Client) PostContext(ctx context.Context, endpoint string, body interface{}) ([]byte, error) {
+	return c.doRequest(ctx, "POST", endpoint, nil, body)
+}
+
+func (c *Client) PostForm(endpoint string, formData url.Values) ([]byte, error) {
+	return c.PostFormContext(context.Background(), endpoint, formData)
+}
+
+func (c *Client) PostFormContext(ctx context.Context, endpoint string, formData url.Values) ([]byte, error) {
+	return c.do(ctx, "POST", endpoint, nil, []byte(formData.Encode()), "application/x-www-form-urlencoded")
 }
 
 func (c *Client) Put(endpoint string, body interface{}) ([]byte, error) {
-	return c.doRequest("PUT", endpoint, nil, body)
+	return c.PutContext(context.Background(), endpoint, body)
+}
+
+func (c *Client) PutContext(ctx context.Context, endpoint string, body interface{}) ([]byte, error) {
+	return c.doRequest(ctx, "PUT", endpoint, nil, body)
 }
 
 func (c *Client) Delete(endpoint string) ([]byte, error) {
-	return c.doRequest("DELETE", endpoint, nil, nil)
+	return c.DeleteContext(context.Background(), endpoint)
+}
+
+func (c *Client) DeleteContext(ctx context.Context, endpoint string) ([]byte, error) {
+	return c.doRequest(ctx, "DELETE", endpoint, nil, nil)
 }
 
 func (c *Client) GetHTTPClient() *http.Client {
@@ -142,6 +204,8 @@ func (c *Client) GetHTTPClient() *http.Client {
 }
 
 func (c *Client) GetAccessToken() string {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
 	return c.accessToken
 }
 
@@ -154,9 +218,54 @@ func (c *Client) SetBaseURL(url string) {
 }
 
 func (c *Client) SetAccessToken(token string) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	c.accessToken = token
+}
+
+// SetTokenRefresher installs a function used to replace the access token
+// when the API rejects it with 401 Unauthorized.
+func (c *Client) SetTokenRefresher(refresher TokenRefresher) {
+	c.tokenRefresher = refresher
+}
+
+// refreshAccessToken replaces stale with a fresh token. Concurrent callers
+// holding the same stale token trigger a single refresh.
+func (c *Client) refreshAccessToken(ctx context.Context, stale string) error {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.accessToken != stale {
+		return nil
+	}
+
+	token, err := c.tokenRefresher(ctx)
+	if err != nil {
+		return err
+	}
 	c.accessToken = token
+	return nil
 }
 
 func (c *Client) SetHTTPClient(httpClient *http.Client) {
 	c.httpClient = httpClient
 }
+
+func (c *Client) GetRetryPolicy() *RetryPolicy {
+	return c.retryPolicy
+}
+
+// SetRetryPolicy replaces the retry policy. A nil policy disables retries.
+func (c *Client) SetRetryPolicy(policy *RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+func (c *Client) GetLimiter() *Limiter {
+	return c.limiter
+}
+
+// SetLimiter replaces the client-side rate limiter. A limiter may be shared
+// by clients using the same token; nil disables client-side throttling.
+func (c *Client) SetLimiter(limiter *Limiter) {
+	c.limiter = limiter
+}
@@ -0,0 +1,851 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testRetryPolicy() *RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 5 * time.Millisecond
+	return policy
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		statuses     []int
+		retryAfter   string
+		wantRequests int
+		wantErr      bool
+	}{
+		{
+			name:         "success on first attempt",
+			method:       "GET",
+			statuses:     []int{200},
+			wantRequests: 1,
+			wantErr:      false,
+		},
+		{
+			name:         "rate limited then success",
+			method:       "GET",
+			statuses:     []int{429, 200},
+			retryAfter:   "0",
+			wantRequests: 2,
+			wantErr:      false,
+		},
+		{
+			name:         "rate limited POST is retried",
+			method:       "POST",
+			statuses:     []int{429, 200},
+			retryAfter:   "0",
+			wantRequests: 2,
+			wantErr:      false,
+		},
+		{
+			name:         "server errors then success",
+			method:       "GET",
+			statuses:     []int{503, 502, 200},
+			wantRequests: 3,
+			wantErr:      false,
+		},
+		{
+			name:         "server error on POST is not retried",
+			method:       "POST",
+			statuses:     []int{500, 200},
+			wantRequests: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "client error is not retried",
+			method:       "GET",
+			statuses:     []int{404, 200},
+			wantRequests: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "attempts exhausted",
+			method:       "PUT",
+			statuses:     []int{500, 500, 500, 500, 500, 500},
+			wantRequests: 5,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callCount := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tt.statuses[callCount]
+				callCount++
+				if status == http.StatusTooManyRequests && tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(status)
+				if status >= 400 {
+					w.Write([]byte(`{"errors":[{"message":"failure"}]}`))
+					return
+				}
+				w.Write([]byte(`{"data":{}}`))
+			}))
+			defer server.Close()
+
+			c := NewClient("test_token")
+			c.SetBaseURL(server.URL)
+			c.SetRetryPolicy(testRetryPolicy())
+
+			var err error
+			switch tt.method {
+			case "GET":
+				_, err = c.Get("/tasks", nil)
+			case "POST":
+				_, err = c.Post("/tasks", map[string]string{"name": "test"})
+			case "PUT":
+				_, err = c.Put("/tasks/1", map[string]string{"name": "test"})
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if callCount != tt.wantRequests {
+				t.Errorf("Expected %d requests, got %d", tt.wantRequests, callCount)
+			}
+		})
+	}
+}
+
+func TestRetryReplaysBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		bodies = append(bodies, r.PostForm.Encode())
+		if len(bodies) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	c := NewClient("test_token")
+	c.SetBaseURL(server.URL)
+	c.SetRetryPolicy(testRetryPolicy())
+
+	form := url.Values{}
+	form.Set("resource", "123")
+	if _, err := c.PostForm("/webhooks", form); err != nil {
+		t.Fatalf("PostForm() error = %v", err)
+	}
+
+	if len(bodies) != 2 || bodies[0] != bodies[1] || bodies[1] != "resource=123" {
+		t.Errorf("Expected identical bodies on both attempts, got %q", bodies)
+	}
+}
+
+func TestRetryDisabled(t *testing.T) {
+	callCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := &Client{}
+	c.SetBaseURL(server.URL)
+	c.SetHTTPClient(http.DefaultClient)
+
+	if _, err := c.Get("/tasks", nil); err == nil {
+		t.Error("Expected an error")
+	}
+	if callCount != 1 {
+		t.Errorf("Expected 1 request, got %d", callCount)
+	}
+}
+
+func TestRetryMaxElapsedTime(t *testing.T) {
+	callCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	c := NewClient("test_token")
+	c.SetBaseURL(server.URL)
+	policy := testRetryPolicy()
+	policy.MaxElapsedTime = time.Second
+	c.SetRetryPolicy(policy)
+
+	if _, err := c.Get("/tasks", nil); err == nil {
+		t.Error("Expected an error")
+	}
+	if callCount != 1 {
+		t.Errorf("Expected 1 request, got %d", callCount)
+	}
+}
+
+func TestRetryStopsWhenContextDone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	c := NewClient("test_token")
+	c.SetBaseURL(server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.GetContext(ctx, "/tasks", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Request took %v after the context expired", elapsed)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "seconds", value: "30", want: 30 * time.Second, wantOK: true},
+		{name: "empty", value: "", wantOK: false},
+		{name: "negative", value: "-1", wantOK: false},
+		{name: "garbage", value: "soon", wantOK: false},
+		{name: "date in the past", value: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseRetryAfter(tt.value)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseRetryAfter(%q) ok = %v, want %v", tt.value, ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("ParseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "req-123")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[{"message":"task: Unknown object","help":"See docs","phrase":"6 sad squid"},{"message":"second"}]}`))
+	}))
+	defer server.Close()
+
+	c := NewClient("test_token")
+	c.SetBaseURL(server.URL)
+
+	_, err := c.Get("/tasks/123", nil)
+	wrapped := fmt.Errorf("failed to get task: %w", err)
+
+	apiErr, ok := AsAPIError(wrapped)
+	if !ok {
+		t.Fatalf("Expected an *APIError, got %T", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, http.StatusNotFound)
+	}
+	if apiErr.Method != "GET" || apiErr.Path != "/tasks/123" {
+		t.Errorf("Request = %s %s, want GET /tasks/123", apiErr.Method, apiErr.Path)
+	}
+	if apiErr.RequestID != "req-123" {
+		t.Errorf("RequestID = %q, want %q", apiErr.RequestID, "req-123")
+	}
+	if len(apiErr.Errors) != 2 || apiErr.Errors[0].Help != "See docs" || apiErr.Errors[0].Phrase != "6 sad squid" {
+		t.Errorf("Errors not properly parsed: %+v", apiErr.Errors)
+	}
+	if want := "API error (404): task: Unknown object; second"; apiErr.Error() != want {
+		t.Errorf("Error() = %q, want %q", apiErr.Error(), want)
+	}
+
+	if !IsNotFound(wrapped) {
+		t.Error("IsNotFound() = false, want true")
+	}
+	if IsRateLimited(wrapped) || IsSyncTokenExpired(wrapped) || IsUnauthorized(wrapped) {
+		t.Error("Unexpected helper match for a 404")
+	}
+	if IsNotFound(errors.New("API error (404)")) {
+		t.Error("IsNotFound() matched a plain error")
+	}
+}
+
+func TestPaginator(t *testing.T) {
+	pages := map[string]string{
+		"":  `{"data":[{"gid":"1"},{"gid":"2"}],"next_page":{"offset":"a"}}`,
+		"a": `{"data":[{"gid":"3"},{"gid":"4"}],"next_page":{"offset":"b"}}`,
+		"b": `{"data":[{"gid":"5"}],"next_page":null}`,
+	}
+
+	type item struct {
+		GID string `json:"gid"`
+	}
+
+	tests := []struct {
+		name         string
+		maxItems     int
+		stopAfter    int
+		wantGIDs     []string
+		wantRequests int
+		wantLimits   []string
+	}{
+		{
+			name:         "all pages",
+			wantGIDs:     []string{"1", "2", "3", "4", "5"},
+			wantRequests: 3,
+			wantLimits:   []string{"100", "100", "100"},
+		},
+		{
+			name:         "item cap truncates and stops",
+			maxItems:     3,
+			wantGIDs:     []string{"1", "2", "3"},
+			wantRequests: 2,
+			wantLimits:   []string{"3", "1"},
+		},
+		{
+			name:         "early termination",
+			stopAfter:    1,
+			wantGIDs:     []string{"1"},
+			wantRequests: 1,
+			wantLimits:   []string{"100"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var limits []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				limits = append(limits, r.URL.Query().Get("limit"))
+				if r.URL.Query().Get("project") != "p1" {
+					t.Errorf("Filter param lost on request %d", len(limits))
+				}
+				w.Write([]byte(pages[r.URL.Query().Get("offset")]))
+			}))
+			defer server.Close()
+
+			c := NewClient("test_token")
+			c.SetBaseURL(server.URL)
+
+			params := url.Values{}
+			params.Set("project", "p1")
+			paginator := NewPaginator[item](c, "/tasks", params)
+			paginator.MaxItems = tt.maxItems
+
+			var gids []string
+			for it, err := range paginator.All(context.Background()) {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				gids = append(gids, it.GID)
+				if tt.stopAfter > 0 && len(gids) == tt.stopAfter {
+					break
+				}
+			}
+
+			if fmt.Sprint(gids) != fmt.Sprint(tt.wantGIDs) {
+				t.Errorf("Got %v, want %v", gids, tt.wantGIDs)
+			}
+			if len(limits) != tt.wantRequests {
+				t.Errorf("Expected %d requests, got %d", tt.wantRequests, len(limits))
+			}
+			if fmt.Sprint(limits) != fmt.Sprint(tt.wantLimits) {
+				t.Errorf("Limits = %v, want %v", limits, tt.wantLimits)
+			}
+			if params.Get("offset") != "" {
+				t.Error("Paginator modified the caller's params")
+			}
+		})
+	}
+}
+
+func TestPaginatorError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") == "" {
+			w.Write([]byte(`{"data":[{"gid":"1"}],"next_page":{"offset":"a"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors":[{"message":"Forbidden"}]}`))
+	}))
+	defer server.Close()
+
+	c := NewClient("test_token")
+	c.SetBaseURL(server.URL)
+
+	items, err := NewPaginator[map[string]string](c, "/tasks", nil).Collect(context.Background())
+	if !IsForbidden(err) {
+		t.Errorf("Expected a 403 error, got %v", err)
+	}
+	if items != nil {
+		t.Errorf("Expected no items on error, got %v", items)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	var chunkSizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/batch" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		var req struct {
+			Data struct {
+				Actions []BatchAction `json:"actions"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode batch request: %v", err)
+		}
+		chunkSizes = append(chunkSizes, len(req.Data.Actions))
+
+		var results []map[string]interface{}
+		for _, action := range req.Data.Actions {
+			if action.RelativePath == "/tasks/missing" {
+				results = append(results, map[string]interface{}{
+					"status_code": 404,
+					"body":        map[string]interface{}{"errors": []map[string]string{{"message": "Unknown object"}}},
+				})
+				continue
+			}
+			results = append(results, map[string]interface{}{
+				"status_code": 200,
+				"body":        map[string]interface{}{"data": map[string]string{"gid": strings.TrimPrefix(action.RelativePath, "/tasks/")}},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": results})
+	}))
+	defer server.Close()
+
+	c := NewClient("test_token")
+	c.SetBaseURL(server.URL)
+
+	batch := c.NewBatch()
+	for i := 0; i < 12; i++ {
+		path := fmt.Sprintf("/tasks/%d", i)
+		if i == 3 {
+			path = "/tasks/missing"
+		}
+		batch.Add("PUT", path, map[string]bool{"completed": true})
+	}
+
+	results, err := batch.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if fmt.Sprint(chunkSizes) != "[10 2]" {
+		t.Errorf("Chunk sizes = %v, want [10 2]", chunkSizes)
+	}
+	if len(results) != 12 {
+		t.Fatalf("Got %d results, want 12", len(results))
+	}
+
+	for i, result := range results {
+		if i == 3 {
+			if !IsNotFound(result.Err) {
+				t.Errorf("Result %d: expected a 404 error, got %v", i, result.Err)
+			}
+			continue
+		}
+
+		var task struct {
+			GID string `json:"gid"`
+		}
+		if err := result.Decode(&task); err != nil {
+			t.Errorf("Result %d: Decode() error = %v", i, err)
+			continue
+		}
+		if task.GID != fmt.Sprint(i) {
+			t.Errorf("Result %d: gid = %q, results out of order", i, task.GID)
+		}
+	}
+}
+
+func TestTokenRefreshOnUnauthorized(t *testing.T) {
+	var authHeaders []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") != "Bearer fresh_token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors":[{"message":"Not Authorized"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	refreshes := 0
+	c := NewClient("stale_token")
+	c.SetBaseURL(server.URL)
+	c.SetTokenRefresher(func(ctx context.Context) (string, error) {
+		refreshes++
+		return "fresh_token", nil
+	})
+
+	if _, err := c.Get("/users/me", nil); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if refreshes != 1 {
+		t.Errorf("Expected 1 refresh, got %d", refreshes)
+	}
+	if fmt.Sprint(authHeaders) != "[Bearer stale_token Bearer fresh_token]" {
+		t.Errorf("Authorization headers = %v", authHeaders)
+	}
+	if c.GetAccessToken() != "fresh_token" {
+		t.Errorf("Access token = %q, want fresh_token", c.GetAccessToken())
+	}
+
+	// A refreshed token that is still rejected is reported, not refreshed again
+	c.SetAccessToken("revoked_token")
+	c.SetTokenRefresher(func(ctx context.Context) (string, error) {
+		refreshes++
+		return "revoked_token_2", nil
+	})
+	if _, err := c.Get("/users/me", nil); !IsUnauthorized(err) {
+		t.Errorf("Expected a 401 error, got %v", err)
+	}
+	if refreshes != 2 {
+		t.Errorf("Expected 2 refreshes, got %d", refreshes)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"via":"` + r.Header.Get("X-Via") + `"}}`))
+	}))
+	defer server.Close()
+
+	var order []string
+	tag := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name+" request")
+				req.Header.Add("X-Via", name)
+				resp, err := next.RoundTrip(req)
+				order = append(order, name+" response")
+				return resp, err
+			})
+		}
+	}
+
+	c := NewClient("test_token")
+	c.SetBaseURL(server.URL)
+	c.Use(tag("outer"), tag("inner"))
+
+	body, err := c.Get("/tasks", nil)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !strings.Contains(string(body), `"via":"outer"`) {
+		t.Errorf("Response = %s, expected the request to pass through the middleware", body)
+	}
+
+	want := "[outer request inner request inner response outer response]"
+	if fmt.Sprint(order) != want {
+		t.Errorf("Order = %v, want %s", order, want)
+	}
+}
+
+func TestDebugMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Hook-Secret", "hook_secret_value")
+		w.Header().Set("X-Request-Id", "req-123")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"data":{"gid":"1","name":"` + strings.Repeat("x", 100) + `"}}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		trace   bool
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "debug",
+			want:    []string{"--> POST " + server.URL + "/webhooks", `"target":"https://example.com"`, "<-- 201 Created POST"},
+			notWant: []string{"test_token", "hook_secret_value", "X-Request-Id", "client_secret_value"},
+		},
+		{
+			name:    "trace",
+			trace:   true,
+			want:    []string{"Authorization: [REDACTED]", "X-Hook-Secret: [REDACTED]", "X-Request-Id: req-123", `{"data":{"gid":"1"`, "bytes truncated"},
+			notWant: []string{"test_token", "hook_secret_value", "client_secret_value"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log strings.Builder
+			c := NewClient("test_token")
+			c.SetBaseURL(server.URL)
+			c.Use(DebugMiddleware(&log, DebugOptions{Trace: tt.trace, MaxBodySize: 80}))
+
+			body, err := c.Post("/webhooks", map[string]interface{}{
+				"data": map[string]string{"target": "https://example.com", "client_secret": "client_secret_value"},
+			})
+			if err != nil {
+				t.Fatalf("Post() error = %v", err)
+			}
+			if !strings.Contains(string(body), strings.Repeat("x", 100)) {
+				t.Errorf("Response body was not passed through intact: %s", body)
+			}
+
+			for _, s := range tt.want {
+				if !strings.Contains(log.String(), s) {
+					t.Errorf("Log does not contain %q:\n%s", s, log.String())
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(log.String(), s) {
+					t.Errorf("Log contains %q:\n%s", s, log.String())
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterRate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	c := NewClient("test_token")
+	c.SetBaseURL(server.URL)
+	// 20 requests per second, two at once
+	c.SetLimiter(NewLimiter(Limits{RequestsPerMinute: 1200, Burst: 2}))
+
+	start := time.Now()
+	for range 4 {
+		if _, err := c.Get("/tasks", nil); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+	}
+	// The burst goes out at once; the other two wait 50ms each
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("4 requests took %v, expected about 100ms", elapsed)
+	}
+
+	// A context that ends while waiting abandons the request
+	c.SetLimiter(NewLimiter(Limits{RequestsPerMinute: 1, Burst: 1}))
+	c.Get("/tasks", nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetContext(ctx, "/tasks", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the wait to end with the context, got %v", err)
+	}
+}
+
+func TestLimiterInFlight(t *testing.T) {
+	var mu sync.Mutex
+	inFlight := map[string]int{}
+	peak := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight[r.Method]++
+		peak[r.Method] = max(peak[r.Method], inFlight[r.Method])
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		inFlight[r.Method]--
+		mu.Unlock()
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	c := NewClient("test_token")
+	c.SetBaseURL(server.URL)
+	c.SetLimiter(NewLimiter(Limits{MaxInFlight: 3, MaxInFlightWrites: 1}))
+
+	var wg sync.WaitGroup
+	for i := range 12 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				c.Get("/tasks", nil)
+			} else {
+				c.Put("/tasks/1", map[string]interface{}{"data": map[string]bool{"completed": true}})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak["GET"]+peak["PUT"] > 3 || peak["GET"] > 3 {
+		t.Errorf("Peak concurrency = %v, want at most 3 requests", peak)
+	}
+	if peak["PUT"] != 1 {
+		t.Errorf("Peak concurrent writes = %d, want 1", peak["PUT"])
+	}
+}
+
+func TestLimiterPausesOnRateLimit(t *testing.T) {
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		times = append(times, time.Now())
+		if len(times) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	c := NewClient("test_token")
+	c.SetBaseURL(server.URL)
+	c.SetRetryPolicy(nil)
+
+	if _, err := c.Get("/tasks", nil); err == nil {
+		t.Fatal("Expected a 429")
+	}
+	// The next request waits out the Retry-After even without a retry policy
+	if _, err := c.Get("/tasks", nil); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if gap := times[1].Sub(times[0]); gap < 900*time.Millisecond {
+		t.Errorf("Second request sent after %v, expected it to wait for Retry-After", gap)
+	}
+}
+
+func TestDefaultCost(t *testing.T) {
+	tests := []struct {
+		method   string
+		endpoint string
+		payload  string
+		want     int
+	}{
+		{"GET", "/tasks/1", "", 1},
+		{"GET", "/workspaces/1/tasks/search", "", searchCost},
+		{"POST", "/batch", `{"data":{"actions":[{},{},{}]}}`, 3},
+		{"POST", "/batch", `not json`, 1},
+	}
+	for _, tt := range tests {
+		if got := DefaultCost(tt.method, tt.endpoint, []byte(tt.payload)); got != tt.want {
+			t.Errorf("DefaultCost(%s %s) = %d, want %d", tt.method, tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestFieldPresets(t *testing.T) {
+	presets := FieldPresets{
+		PresetMinimal: {"name"},
+		PresetDefault: {"name", "assignee.name"},
+	}
+
+	tests := []struct {
+		opts []Options
+		want string
+	}{
+		{nil, "name,assignee.name"},
+		{[]Options{{}}, "name,assignee.name"},
+		{[]Options{{Fields: []string{"minimal"}}}, "name"},
+		{[]Options{{Fields: []string{"default", "custom_fields.number_value", "name"}}}, "name,assignee.name,custom_fields.number_value"},
+		{[]Options{{Fields: ParseFields(" notes, ,due_on ")}}, "notes,due_on"},
+	}
+	for _, tt := range tests {
+		if got := presets.OptFields(PresetDefault, tt.opts...); got != tt.want {
+			t.Errorf("OptFields(%v) = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+// extraResource and extraField keep unknown fields the way the managers'
+// types do, with a nested list like a task's custom_fields.
+type extraResource struct {
+	GID          string                     `json:"gid"`
+	Name         string                     `json:"name,omitempty"`
+	CustomFields []extraField               `json:"custom_fields,omitempty"`
+	Extra        map[string]json.RawMessage `json:"-"`
+}
+
+func (r *extraResource) UnmarshalJSON(data []byte) error {
+	type plain extraResource
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+	extra, err := UnknownFields(data, plain{})
+	r.Extra = extra
+	return err
+}
+
+func (r extraResource) MarshalJSON() ([]byte, error) {
+	type plain extraResource
+	return MarshalWithExtra(plain(r), r.Extra)
+}
+
+type extraField struct {
+	GID   string                     `json:"gid"`
+	Name  string                     `json:"name,omitempty"`
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+func (f *extraField) UnmarshalJSON(data []byte) error {
+	type plain extraField
+	if err := json.Unmarshal(data, (*plain)(f)); err != nil {
+		return err
+	}
+	extra, err := UnknownFields(data, plain{})
+	f.Extra = extra
+	return err
+}
+
+func (f extraField) MarshalJSON() ([]byte, error) {
+	type plain extraField
+	return MarshalWithExtra(plain(f), f.Extra)
+}
+
+func TestUnknownFields(t *testing.T) {
+	data := []byte(`{"gid":"1","name":"Launch","permalink_url":"https://app.asana.com/0/1","custom_fields":[{"gid":"2","name":"Points","number_value":3}]}`)
+	var resource extraResource
+	if err := json.Unmarshal(data, &resource); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(resource.Extra) != 1 || string(resource.Extra["permalink_url"]) != `"https://app.asana.com/0/1"` {
+		t.Errorf("Extra = %v", resource.Extra)
+	}
+	if len(resource.CustomFields) != 1 || string(resource.CustomFields[0].Extra["number_value"]) != "3" {
+		t.Errorf("CustomFields = %+v", resource.CustomFields)
+	}
+
+	out, err := json.Marshal(resource)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `{"gid":"1","name":"Launch","custom_fields":[{"gid":"2","name":"Points","number_value":3}],"permalink_url":"https://app.asana.com/0/1"}`
+	if string(out) != want {
+		t.Errorf("Marshal() = %s, want %s", out, want)
+	}
+
+	if out, _ := MarshalWithExtra(struct{}{}, map[string]json.RawMessage{"a": []byte("1")}); string(out) != `{"a":1}` {
+		t.Errorf("MarshalWithExtra() of an empty struct = %s", out)
+	}
+}
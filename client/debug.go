@@ -0,0 +1,197 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxBodyLog is how much of a body DebugMiddleware logs by default.
+const DefaultMaxBodyLog = 4096
+
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are never logged. X-Hook-Secret carries the webhook
+// handshake secret and X-Hook-Signature is derived from it.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":    true,
+	"Cookie":           true,
+	"Set-Cookie":       true,
+	"X-Hook-Secret":    true,
+	"X-Hook-Signature": true,
+}
+
+// sensitiveFields are JSON and form fields whose values are never logged.
+var sensitiveFields = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"client_secret": true,
+	"secret":        true,
+	"password":      true,
+}
+
+type DebugOptions struct {
+	// Trace additionally logs request and response headers and response bodies.
+	Trace bool
+	// MaxBodySize truncates logged bodies. Zero means DefaultMaxBodyLog.
+	MaxBodySize int
+}
+
+// DebugMiddleware logs every request and response to w: method, URL, request
+// body, status and latency, plus headers and response bodies when tracing.
+// Credentials and webhook secrets are always redacted.
+func DebugMiddleware(w io.Writer, opts DebugOptions) Middleware {
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodyLog
+	}
+	var mu sync.Mutex
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			var entry bytes.Buffer
+
+			fmt.Fprintf(&entry, "--> %s %s\n", req.Method, req.URL.Redacted())
+			if opts.Trace {
+				writeHeaders(&entry, req.Header)
+			}
+			if req.GetBody != nil && req.ContentLength != 0 {
+				if body, err := req.GetBody(); err == nil {
+					data, _ := io.ReadAll(body)
+					body.Close()
+					writeBody(&entry, RedactBody(data, req.Header.Get("Content-Type")), opts.MaxBodySize)
+				}
+			}
+
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			elapsed := time.Since(start).Round(time.Millisecond)
+
+			if err != nil {
+				fmt.Fprintf(&entry, "<-- error %s %s (%s): %v\n", req.Method, req.URL.Redacted(), elapsed, err)
+			} else {
+				fmt.Fprintf(&entry, "<-- %s %s %s (%s)\n", resp.Status, req.Method, req.URL.Redacted(), elapsed)
+				if opts.Trace {
+					writeHeaders(&entry, resp.Header)
+
+					// Buffer the body so it can be both logged and returned
+					data, readErr := io.ReadAll(resp.Body)
+					resp.Body.Close()
+					if readErr != nil {
+						fmt.Fprintf(&entry, "    failed to read body: %v\n", readErr)
+						resp, err = nil, readErr
+					} else {
+						resp.Body = io.NopCloser(bytes.NewReader(data))
+						writeBody(&entry, RedactBody(data, resp.Header.Get("Content-Type")), opts.MaxBodySize)
+					}
+				}
+			}
+
+			mu.Lock()
+			w.Write(entry.Bytes())
+			mu.Unlock()
+
+			return resp, err
+		})
+	}
+}
+
+func writeHeaders(w io.Writer, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	clean := RedactHeaders(header)
+	for _, name := range names {
+		for _, value := range clean[name] {
+			fmt.Fprintf(w, "    %s: %s\n", name, value)
+		}
+	}
+}
+
+func writeBody(w io.Writer, body []byte, limit int) {
+	if len(body) == 0 {
+		return
+	}
+	if len(body) > limit {
+		fmt.Fprintf(w, "    %s… (%d bytes truncated)\n", body[:limit], len(body)-limit)
+		return
+	}
+	fmt.Fprintf(w, "    %s\n", bytes.TrimRight(body, "\n"))
+}
+
+// RedactHeaders returns a copy of header with credentials and webhook secrets
+// replaced.
+func RedactHeaders(header http.Header) http.Header {
+	clean := header.Clone()
+	for name := range clean {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			clean[name] = []string{redacted}
+		}
+	}
+	return clean
+}
+
+// RedactBody returns body with the values of sensitive JSON or form fields
+// replaced. Other bodies are returned unchanged.
+func RedactBody(body []byte, contentType string) []byte {
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return body
+		}
+		for key := range values {
+			if sensitiveFields[key] {
+				values[key] = []string{redacted}
+			}
+		}
+		return []byte(values.Encode())
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+	if !redactValue(v) {
+		return body
+	}
+	clean, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return clean
+}
+
+// redactValue replaces sensitive fields in a decoded JSON value in place and
+// reports whether anything was replaced.
+func redactValue(v interface{}) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if sensitiveFields[key] {
+				v[key] = redacted
+				changed = true
+				continue
+			}
+			if redactValue(value) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if redactValue(value) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
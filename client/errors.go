@@ -0,0 +1,108 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrorDetail is a single entry of the "errors" array in an Asana error response.
+type ErrorDetail struct {
+	Message string `json:"message"`
+	Help    string `json:"help,omitempty"`
+	Phrase  string `json:"phrase,omitempty"`
+}
+
+// APIError is returned for every response with a status code of 400 or above.
+type APIError struct {
+	StatusCode int
+	Method     string
+	Path       string
+	RequestID  string
+	Errors     []ErrorDetail
+	// Body holds the raw response body. Some errors carry useful data, such as
+	// the fresh sync token returned with a 412 from the events endpoint.
+	Body []byte
+}
+
+func newAPIError(method, path string, statusCode int, requestID string, respBody []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: statusCode,
+		Method:     method,
+		Path:       path,
+		RequestID:  requestID,
+		Body:       respBody,
+	}
+
+	var errorResp struct {
+		Errors []ErrorDetail `json:"errors"`
+	}
+	if err := json.Unmarshal(respBody, &errorResp); err == nil {
+		apiErr.Errors = errorResp.Errors
+	}
+
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	if len(e.Errors) == 0 {
+		body := strings.TrimSpace(string(e.Body))
+		if body == "" {
+			body = http.StatusText(e.StatusCode)
+		}
+		return fmt.Sprintf("API error (%d): %s", e.StatusCode, body)
+	}
+
+	messages := make([]string, len(e.Errors))
+	for i, detail := range e.Errors {
+		messages[i] = detail.Message
+	}
+	return fmt.Sprintf("API error (%d): %s", e.StatusCode, strings.Join(messages, "; "))
+}
+
+// AsAPIError returns the *APIError wrapped in err, if any.
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+func hasStatus(err error, statusCode int) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.StatusCode == statusCode
+}
+
+// IsBadRequest reports whether err is a 400 Bad Request from the API.
+func IsBadRequest(err error) bool {
+	return hasStatus(err, http.StatusBadRequest)
+}
+
+// IsUnauthorized reports whether err is a 401 Unauthorized from the API.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsForbidden reports whether err is a 403 Forbidden from the API.
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// IsNotFound reports whether err is a 404 Not Found from the API.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsSyncTokenExpired reports whether err is the 412 Precondition Failed the
+// events endpoint returns for a missing, invalid or expired sync token.
+func IsSyncTokenExpired(err error) bool {
+	return hasStatus(err, http.StatusPreconditionFailed)
+}
+
+// IsRateLimited reports whether err is a 429 Too Many Requests from the API.
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Field presets understood by the managers' Options.
+const (
+	PresetMinimal = "minimal"
+	PresetDefault = "default"
+	PresetFull    = "full"
+)
+
+// Options adjusts a single manager call.
+type Options struct {
+	// Fields are the opt_fields to request. Entries are field paths such as
+	// "assignee.email" or preset names ("minimal", "default", "full"), which
+	// the manager expands to its own field list for that preset. Presets and
+	// fields can be combined, e.g. {"default", "custom_fields.number_value"}.
+	// Empty means the method's default preset.
+	Fields []string
+}
+
+// FieldPresets maps preset names to the fields requested for one kind of
+// resource.
+type FieldPresets map[string][]string
+
+// OptFields returns the opt_fields parameter for a call: the fields in opts
+// with presets expanded, or the fallback preset if opts select no fields.
+// Only the first Options is used.
+func (p FieldPresets) OptFields(fallback string, opts ...Options) string {
+	var requested []string
+	if len(opts) > 0 {
+		requested = opts[0].Fields
+	}
+	if len(requested) == 0 {
+		requested = []string{fallback}
+	}
+
+	var fields []string
+	for _, field := range requested {
+		field = strings.TrimSpace(field)
+		if preset, ok := p[field]; ok {
+			fields = append(fields, preset...)
+		} else if field != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	// Deduplicate while keeping the order fields were given in
+	seen := map[string]bool{}
+	fields = slices.DeleteFunc(fields, func(field string) bool {
+		duplicate := seen[field]
+		seen[field] = true
+		return duplicate
+	})
+	return strings.Join(fields, ",")
+}
+
+// ParseFields splits a comma-separated --fields value.
+func ParseFields(value string) []string {
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// knownFields caches the JSON field names of struct types.
+var knownFields sync.Map
+
+// UnknownFields returns the members of the JSON object data that have no
+// matching field in the struct v, for types that keep fields requested with
+// opt_fields but not modelled in Go. It returns nil if there are none.
+func UnknownFields(data []byte, v interface{}) (map[string]json.RawMessage, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+
+	known := jsonFieldNames(reflect.TypeOf(v))
+	var extra map[string]json.RawMessage
+	for name, value := range members {
+		if known[name] {
+			continue
+		}
+		if extra == nil {
+			extra = map[string]json.RawMessage{}
+		}
+		extra[name] = value
+	}
+	return extra, nil
+}
+
+// MarshalWithExtra marshals the struct v and adds the members of extra that
+// v does not already have, in sorted order after v's own fields.
+func MarshalWithExtra(v interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	known := jsonFieldNames(reflect.TypeOf(v))
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		if !known[name] {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return data, nil
+	}
+	slices.Sort(names)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for i, name := range names {
+		if i > 0 || len(data) > 2 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(extra[name])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if names, ok := knownFields.Load(t); ok {
+		return names.(map[string]bool)
+	}
+
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	knownFields.Store(t, names)
+	return names
+}
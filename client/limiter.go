@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits configures client-side throttling, which keeps a client (or several
+// clients sharing a Limiter) under Asana's per-token limits instead of
+// relying on 429 responses. Zero values disable the corresponding limit.
+type Limits struct {
+	// RequestsPerMinute is the sustained request rate. Asana allows 150
+	// requests per minute on free plans and 1500 on paid plans.
+	RequestsPerMinute int
+	// Burst is the number of requests that may be sent at once after a
+	// quiet period. It defaults to RequestsPerMinute/15.
+	Burst int
+	// MaxInFlight caps the number of concurrent requests.
+	MaxInFlight int
+	// MaxInFlightWrites additionally caps concurrent POST, PUT, PATCH and
+	// DELETE requests, which Asana limits more strictly than reads.
+	MaxInFlightWrites int
+	// Cost returns how many requests' worth of the rate limit a request
+	// consumes. Nil means DefaultCost.
+	Cost func(method, endpoint string, payload []byte) int
+}
+
+// DefaultLimits returns the limits used by NewClient, which match Asana's
+// limits for paid plans.
+func DefaultLimits() Limits {
+	return Limits{
+		RequestsPerMinute: 1500,
+		MaxInFlight:       50,
+		MaxInFlightWrites: 15,
+	}
+}
+
+// searchCost is the cost of a search request. Asana allows 60 searches per
+// minute against 1500 requests, so each search uses 25 requests' worth.
+const searchCost = 25
+
+// DefaultCost follows how Asana counts requests: each action of a batch
+// request counts separately, and searches count against their own, much
+// lower limit.
+func DefaultCost(method, endpoint string, payload []byte) int {
+	path, _, _ := strings.Cut(endpoint, "?")
+	switch {
+	case method == http.MethodPost && path == "/batch":
+		var body struct {
+			Data struct {
+				Actions []json.RawMessage `json:"actions"`
+			} `json:"data"`
+		}
+		if json.Unmarshal(payload, &body) == nil && len(body.Data.Actions) > 0 {
+			return len(body.Data.Actions)
+		}
+	case strings.HasSuffix(path, "/tasks/search"), strings.HasSuffix(path, "/typeahead"):
+		return searchCost
+	}
+	return 1
+}
+
+// Limiter throttles requests with a token bucket and caps the number in
+// flight. It is safe for concurrent use and may be shared by several clients
+// using the same token.
+type Limiter struct {
+	limits Limits
+
+	mu sync.Mutex
+	// rate is in tokens per second. tokens may go negative: each request
+	// reserves its cost up front and waits until the deficit is repaid,
+	// which serves waiting requests in arrival order.
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+
+	inFlight       chan struct{}
+	inFlightWrites chan struct{}
+}
+
+func NewLimiter(limits Limits) *Limiter {
+	l := &Limiter{limits: limits}
+	if limits.RequestsPerMinute > 0 {
+		l.rate = float64(limits.RequestsPerMinute) / 60
+		l.burst = float64(limits.Burst)
+		if l.burst <= 0 {
+			l.burst = max(1, float64(limits.RequestsPerMinute)/15)
+		}
+		l.tokens = l.burst
+	}
+	if limits.MaxInFlight > 0 {
+		l.inFlight = make(chan struct{}, limits.MaxInFlight)
+	}
+	if limits.MaxInFlightWrites > 0 {
+		l.inFlightWrites = make(chan struct{}, limits.MaxInFlightWrites)
+	}
+	return l
+}
+
+// Limits returns the limits the limiter was created with.
+func (l *Limiter) Limits() Limits {
+	return l.limits
+}
+
+// Wait blocks until a request may be sent, or ctx is done. On success the
+// returned function must be called once the request has completed.
+func (l *Limiter) Wait(ctx context.Context, method, endpoint string, payload []byte) (func(), error) {
+	cost := DefaultCost
+	if l.limits.Cost != nil {
+		cost = l.limits.Cost
+	}
+	if err := l.reserve(ctx, float64(cost(method, endpoint, payload))); err != nil {
+		return nil, err
+	}
+
+	var held []chan struct{}
+	release := func() {
+		for _, sem := range held {
+			<-sem
+		}
+	}
+
+	sems := []chan struct{}{l.inFlight}
+	if method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions {
+		sems = append(sems, l.inFlightWrites)
+	}
+	for _, sem := range sems {
+		if sem == nil {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+			held = append(held, sem)
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+// reserve takes cost tokens from the bucket and waits until they are
+// available.
+func (l *Limiter) reserve(ctx context.Context, cost float64) error {
+	l.mu.Lock()
+	now := time.Now()
+	var delay time.Duration
+	if l.rate > 0 {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		l.tokens -= cost
+		if l.tokens < 0 {
+			delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+		}
+	}
+	if pause := l.pausedUntil.Sub(now); pause > delay {
+		delay = pause
+	}
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give the reservation back so later requests need not wait for it
+		l.mu.Lock()
+		if l.rate > 0 {
+			l.tokens = min(l.burst, l.tokens+cost)
+		}
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Pause holds back all requests for d, as Asana asks when it answers 429
+// Too Many Requests with Retry-After: the limit applies to the token, not
+// to the request that hit it.
+func (l *Limiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
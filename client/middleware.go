@@ -0,0 +1,42 @@
+package client
+
+import (
+	"net/http"
+)
+
+// Middleware wraps the transport used to send requests, e.g. to log, record
+// or modify them. Middleware sees every attempt, including retries.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Use adds middleware to the client's transport chain. Middleware added first
+// is outermost: it sees requests first and responses last.
+func (c *Client) Use(middleware ...Middleware) {
+	c.middleware = append(c.middleware, middleware...)
+}
+
+// transport returns the HTTP client to send requests with, with the
+// middleware chain wrapped around its transport.
+func (c *Client) transport() *http.Client {
+	if len(c.middleware) == 0 {
+		return c.httpClient
+	}
+
+	next := c.httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		next = c.middleware[i](next)
+	}
+
+	httpClient := *c.httpClient
+	httpClient.Transport = next
+	return &httpClient
+}
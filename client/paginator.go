@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/url"
+	"strconv"
+)
+
+// DefaultPageSize is the number of items requested per page. It is the
+// maximum Asana accepts for offset-paginated endpoints.
+const DefaultPageSize = 100
+
+type NextPage struct {
+	Offset string `json:"offset,omitempty"`
+	Path   string `json:"path,omitempty"`
+	URI    string `json:"uri,omitempty"`
+}
+
+// Page is a single page returned by an Asana list endpoint.
+type Page[T any] struct {
+	Data     []T       `json:"data"`
+	NextPage *NextPage `json:"next_page,omitempty"`
+}
+
+// Paginator walks an offset-paginated list endpoint, following
+// next_page.offset until the results are exhausted.
+type Paginator[T any] struct {
+	client   *Client
+	endpoint string
+	params   url.Values
+
+	// PageSize is the number of items requested per page (1-100).
+	PageSize int
+	// MaxItems stops iteration after this many items. Zero means no limit.
+	MaxItems int
+}
+
+func NewPaginator[T any](c *Client, endpoint string, params url.Values) *Paginator[T] {
+	return &Paginator[T]{
+		client:   c,
+		endpoint: endpoint,
+		params:   params,
+		PageSize: DefaultPageSize,
+	}
+}
+
+// Pages yields every page in turn. Iteration stops at the first error, when
+// the caller breaks out of the loop, or once MaxItems items have been yielded;
+// the final page is truncated so that no more than MaxItems items are returned.
+func (p *Paginator[T]) Pages(ctx context.Context) iter.Seq2[*Page[T], error] {
+	return func(yield func(*Page[T], error) bool) {
+		params := url.Values{}
+		for key, values := range p.params {
+			params[key] = append([]string(nil), values...)
+		}
+
+		count := 0
+		for {
+			pageSize := p.PageSize
+			if pageSize <= 0 || pageSize > DefaultPageSize {
+				pageSize = DefaultPageSize
+			}
+			if p.MaxItems > 0 && p.MaxItems-count < pageSize {
+				pageSize = p.MaxItems - count
+			}
+			params.Set("limit", strconv.Itoa(pageSize))
+
+			respBody, err := p.client.GetContext(ctx, p.endpoint, params)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			var page Page[T]
+			if err := json.Unmarshal(respBody, &page); err != nil {
+				yield(nil, fmt.Errorf("failed to parse response: %w", err))
+				return
+			}
+
+			if p.MaxItems > 0 && count+len(page.Data) > p.MaxItems {
+				page.Data = page.Data[:p.MaxItems-count]
+			}
+			count += len(page.Data)
+
+			if !yield(&page, nil) {
+				return
+			}
+
+			if page.NextPage == nil || page.NextPage.Offset == "" {
+				return
+			}
+			if p.MaxItems > 0 && count >= p.MaxItems {
+				return
+			}
+
+			params.Set("offset", page.NextPage.Offset)
+		}
+	}
+}
+
+// All yields items one by one across all pages.
+func (p *Paginator[T]) All(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for page, err := range p.Pages(ctx) {
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page.Data {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Collect gathers every item into a slice.
+func (p *Paginator[T]) Collect(ctx context.Context) ([]T, error) {
+	return Collect(p.All(ctx))
+}
+
+// Collect drains an item iterator into a slice, stopping at the first error.
+func Collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	items := []T{}
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how the client retries requests that failed because of
+// rate limiting, transient server errors or network errors.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts per request, including the
+	// first one. Values below 2 disable retries.
+	MaxAttempts int
+	// MaxElapsedTime bounds the total time spent on a request across all
+	// attempts. Zero means no limit.
+	MaxElapsedTime time.Duration
+	// InitialBackoff is the base delay before the first retry. It doubles on
+	// every subsequent attempt, with full jitter applied.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the exponential backoff delay.
+	MaxBackoff time.Duration
+	// RetryableMethods lists the HTTP methods that are safe to retry after a
+	// 5xx response or a network error. Rate-limited (429) requests are always
+	// retried because Asana rejects them before doing any work.
+	RetryableMethods []string
+}
+
+// DefaultRetryPolicy returns the policy used by NewClient.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:      5,
+		MaxElapsedTime:   2 * time.Minute,
+		InitialBackoff:   500 * time.Millisecond,
+		MaxBackoff:       30 * time.Second,
+		RetryableMethods: []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete},
+	}
+}
+
+func (p *RetryPolicy) retryableMethod(method string) bool {
+	for _, m := range p.RetryableMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff reports whether another attempt should be made and how long to wait
+// before making it. resp is nil when the previous attempt failed at the
+// network level.
+func (p *RetryPolicy) backoff(method string, attempt int, elapsed time.Duration, resp *http.Response) (time.Duration, bool) {
+	if p == nil || attempt >= p.MaxAttempts {
+		return 0, false
+	}
+
+	var delay time.Duration
+	switch {
+	case resp == nil:
+		if !p.retryableMethod(method) {
+			return 0, false
+		}
+		delay = p.jitteredBackoff(attempt)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		if retryAfter, ok := ParseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			delay = retryAfter
+		} else {
+			delay = p.jitteredBackoff(attempt)
+		}
+	case resp.StatusCode == http.StatusInternalServerError,
+		resp.StatusCode == http.StatusBadGateway,
+		resp.StatusCode == http.StatusServiceUnavailable,
+		resp.StatusCode == http.StatusGatewayTimeout:
+		if !p.retryableMethod(method) {
+			return 0, false
+		}
+		delay = p.jitteredBackoff(attempt)
+	default:
+		return 0, false
+	}
+
+	if p.MaxElapsedTime > 0 && elapsed+delay > p.MaxElapsedTime {
+		return 0, false
+	}
+
+	return delay, true
+}
+
+func (p *RetryPolicy) jitteredBackoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff << (attempt - 1)
+	if backoff <= 0 || (p.MaxBackoff > 0 && backoff > p.MaxBackoff) {
+		backoff = p.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return rand.N(backoff + 1)
+}
+
+// ParseRetryAfter understands both forms of the Retry-After header: a number
+// of seconds or an HTTP date.
+func ParseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		delay := time.Until(t)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
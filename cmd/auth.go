@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/octoberswimmer/utka/config"
+	"github.com/octoberswimmer/utka/oauth"
+	"github.com/octoberswimmer/utka/users"
+	"github.com/spf13/cobra"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Log in to Asana with OAuth",
+	Long: `Commands for authenticating with Asana using the OAuth 2.0 authorization-code flow.
+
+Tokens obtained with 'utka auth login' are stored in the selected profile and
+refreshed automatically when they expire.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := startCommand(cmd); err != nil {
+			return err
+		}
+
+		// Login and logout must work before any token is configured
+		if err := loadProfile(cmd); err != nil {
+			return err
+		}
+		if err := loadSecrets(activeProfileName, activeProfile); err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Log in with the OAuth authorization-code flow",
+	Long: `Log in with the OAuth authorization-code flow using PKCE.
+
+A callback server is started on the loopback interface and the authorization
+page is opened in your browser. Register http://127.0.0.1:<port>/callback as a
+redirect URL of your OAuth application and pass the same port with --port.
+
+The application settings are saved in the profile (--profile, or the current
+profile, or a new profile named "default"), so later logins only need:
+  utka auth login`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := activeProfileName
+		if name == "" {
+			name = "default"
+		}
+
+		profile := activeProfile
+		if profile == nil {
+			profile = &config.Profile{}
+		}
+		if profile.OAuth == nil {
+			profile.OAuth = &config.OAuth{}
+		}
+		settings := profile.OAuth
+
+		if cmd.Flags().Changed("client-id") {
+			settings.ClientID, _ = cmd.Flags().GetString("client-id")
+		}
+		if cmd.Flags().Changed("client-secret") {
+			settings.ClientSecret, _ = cmd.Flags().GetString("client-secret")
+		}
+		if cmd.Flags().Changed("auth-url") {
+			settings.AuthURL, _ = cmd.Flags().GetString("auth-url")
+		}
+		if cmd.Flags().Changed("token-url") {
+			settings.TokenURL, _ = cmd.Flags().GetString("token-url")
+		}
+		if cmd.Flags().Changed("port") {
+			settings.RedirectPort, _ = cmd.Flags().GetInt("port")
+		}
+		if cmd.Flags().Changed("scopes") {
+			settings.Scopes, _ = cmd.Flags().GetStringSlice("scopes")
+		}
+
+		if settings.ClientID == "" {
+			return validationErrorf("--client-id is required for the first login")
+		}
+
+		noBrowser, _ := cmd.Flags().GetBool("no-browser")
+		openBrowser := func(authURL string) error {
+			fmt.Printf("Open this URL to authorize utka:\n\n  %s\n\n", authURL)
+			if !noBrowser {
+				if err := launchBrowser(authURL); err != nil {
+					fmt.Printf("Could not open a browser automatically: %v\n", err)
+				}
+			}
+			fmt.Println("Waiting for authorization...")
+			return nil
+		}
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Minute)
+		defer cancel()
+
+		token, err := oauthConfig(settings).Login(ctx, openBrowser)
+		if err != nil {
+			return authErrorf("login failed: %w", err)
+		}
+
+		settings.AccessToken = token.AccessToken
+		settings.RefreshToken = token.RefreshToken
+		settings.Expiry = token.Expiry
+
+		appConfig.SetProfile(name, profile)
+		if err := saveConfig(name, profile); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		fmt.Printf("✓ Logged in (profile: %s)\n", name)
+		return nil
+	},
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the current authentication status",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := rootCmd.PersistentPreRunE(cmd, args); err != nil {
+			return err
+		}
+
+		if activeProfileName != "" {
+			fmt.Printf("Profile:  %s\n", activeProfileName)
+		}
+
+		switch authMethod {
+		case "oauth":
+			fmt.Printf("Method:   OAuth\n")
+			if expiry := activeProfile.OAuth.Expiry; !expiry.IsZero() {
+				fmt.Printf("Expires:  %s\n", expiry.Local().Format("2006-01-02 15:04:05 MST"))
+			}
+		case "profile":
+			fmt.Printf("Method:   Personal access token (profile)\n")
+		default:
+			fmt.Printf("Method:   Personal access token (ASANA_PERSONAL_ACCESS_TOKEN)\n")
+		}
+
+		me, err := users.NewUserManager(asanaClient).MeContext(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("token check failed: %w", err)
+		}
+
+		fmt.Printf("User:     %s (%s) - GID: %s\n", me.Name, me.Email, me.GID)
+		return nil
+	},
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove stored OAuth tokens",
+	Long:  `Remove the OAuth access and refresh tokens from the profile. The OAuth application settings are kept for the next login.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if activeProfile == nil || activeProfile.OAuth == nil || activeProfile.OAuth.AccessToken == "" {
+			fmt.Println("Not logged in with OAuth")
+			return nil
+		}
+
+		activeProfile.OAuth.AccessToken = ""
+		activeProfile.OAuth.RefreshToken = ""
+		activeProfile.OAuth.Expiry = time.Time{}
+
+		if err := saveConfig(activeProfileName, activeProfile); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		fmt.Printf("✓ Logged out (profile: %s)\n", activeProfileName)
+		return nil
+	},
+}
+
+func init() {
+	authLoginCmd.Flags().String("client-id", "", "OAuth client ID")
+	authLoginCmd.Flags().String("client-secret", "", "OAuth client secret (optional with PKCE)")
+	authLoginCmd.Flags().String("auth-url", "", "Authorization endpoint (defaults to "+oauth.AuthURL+")")
+	authLoginCmd.Flags().String("token-url", "", "Token endpoint (defaults to "+oauth.TokenURL+")")
+	authLoginCmd.Flags().Int("port", 0, "Loopback port for the callback server (0 picks a free port)")
+	authLoginCmd.Flags().StringSlice("scopes", nil, "OAuth scopes to request (comma-separated)")
+	authLoginCmd.Flags().Bool("no-browser", false, "Print the authorization URL instead of opening a browser")
+
+	authCmd.AddCommand(authLoginCmd)
+	authCmd.AddCommand(authStatusCmd)
+	authCmd.AddCommand(authLogoutCmd)
+
+	rootCmd.AddCommand(authCmd)
+}
+
+func oauthConfig(settings *config.OAuth) *oauth.Config {
+	return &oauth.Config{
+		ClientID:     settings.ClientID,
+		ClientSecret: settings.ClientSecret,
+		AuthURL:      settings.AuthURL,
+		TokenURL:     settings.TokenURL,
+		Scopes:       settings.Scopes,
+		RedirectPort: settings.RedirectPort,
+	}
+}
+
+// currentOAuthToken returns the active profile's OAuth access token,
+// refreshing it first if it is known to have expired.
+func currentOAuthToken(ctx context.Context) (string, error) {
+	settings := activeProfile.OAuth
+	token := &oauth.Token{AccessToken: settings.AccessToken, Expiry: settings.Expiry}
+	if token.Expired() && settings.RefreshToken != "" {
+		return refreshOAuthToken(ctx)
+	}
+	return settings.AccessToken, nil
+}
+
+// refreshOAuthToken exchanges the active profile's refresh token for a new
+// access token and saves it. It is installed as the client's TokenRefresher.
+func refreshOAuthToken(ctx context.Context) (string, error) {
+	settings := activeProfile.OAuth
+	if settings.RefreshToken == "" {
+		return "", fmt.Errorf("no refresh token stored, run 'utka auth login'")
+	}
+
+	token, err := oauthConfig(settings).Refresh(ctx, settings.RefreshToken)
+	if err != nil {
+		return "", err
+	}
+
+	settings.AccessToken = token.AccessToken
+	settings.RefreshToken = token.RefreshToken
+	settings.Expiry = token.Expiry
+
+	if err := saveConfig(activeProfileName, activeProfile); err != nil {
+		return "", fmt.Errorf("failed to save refreshed token: %w", err)
+	}
+
+	return token.AccessToken, nil
+}
+
+func launchBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
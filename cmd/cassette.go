@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/octoberswimmer/utka/cassette"
+	"github.com/octoberswimmer/utka/client"
+	"github.com/spf13/cobra"
+)
+
+// applyCassetteFlags records API interactions to the --record cassette, or
+// answers requests from the --replay cassette instead of contacting Asana.
+// Completions only read a --replay cassette, as recording them would
+// overwrite the cassette of the command being typed.
+func applyCassetteFlags(cmd *cobra.Command, c *client.Client) error {
+	record, _ := cmd.Flags().GetString("record")
+	replay, _ := cmd.Flags().GetString("replay")
+
+	switch {
+	case record != "" && replay != "":
+		return validationErrorf("--record and --replay cannot be used together")
+	case record != "" && !completing:
+		c.Use(cassette.New(record).Recorder())
+	case replay != "":
+		recorded, err := cassette.Load(replay)
+		if err != nil {
+			return err
+		}
+		c.Use(recorded.Player())
+	}
+	return nil
+}
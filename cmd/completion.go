@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"iter"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/octoberswimmer/utka/client"
+	"github.com/octoberswimmer/utka/projects"
+	"github.com/octoberswimmer/utka/resolve"
+	"github.com/octoberswimmer/utka/tasks"
+	"github.com/octoberswimmer/utka/users"
+	"github.com/octoberswimmer/utka/workspaces"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// completionTTL is how long suggestions are reused. Completion runs on
+	// every press of Tab, so it must not wait for Asana each time, but new
+	// projects and tasks should show up soon.
+	completionTTL = 5 * time.Minute
+
+	// completionTimeout bounds the requests made for one completion; no
+	// suggestions are better than a shell that hangs.
+	completionTimeout = 5 * time.Second
+
+	// completionLimit caps the projects and tasks listed for suggestions.
+	completionLimit = 500
+)
+
+// completing is set while suggesting completions, when prompting would hang
+// the shell and nothing should be remembered in the config.
+var completing bool
+
+// completionCache holds the suggestion lists, in completions.json next to
+// the name cache.
+var completionCache *resolve.Cache
+
+// completionSource lists the resources a flag or argument can name.
+type completionSource func(cmd *cobra.Command) ([]resolve.Match, error)
+
+var (
+	completeWorkspaces  = completeGIDs(workspaceCandidates)
+	completeProjects    = completeGIDs(projectCandidates)
+	completeProjectList = completeGIDList(projectCandidates)
+	completeSections    = completeGIDs(sectionCandidates)
+	completeTasks       = completeGIDs(taskCandidates)
+	completeTaskList    = completeGIDList(taskCandidates)
+	completeUsers       = completeGIDs(userCandidates)
+	completeWebhooks    = completeGIDs(webhookCandidates)
+)
+
+// completeGIDs suggests the GIDs source lists, with their names as
+// descriptions.
+func completeGIDs(source completionSource) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return gidCompletions(cmd, source, "", toComplete)
+	}
+}
+
+// completeGIDList is completeGIDs for comma-separated flags such as --gids.
+func completeGIDList(source completionSource) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		done, partial := "", toComplete
+		if i := strings.LastIndex(toComplete, ","); i >= 0 {
+			done, partial = toComplete[:i+1], toComplete[i+1:]
+		}
+		return gidCompletions(cmd, source, done, partial)
+	}
+}
+
+func gidCompletions(cmd *cobra.Command, source completionSource, done, partial string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	matches, err := completionMatches(cmd, source)
+	if err != nil {
+		// Errors would garble the command line, so they only go to
+		// $BASH_COMP_DEBUG_FILE
+		cobra.CompDebugln(err.Error(), false)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []cobra.Completion
+	for _, match := range matches {
+		if strings.HasPrefix(match.GID, partial) {
+			completions = append(completions, cobra.CompletionWithDesc(done+match.GID, match.Name))
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completionMatches(cmd *cobra.Command, source completionSource) ([]resolve.Match, error) {
+	if err := startCompletion(cmd); err != nil {
+		return nil, err
+	}
+
+	parent := cmd.Context()
+	ctx, cancel := context.WithTimeout(parent, completionTimeout)
+	defer cancel()
+	cmd.SetContext(ctx)
+	defer cmd.SetContext(parent)
+	return source(cmd)
+}
+
+// startCompletion sets up the client for a completion. Completions skip the
+// commands' hooks, which would reject the unfinished command line.
+func startCompletion(cmd *cobra.Command) error {
+	completing = true
+	if err := setupClient(cmd); err != nil {
+		return err
+	}
+	resolver.Choose = nil
+
+	completionCache = nil
+	if dir, err := resolve.CacheDir(); err == nil {
+		completionCache = resolve.NewCache(filepath.Join(dir, "completions.json"), completionTTL)
+	}
+	return nil
+}
+
+// cachedMatches returns the list cached under key, or fetches and caches
+// it. Keys are scoped to the profile, as accounts see different resources.
+func cachedMatches(key string, fetch func() ([]resolve.Match, error)) ([]resolve.Match, error) {
+	key = activeProfileName + ":" + key
+	if completionCache != nil {
+		if matches, ok := completionCache.GetAll(key); ok {
+			return matches, nil
+		}
+	}
+
+	matches, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	if completionCache != nil {
+		// A cache that cannot be written only makes the next Tab slower
+		completionCache.PutAll(key, matches)
+	}
+	return matches, nil
+}
+
+// completionWorkspace returns the workspace whose resources are suggested,
+// chosen like the workspace of the command itself.
+func completionWorkspace(cmd *cobra.Command, related ...relatedResource) (string, error) {
+	workspace, err := workspaceFlag(cmd, related...)
+	if err == nil && workspace == "" {
+		err = resolve.ErrWorkspaceRequired
+	}
+	return workspace, err
+}
+
+func workspaceCandidates(cmd *cobra.Command) ([]resolve.Match, error) {
+	return cachedMatches("workspaces", func() ([]resolve.Match, error) {
+		all, err := workspaces.NewWorkspaceManager(asanaClient).ListContext(cmd.Context())
+		if err != nil {
+			return nil, err
+		}
+		matches := make([]resolve.Match, len(all))
+		for i, workspace := range all {
+			matches[i] = resolve.Match{GID: workspace.GID, Name: workspace.Name}
+		}
+		return matches, nil
+	})
+}
+
+func projectCandidates(cmd *cobra.Command) ([]resolve.Match, error) {
+	workspace, err := completionWorkspace(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return cachedMatches("projects:"+workspace, func() ([]resolve.Match, error) {
+		var matches []resolve.Match
+		for project, err := range projects.NewProjectManager(asanaClient).IterByWorkspace(cmd.Context(), workspace, false, completionLimit, nameField) {
+			if err != nil {
+				return nil, err
+			}
+			matches = append(matches, resolve.Match{GID: project.GID, Name: project.Name})
+		}
+		return matches, nil
+	})
+}
+
+// sectionCandidates lists the sections of the project given with
+// --project, and nothing without it.
+func sectionCandidates(cmd *cobra.Command) ([]resolve.Match, error) {
+	project, err := resolveFlag(cmd, "project", resolve.Project)
+	if err != nil || project == "" {
+		return nil, err
+	}
+	return cachedMatches("sections:"+project, func() ([]resolve.Match, error) {
+		sections, err := projects.NewProjectManager(asanaClient).SectionsContext(cmd.Context(), project)
+		if err != nil {
+			return nil, err
+		}
+		matches := make([]resolve.Match, len(sections))
+		for i, section := range sections {
+			matches[i] = resolve.Match{GID: section.GID, Name: section.Name}
+		}
+		return matches, nil
+	})
+}
+
+// taskCandidates lists the incomplete tasks of the project given with
+// --project, or otherwise the incomplete tasks assigned to the user.
+func taskCandidates(cmd *cobra.Command) ([]resolve.Match, error) {
+	manager := tasks.NewTaskManager(asanaClient)
+
+	project, err := resolveFlag(cmd, "project", resolve.Project)
+	if err != nil {
+		return nil, err
+	}
+	if project != "" {
+		return cachedMatches("tasks:project:"+project, func() ([]resolve.Match, error) {
+			return taskMatches(manager.IterByProject(cmd.Context(), project, 0, completionLimit, nameField))
+		})
+	}
+
+	workspace, err := completionWorkspace(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return cachedMatches("tasks:me:"+workspace, func() ([]resolve.Match, error) {
+		return taskMatches(manager.IterByAssignee(cmd.Context(), "me", workspace, 0, completionLimit, nameField))
+	})
+}
+
+func taskMatches(all iter.Seq2[tasks.Task, error]) ([]resolve.Match, error) {
+	var matches []resolve.Match
+	for task, err := range all {
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, resolve.Match{GID: task.GID, Name: task.Name})
+	}
+	return matches, nil
+}
+
+// userCandidates lists "me" and the users of the workspace.
+func userCandidates(cmd *cobra.Command) ([]resolve.Match, error) {
+	workspace, err := completionWorkspace(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return cachedMatches("users:"+workspace, func() ([]resolve.Match, error) {
+		all, err := users.NewUserManager(asanaClient).ListInWorkspaceContext(cmd.Context(), workspace)
+		if err != nil {
+			return nil, err
+		}
+		matches := []resolve.Match{{GID: "me", Name: "The authenticated user"}}
+		for _, user := range all {
+			matches = append(matches, resolve.Match{GID: user.GID, Name: user.Name})
+		}
+		return matches, nil
+	})
+}
+
+// webhookCandidates lists the webhooks of the workspace, described by the
+// resource they watch and their target.
+func webhookCandidates(cmd *cobra.Command) ([]resolve.Match, error) {
+	workspace, err := completionWorkspace(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return cachedMatches("webhooks:"+workspace, func() ([]resolve.Match, error) {
+		all, err := webhookManager.ListContext(cmd.Context(), workspace, "")
+		if err != nil {
+			return nil, err
+		}
+		matches := make([]resolve.Match, len(all))
+		for i, webhook := range all {
+			resource := ""
+			if webhook.Resource != nil {
+				resource = cmp.Or(webhook.Resource.Name, webhook.Resource.GID)
+			}
+			matches[i] = resolve.Match{GID: webhook.GID, Name: fmt.Sprintf("%s -> %s", resource, webhook.Target)}
+		}
+		return matches, nil
+	})
+}
+
+// completeProfiles suggests the names of the configured profiles.
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if err := loadConfig(cmd); err != nil {
+		cobra.CompDebugln(err.Error(), false)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []cobra.Completion
+	for _, name := range appConfig.ProfileNames() {
+		if !strings.HasPrefix(name, toComplete) {
+			continue
+		}
+		if name == appConfig.CurrentProfile {
+			completions = append(completions, cobra.CompletionWithDesc(name, "current profile"))
+		} else {
+			completions = append(completions, cobra.Completion(name))
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeFilters suggests the names of the saved event filters.
+func completeFilters(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if err := loadConfig(cmd); err != nil {
+		cobra.CompDebugln(err.Error(), false)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []cobra.Completion
+	for _, name := range appConfig.FilterNames() {
+		if strings.HasPrefix(name, toComplete) {
+			completions = append(completions, cobra.CompletionWithDesc(name, appConfig.Filters[name]))
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// firstArg limits an argument completion to the first argument, for
+// commands that take a single one.
+func firstArg(complete cobra.CompletionFunc) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return complete(cmd, args, toComplete)
+	}
+}
+
+var nameField = client.Options{Fields: []string{"name"}}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/octoberswimmer/utka/asanatest"
+)
+
+// completeAgainstFake asks for completions of the command line in args and
+// returns the suggestions.
+func completeAgainstFake(t *testing.T, server *asanatest.Server, workspace string, args ...string) []string {
+	t.Helper()
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(io.Discard)
+	t.Cleanup(func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+		completing = false
+	})
+
+	if err := runAgainstFake(t, server, "secret", workspace, append([]string{"__complete"}, args...)...); err != nil {
+		t.Fatalf("Completing %q: %v", args, err)
+	}
+
+	// The last line is the directive for the shell
+	var suggestions []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if !strings.HasPrefix(line, ":") {
+			suggestions = append(suggestions, line)
+		}
+	}
+	return suggestions
+}
+
+func TestCompletion(t *testing.T) {
+	server := asanatest.NewServer()
+	defer server.Close()
+	server.Token = "secret"
+	workspace := server.AddWorkspace(asanatest.Workspace{Name: "Acme"})
+	alice := server.AddUser(asanatest.User{Name: "Alice"})
+	server.SetMe(alice.GID)
+	project := server.AddProject(asanatest.Project{Name: "Launch", Workspace: workspace.GID})
+	section := server.AddSection(asanatest.Section{Name: "Drafts", Project: project.GID})
+	draft := server.AddTask(asanatest.Task{Name: "Draft", Assignee: alice.GID, Workspace: workspace.GID})
+	review := server.AddTask(asanatest.Task{Name: "Review", Assignee: alice.GID, Workspace: workspace.GID})
+	webhook := server.AddWebhook(asanatest.Webhook{Resource: project.GID, Target: "https://example.com/hook"})
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"workspace", "get", "--gid", ""}, []string{workspace.GID + "\tAcme"}},
+		{[]string{"project", "get", "--gid", ""}, []string{project.GID + "\tLaunch"}},
+		{[]string{"task", "list", "--project", "Launch", "--section", ""}, []string{section.GID + "\tDrafts"}},
+		{[]string{"task", "get", "--gid", ""}, []string{draft.GID + "\tDraft", review.GID + "\tReview"}},
+		{[]string{"task", "get", "--gid", review.GID}, []string{review.GID + "\tReview"}},
+		{[]string{"task", "bulk", "--gids", draft.GID + ","}, []string{draft.GID + "," + draft.GID + "\tDraft", draft.GID + "," + review.GID + "\tReview"}},
+		{[]string{"task", "list", "--assignee", ""}, []string{"me\tThe authenticated user", alice.GID + "\tAlice"}},
+		{[]string{"webhook", "get", "--gid", ""}, []string{webhook.GID + "\tLaunch -> https://example.com/hook"}},
+		{[]string{"config", "use", ""}, []string{"fake\tcurrent profile"}},
+	}
+	for _, test := range tests {
+		got := completeAgainstFake(t, server, workspace.GID, test.args...)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("Completing %q = %q, want %q", test.args, got, test.want)
+		}
+	}
+
+	// Completing a command line does not record it or log its requests
+	dir := t.TempDir()
+	cassettePath, debugPath := filepath.Join(dir, "cassette.yaml"), filepath.Join(dir, "debug.log")
+	completeAgainstFake(t, server, workspace.GID, "project", "get", "--record", cassettePath, "--debug", "--debug-file", debugPath, "--gid", "")
+	for _, path := range []string{cassettePath, debugPath} {
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Completion created %s: %v", filepath.Base(path), err)
+		}
+	}
+}
@@ -0,0 +1,521 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/octoberswimmer/utka/client"
+	"github.com/octoberswimmer/utka/config"
+	"github.com/octoberswimmer/utka/events/filter"
+	"github.com/octoberswimmer/utka/output"
+	"github.com/octoberswimmer/utka/resolve"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage configuration profiles",
+	Long: `Commands for managing named profiles in the utka config file.
+
+Each profile holds an Asana token, a default workspace, an API base URL and an
+output preference. Select a profile for a single command with --profile or
+$UTKA_PROFILE, or make it the default with 'utka config use'.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := startCommand(cmd); err != nil {
+			return err
+		}
+
+		// Config commands must work before any token is configured
+		if err := loadConfig(cmd); err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
+var configAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add or update a profile",
+	Long: `Add a new profile, or update the given fields of an existing one.
+
+The first profile added becomes the current profile.
+
+Tokens are stored in the config file unless a credential store is selected:
+  --credential-store file      encrypt tokens in a passphrase-protected file
+                               (the passphrase is read from $UTKA_PASSPHRASE
+                               or prompted for)
+  --credential-store command   run --credential-command to print the token,
+                               e.g. "pass show asana/work"
+  --credential-store config    move tokens back into the config file
+
+Tokens already stored for the profile are moved to the new store.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		profile, ok := appConfig.Profiles[name]
+		if !ok {
+			profile = &config.Profile{}
+		}
+
+		if cmd.Flags().Changed("credential-store") || cmd.Flags().Changed("token") {
+			// Read the tokens from the current store so they can be moved or
+			// kept when the profile is saved
+			if err := loadSecrets(name, profile); err != nil {
+				return err
+			}
+		}
+
+		previous := profile.Credentials
+		if cmd.Flags().Changed("credential-store") {
+			backend, _ := cmd.Flags().GetString("credential-store")
+			path, _ := cmd.Flags().GetString("credential-file")
+			command, _ := cmd.Flags().GetString("credential-command")
+
+			switch backend {
+			case "config":
+				profile.Credentials = nil
+			case config.BackendFile:
+				profile.Credentials = &config.Credentials{Backend: backend, Path: path}
+			case config.BackendCommand:
+				if command == "" {
+					return validationErrorf("--credential-command is required with --credential-store command")
+				}
+				profile.Credentials = &config.Credentials{Backend: backend, Command: command}
+			default:
+				return validationErrorf("invalid credential store %q: must be config, file or command", backend)
+			}
+			secretsLoaded[profile] = true
+		}
+
+		if cmd.Flags().Changed("token") {
+			if profile.Credentials != nil && profile.Credentials.Backend == config.BackendCommand {
+				return validationErrorf("the token of this profile is supplied by its credential command")
+			}
+			profile.Token, _ = cmd.Flags().GetString("token")
+		}
+		if cmd.Flags().Changed("workspace") {
+			profile.Workspace, _ = cmd.Flags().GetString("workspace")
+		}
+		if cmd.Flags().Changed("base-url") {
+			profile.BaseURL, _ = cmd.Flags().GetString("base-url")
+		}
+		if cmd.Flags().Changed("output") {
+			profile.Output, _ = cmd.Flags().GetString("output")
+			if _, err := output.Parse(profile.Output); err != nil {
+				return validationErrorf("invalid output: %w", err)
+			}
+		}
+
+		for _, limit := range []struct {
+			flag string
+			dest func(*config.RateLimit) *int
+		}{
+			{"rate-limit", func(r *config.RateLimit) *int { return &r.RequestsPerMinute }},
+			{"rate-burst", func(r *config.RateLimit) *int { return &r.Burst }},
+			{"max-in-flight", func(r *config.RateLimit) *int { return &r.MaxInFlight }},
+			{"max-in-flight-writes", func(r *config.RateLimit) *int { return &r.MaxInFlightWrites }},
+		} {
+			if !cmd.Flags().Changed(limit.flag) {
+				continue
+			}
+			if profile.RateLimit == nil {
+				profile.RateLimit = &config.RateLimit{}
+			}
+			*limit.dest(profile.RateLimit), _ = cmd.Flags().GetInt(limit.flag)
+		}
+		if profile.RateLimit != nil && *profile.RateLimit == (config.RateLimit{}) {
+			profile.RateLimit = nil
+		}
+
+		appConfig.SetProfile(name, profile)
+
+		if use, _ := cmd.Flags().GetBool("use"); use {
+			appConfig.Use(name)
+		}
+
+		if err := saveConfig(name, profile); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		if previous != nil && profile.Credentials != previous {
+			// The tokens now live in the new store
+			old := &config.Profile{Credentials: previous}
+			oldStore, _ := credentialStore(old)
+			newStore, _ := credentialStore(profile)
+			if oldStore != newStore {
+				if err := deleteSecrets(name, old); err != nil {
+					log.Printf("Warning: %v", err)
+				}
+			}
+		}
+
+		if ok {
+			fmt.Printf("✓ Profile %s updated\n", name)
+		} else {
+			fmt.Printf("✓ Profile %s added\n", name)
+		}
+		if appConfig.CurrentProfile == name {
+			fmt.Printf("  Current profile: %s\n", name)
+		}
+		return nil
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	Long:  `List all profiles. The current profile is marked with an asterisk.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names := appConfig.ProfileNames()
+		if len(names) == 0 {
+			fmt.Println("No profiles configured. Add one with 'utka config add <name> --token <token>'")
+			return nil
+		}
+
+		for _, name := range names {
+			marker := " "
+			if name == appConfig.CurrentProfile {
+				marker = "*"
+			}
+			profile := appConfig.Profiles[name]
+			if profile.Workspace != "" {
+				fmt.Printf("%s %s (workspace: %s)\n", marker, name, profile.Workspace)
+			} else {
+				fmt.Printf("%s %s\n", marker, name)
+			}
+		}
+		return nil
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:               "show [name]",
+	Short:             "Show a profile",
+	Long:              `Show the settings of a profile, or of the current profile if no name is given. Tokens are masked.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: firstArg(completeProfiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := appConfig.CurrentProfile
+		if len(args) > 0 {
+			name = args[0]
+		}
+		if name == "" {
+			return validationErrorf("no current profile. Specify a profile name or run 'utka config use <name>'")
+		}
+
+		profile, err := appConfig.Profile(name)
+		if err != nil {
+			return notFoundErrorf("%w", err)
+		}
+
+		fmt.Printf("Profile:   %s\n", name)
+		switch {
+		case profile.Credentials == nil:
+			fmt.Printf("Token:     %s\n", maskToken(profile.Token))
+		case profile.Credentials.Backend == config.BackendCommand:
+			fmt.Printf("Token:     (from command: %s)\n", profile.Credentials.Command)
+		default:
+			fmt.Printf("Token:     (encrypted file: %s)\n", valueOrDefault(profile.Credentials.Path, "default"))
+		}
+		if profile.OAuth != nil {
+			fmt.Printf("OAuth:     client %s\n", profile.OAuth.ClientID)
+		}
+		fmt.Printf("Workspace: %s\n", valueOrDefault(profile.Workspace, "(none)"))
+		fmt.Printf("Base URL:  %s\n", valueOrDefault(profile.BaseURL, "(default)"))
+		fmt.Printf("Output:    %s\n", valueOrDefault(profile.Output, "(default)"))
+		limits := profileLimits(profile)
+		fmt.Printf("Limits:    %s requests/min, %s in flight (%s writes)\n",
+			limitString(limits.RequestsPerMinute), limitString(limits.MaxInFlight), limitString(limits.MaxInFlightWrites))
+		fmt.Printf("Config:    %s\n", appConfig.Path())
+		return nil
+	},
+}
+
+var configUseCmd = &cobra.Command{
+	Use:               "use <name>",
+	Short:             "Switch the current profile",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: firstArg(completeProfiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := appConfig.Use(args[0]); err != nil {
+			return notFoundErrorf("%w", err)
+		}
+
+		if err := appConfig.Save(); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		fmt.Printf("✓ Switched to profile %s\n", args[0])
+		return nil
+	},
+}
+
+var configRemoveCmd = &cobra.Command{
+	Use:               "remove <name>",
+	Short:             "Remove a profile",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: firstArg(completeProfiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, err := appConfig.Profile(args[0])
+		if err != nil {
+			return notFoundErrorf("%w", err)
+		}
+		if err := deleteSecrets(args[0], profile); err != nil {
+			return err
+		}
+
+		if err := appConfig.RemoveProfile(args[0]); err != nil {
+			return err
+		}
+
+		if err := appConfig.Save(); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		fmt.Printf("✓ Profile %s removed\n", args[0])
+		return nil
+	},
+}
+
+var configFilterCmd = &cobra.Command{
+	Use:   "filter",
+	Short: "Manage saved event filters",
+	Long: `Save event filter expressions under a name, to use them with -f @name,
+--exclude @name or a --sink @name: prefix of 'events get' and 'events poll'.
+Saved filters are shared by all profiles.`,
+}
+
+var configFilterSetCmd = &cobra.Command{
+	Use:   "set <name> <expression>",
+	Short: "Save an event filter",
+	Long: `Save an event filter expression under a name, replacing any filter saved
+under it before. The expression is checked before it is saved.
+
+Example:
+  utka config filter set mine 'by("me") && resourceIs("task")'`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: firstArg(completeFilters),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, expression := args[0], args[1]
+		if !filterName.MatchString(name) {
+			return validationErrorf("invalid filter name %q: use letters, digits, - and _", name)
+		}
+		if _, err := filter.Compile(expression, filter.Options{}); err != nil {
+			return validationErrorf("%v", err)
+		}
+
+		appConfig.SetFilter(name, expression)
+		if err := appConfig.Save(); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		fmt.Printf("✓ Filter %s saved\n", name)
+		return nil
+	},
+}
+
+var configFilterListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved event filters",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names := appConfig.FilterNames()
+		if len(names) == 0 {
+			fmt.Println("No saved filters. Save one with 'utka config filter set <name> <expression>'")
+			return nil
+		}
+
+		for _, name := range names {
+			fmt.Printf("%s: %s\n", name, appConfig.Filters[name])
+		}
+		return nil
+	},
+}
+
+var configFilterRemoveCmd = &cobra.Command{
+	Use:               "remove <name>",
+	Short:             "Remove a saved event filter",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: firstArg(completeFilters),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := appConfig.RemoveFilter(args[0]); err != nil {
+			return notFoundErrorf("%w", err)
+		}
+
+		if err := appConfig.Save(); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		fmt.Printf("✓ Filter %s removed\n", args[0])
+		return nil
+	},
+}
+
+// filterName is the form of saved filter names, which end at the first
+// colon of a --sink @name: prefix.
+var filterName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func init() {
+	configAddCmd.Flags().String("token", "", "Asana personal access token")
+	configAddCmd.Flags().String("workspace", "", "Default workspace GID or name")
+	configAddCmd.Flags().String("base-url", "", "Asana API base URL (defaults to "+client.BaseURL+")")
+	configAddCmd.Flags().String("output", "", "Default output format: "+output.Names)
+	configAddCmd.Flags().String("credential-store", "", "Where to keep tokens: config, file or command")
+	configAddCmd.Flags().String("credential-file", "", "Encrypted credentials file (defaults to credentials.enc next to the config file)")
+	configAddCmd.Flags().String("credential-command", "", "Command that prints the token, for --credential-store command")
+	configAddCmd.Flags().Int("rate-limit", 0, "Requests per minute (0 restores the default of 1500, -1 disables the limit)")
+	configAddCmd.Flags().Int("rate-burst", 0, "Requests that may be sent at once (defaults to a fifteenth of the rate limit)")
+	configAddCmd.Flags().Int("max-in-flight", 0, "Maximum concurrent requests (0 restores the default of 50, -1 disables the limit)")
+	configAddCmd.Flags().Int("max-in-flight-writes", 0, "Maximum concurrent POST, PUT and DELETE requests (0 restores the default of 15, -1 disables the limit)")
+	configAddCmd.Flags().Bool("use", false, "Make this the current profile")
+
+	configCmd.AddCommand(configAddCmd)
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configUseCmd)
+	configCmd.AddCommand(configRemoveCmd)
+
+	configFilterCmd.AddCommand(configFilterSetCmd)
+	configFilterCmd.AddCommand(configFilterListCmd)
+	configFilterCmd.AddCommand(configFilterRemoveCmd)
+	configCmd.AddCommand(configFilterCmd)
+
+	rootCmd.AddCommand(configCmd)
+}
+
+// loadConfig reads the config file named by --config, $UTKA_CONFIG or the
+// default location into appConfig.
+func loadConfig(cmd *cobra.Command) error {
+	path, _ := cmd.Flags().GetString("config")
+	if path == "" {
+		var err error
+		path, err = config.DefaultPath()
+		if err != nil {
+			return err
+		}
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	appConfig = cfg
+	return nil
+}
+
+// loadProfile loads the config and selects the active profile.
+func loadProfile(cmd *cobra.Command) error {
+	if err := loadConfig(cmd); err != nil {
+		return err
+	}
+
+	name, _ := cmd.Flags().GetString("profile")
+	if name == "" {
+		name = os.Getenv("UTKA_PROFILE")
+	}
+	if name == "" {
+		name = appConfig.CurrentProfile
+	}
+	if name == "" {
+		return nil
+	}
+
+	profile, err := appConfig.Profile(name)
+	if err != nil {
+		return notFoundErrorf("%w", err)
+	}
+
+	activeProfile = profile
+	activeProfileName = name
+	return nil
+}
+
+// workspaceFlag returns the GID of the workspace named by the --workspace
+// flag. Without the flag, the workspace is that of the first related task or
+// project, the active profile's default workspace, or the token's only
+// workspace, in that order. It returns "" if none of them applies.
+func workspaceFlag(cmd *cobra.Command, related ...relatedResource) (string, error) {
+	if workspace, _ := cmd.Flags().GetString("workspace"); workspace != "" {
+		return resolveRef(cmd, resolve.Workspace, workspace)
+	}
+
+	for _, resource := range related {
+		if resource.gid == "" {
+			continue
+		}
+		workspace, err := resolver.WorkspaceOf(cmd.Context(), resource.kind, resource.gid)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up the workspace of %s %s: %w", resource.kind, resource.gid, err)
+		}
+		if workspace != "" {
+			return workspace, nil
+		}
+	}
+
+	if activeProfile != nil && activeProfile.Workspace != "" {
+		return resolveRef(cmd, resolve.Workspace, activeProfile.Workspace)
+	}
+	return inferWorkspace(cmd)
+}
+
+// fieldOptions returns the manager options selected by the global --fields
+// flag.
+func fieldOptions(cmd *cobra.Command) client.Options {
+	fields, _ := cmd.Flags().GetString("fields")
+	return client.Options{Fields: client.ParseFields(fields)}
+}
+
+func maskToken(token string) string {
+	if token == "" {
+		return "(none)"
+	}
+	if len(token) <= 8 {
+		return "********"
+	}
+	return token[:4] + "…" + token[len(token)-4:]
+}
+
+func valueOrDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// profileLimits returns the client-side rate limits for a profile: the
+// defaults, overridden by the profile's rate_limit settings.
+func profileLimits(profile *config.Profile) client.Limits {
+	limits := client.DefaultLimits()
+	if profile == nil || profile.RateLimit == nil {
+		return limits
+	}
+
+	for _, override := range []struct {
+		value int
+		dest  *int
+	}{
+		{profile.RateLimit.RequestsPerMinute, &limits.RequestsPerMinute},
+		{profile.RateLimit.Burst, &limits.Burst},
+		{profile.RateLimit.MaxInFlight, &limits.MaxInFlight},
+		{profile.RateLimit.MaxInFlightWrites, &limits.MaxInFlightWrites},
+	} {
+		switch {
+		case override.value < 0:
+			*override.dest = 0
+		case override.value > 0:
+			*override.dest = override.value
+		}
+	}
+	return limits
+}
+
+func limitString(limit int) string {
+	if limit <= 0 {
+		return "unlimited"
+	}
+	return strconv.Itoa(limit)
+}
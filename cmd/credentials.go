@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/octoberswimmer/utka/config"
+	"github.com/octoberswimmer/utka/credentials"
+)
+
+// credentialStores caches opened stores so a passphrase is asked for at most
+// once per run.
+var credentialStores = map[string]credentials.Store{}
+
+// secretsLoaded records the profiles whose secrets loadSecrets filled in.
+var secretsLoaded = map[*config.Profile]bool{}
+
+// credentialStore returns the credential store configured for profile, or nil
+// if the profile keeps its tokens in the config file.
+func credentialStore(profile *config.Profile) (credentials.Store, error) {
+	if profile == nil || profile.Credentials == nil {
+		return nil, nil
+	}
+
+	var key string
+	var open func() credentials.Store
+	switch settings := profile.Credentials; settings.Backend {
+	case config.BackendFile:
+		path := settings.Path
+		if path == "" {
+			path = filepath.Join(filepath.Dir(appConfig.Path()), "credentials.enc")
+		}
+		key = "file:" + path
+		open = func() credentials.Store { return credentials.NewFileStore(path, readPassphrase) }
+	case config.BackendCommand:
+		if settings.Command == "" {
+			return nil, errors.New("credential store \"command\" requires a command")
+		}
+		key = "command:" + settings.Command
+		open = func() credentials.Store { return credentials.NewCommandStore(settings.Command) }
+	default:
+		return nil, fmt.Errorf("unknown credential store %q: must be file or command", settings.Backend)
+	}
+
+	store, ok := credentialStores[key]
+	if !ok {
+		store = open()
+		credentialStores[key] = store
+	}
+	return store, nil
+}
+
+// Keys under which a profile's secrets are kept in its credential store.
+func tokenKey(profileName string) string        { return profileName + "/token" }
+func accessTokenKey(profileName string) string  { return profileName + "/oauth/access_token" }
+func refreshTokenKey(profileName string) string { return profileName + "/oauth/refresh_token" }
+
+// storesOAuthTokens reports whether the profile's OAuth tokens live in its
+// credential store. The command backend only supplies a personal access token,
+// so OAuth tokens of such profiles stay in the config file.
+func storesOAuthTokens(profile *config.Profile) bool {
+	return profile.Credentials != nil && profile.Credentials.Backend == config.BackendFile
+}
+
+// loadSecrets fills in the profile's tokens from its credential store, so the
+// rest of the command can use the profile as if the tokens were in the config
+// file. Use saveConfig to write the config back without them.
+func loadSecrets(name string, profile *config.Profile) error {
+	store, err := credentialStore(profile)
+	if store == nil || err != nil || secretsLoaded[profile] {
+		return err
+	}
+
+	fields := map[string]*string{tokenKey(name): &profile.Token}
+	if storesOAuthTokens(profile) && profile.OAuth != nil {
+		fields[accessTokenKey(name)] = &profile.OAuth.AccessToken
+		fields[refreshTokenKey(name)] = &profile.OAuth.RefreshToken
+	}
+
+	for key, field := range fields {
+		if *field != "" {
+			// Not yet moved out of the config file
+			continue
+		}
+		secret, err := store.Get(key)
+		if err != nil && !errors.Is(err, credentials.ErrNotFound) {
+			return fmt.Errorf("failed to read credentials for profile %s: %w", name, err)
+		}
+		*field = secret
+	}
+
+	secretsLoaded[profile] = true
+	return nil
+}
+
+// saveConfig saves the config file after moving the named profile's tokens to
+// its credential store, so they are never written to the config in plaintext.
+// The store is only updated if the secrets were loaded with loadSecrets.
+func saveConfig(name string, profile *config.Profile) error {
+	store, err := credentialStore(profile)
+	if err != nil {
+		return err
+	}
+	if store == nil || !secretsLoaded[profile] {
+		return appConfig.Save()
+	}
+
+	token := profile.Token
+	defer func() { profile.Token = token }()
+	profile.Token = ""
+
+	if profile.Credentials.Backend == config.BackendFile {
+		if err := storeSecret(store, tokenKey(name), token); err != nil {
+			return err
+		}
+	}
+
+	if storesOAuthTokens(profile) && profile.OAuth != nil {
+		access, refresh := profile.OAuth.AccessToken, profile.OAuth.RefreshToken
+		defer func() { profile.OAuth.AccessToken, profile.OAuth.RefreshToken = access, refresh }()
+		profile.OAuth.AccessToken, profile.OAuth.RefreshToken = "", ""
+
+		if err := storeSecret(store, accessTokenKey(name), access); err != nil {
+			return err
+		}
+		if err := storeSecret(store, refreshTokenKey(name), refresh); err != nil {
+			return err
+		}
+	}
+
+	return appConfig.Save()
+}
+
+// deleteSecrets removes the named profile's tokens from its credential store.
+func deleteSecrets(name string, profile *config.Profile) error {
+	if !storesOAuthTokens(profile) {
+		return nil
+	}
+
+	store, err := credentialStore(profile)
+	if err != nil {
+		return err
+	}
+	for _, key := range []string{tokenKey(name), accessTokenKey(name), refreshTokenKey(name)} {
+		if err := store.Delete(key); err != nil {
+			return fmt.Errorf("failed to delete credentials for profile %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func storeSecret(store credentials.Store, key, secret string) error {
+	var err error
+	if secret == "" {
+		err = store.Delete(key)
+	} else {
+		err = store.Set(key, secret)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save credentials: %w", err)
+	}
+	return nil
+}
+
+// readPassphrase returns $UTKA_PASSPHRASE, or prompts for the passphrase on
+// the terminal. A new passphrase is asked for twice.
+func readPassphrase(create bool) (string, error) {
+	if passphrase := os.Getenv("UTKA_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+
+	if completing {
+		// The shell waits for completions and would hang on a prompt
+		return "", errors.New("cannot prompt for a passphrase while completing; set UTKA_PASSPHRASE")
+	}
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return "", errors.New("no terminal to prompt for a passphrase; set UTKA_PASSPHRASE")
+	}
+
+	if !create {
+		return promptSecret("Credentials passphrase: ")
+	}
+
+	passphrase, err := promptSecret("New credentials passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	confirm, err := promptSecret("Confirm passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != confirm {
+		return "", errors.New("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// promptSecret reads a line from the terminal with echo turned off where stty
+// is available.
+func promptSecret(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+
+	if err := stty("-echo"); err == nil {
+		defer stty("echo")
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func stty(arg string) error {
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/octoberswimmer/utka/client"
+	"github.com/spf13/cobra"
+)
+
+// debugFile is the open --debug-file, closed by closeDebugFile.
+var debugFile *os.File
+
+// applyDebugFlags installs request logging on the client when --debug or
+// --trace is given.
+func applyDebugFlags(cmd *cobra.Command, c *client.Client) error {
+	middleware, err := debugMiddleware(cmd)
+	if err != nil || middleware == nil {
+		return err
+	}
+	c.Use(middleware)
+	return nil
+}
+
+// debugMiddleware returns the request logger selected by --debug, --trace
+// and --debug-file, or nil if logging is off.
+func debugMiddleware(cmd *cobra.Command) (client.Middleware, error) {
+	// The client may be set up more than once, as for every completion
+	closeDebugFile()
+
+	debug, _ := cmd.Flags().GetBool("debug")
+	trace, _ := cmd.Flags().GetBool("trace")
+	if !debug && !trace {
+		return nil, nil
+	}
+
+	var w io.Writer = os.Stderr
+	if path, _ := cmd.Flags().GetString("debug-file"); path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open debug file: %w", err)
+		}
+		debugFile = f
+		w = f
+	}
+
+	return client.DebugMiddleware(w, client.DebugOptions{Trace: trace}), nil
+}
+
+// closeDebugFile closes the --debug-file, if one is open.
+func closeDebugFile() {
+	if debugFile == nil {
+		return
+	}
+	if err := debugFile.Close(); err != nil {
+		log.Printf("Warning: failed to close debug file: %v", err)
+	}
+	debugFile = nil
+}
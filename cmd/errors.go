@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/octoberswimmer/utka/client"
+	"github.com/octoberswimmer/utka/output"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes. Scripts depend on them, so existing codes must not change.
+const (
+	exitError       = 1   // any failure not listed below
+	exitValidation  = 2   // invalid flags, arguments or input, including 400 Bad Request
+	exitAuth        = 3   // missing or rejected credentials (401 Unauthorized, 403 Forbidden)
+	exitNotFound    = 4   // 404 Not Found
+	exitRateLimited = 5   // 429 Too Many Requests, once retries are exhausted
+	exitPartial     = 6   // some items of a bulk operation failed
+	exitInterrupted = 130 // cancelled with SIGINT or SIGTERM
+)
+
+// Error kinds, as reported in JSON error objects.
+const (
+	kindError       = "error"
+	kindAPI         = "api_error"
+	kindValidation  = "validation"
+	kindAuth        = "auth"
+	kindNotFound    = "not_found"
+	kindRateLimited = "rate_limited"
+	kindPartial     = "partial_failure"
+	kindInterrupted = "interrupted"
+)
+
+// commandStarted is set once cobra has parsed and validated the command
+// line and the command starts running. Errors returned before then are
+// mistakes in the command line.
+var commandStarted bool
+
+// startCommand is called first by every PersistentPreRunE. Cobra checks
+// required flags only after the pre-run hooks, so they are checked here to
+// report them as command-line mistakes.
+func startCommand(cmd *cobra.Command) error {
+	if err := cmd.ValidateRequiredFlags(); err != nil {
+		return err
+	}
+	if err := cmd.ValidateFlagGroups(); err != nil {
+		return err
+	}
+	commandStarted = true
+	return nil
+}
+
+// commandError is an error with an explicit kind and exit code.
+type commandError struct {
+	kind string
+	code int
+	err  error
+}
+
+func (e *commandError) Error() string { return e.err.Error() }
+func (e *commandError) Unwrap() error { return e.err }
+
+// validationErrorf reports invalid flags, arguments or input.
+func validationErrorf(format string, args ...interface{}) error {
+	return &commandError{kind: kindValidation, code: exitValidation, err: fmt.Errorf(format, args...)}
+}
+
+// authErrorf reports missing or unusable credentials.
+func authErrorf(format string, args ...interface{}) error {
+	return &commandError{kind: kindAuth, code: exitAuth, err: fmt.Errorf(format, args...)}
+}
+
+// notFoundErrorf reports a missing resource, for messages that replace the
+// API's 404 error.
+func notFoundErrorf(format string, args ...interface{}) error {
+	return &commandError{kind: kindNotFound, code: exitNotFound, err: fmt.Errorf(format, args...)}
+}
+
+// partialFailuref reports that some items of a bulk operation failed after
+// the results have been printed.
+func partialFailuref(format string, args ...interface{}) error {
+	return &commandError{kind: kindPartial, code: exitPartial, err: fmt.Errorf(format, args...)}
+}
+
+// classifyError returns the kind of err and the exit code it maps to.
+func classifyError(err error) (string, int) {
+	var cmdErr *commandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.kind, cmdErr.code
+	}
+
+	if apiErr, ok := client.AsAPIError(err); ok {
+		switch apiErr.StatusCode {
+		case http.StatusBadRequest:
+			return kindValidation, exitValidation
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return kindAuth, exitAuth
+		case http.StatusNotFound:
+			return kindNotFound, exitNotFound
+		case http.StatusTooManyRequests:
+			return kindRateLimited, exitRateLimited
+		}
+		return kindAPI, exitError
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return kindInterrupted, exitInterrupted
+	}
+	return kindError, exitError
+}
+
+// errorObject is the JSON form of an error, written under JSON output.
+type errorObject struct {
+	Error struct {
+		Kind      string               `json:"kind"`
+		Message   string               `json:"message"`
+		ExitCode  int                  `json:"exit_code"`
+		Status    int                  `json:"status,omitempty"`
+		RequestID string               `json:"request_id,omitempty"`
+		Details   []client.ErrorDetail `json:"details,omitempty"`
+	} `json:"error"`
+}
+
+// renderError writes err to stderr and returns the exit code for it. Under
+// JSON or NDJSON output the error is written as a single-line JSON object,
+// so programs reading the output can parse failures too.
+func renderError(cmd *cobra.Command, err error) int {
+	if !commandStarted {
+		var cmdErr *commandError
+		if !errors.As(err, &cmdErr) {
+			err = &commandError{kind: kindValidation, code: exitValidation, err: err}
+		}
+	}
+	kind, code := classifyError(err)
+
+	if errorsAsJSON(cmd) {
+		var obj errorObject
+		obj.Error.Kind = kind
+		obj.Error.Message = err.Error()
+		obj.Error.ExitCode = code
+		if apiErr, ok := client.AsAPIError(err); ok {
+			obj.Error.Status = apiErr.StatusCode
+			obj.Error.RequestID = apiErr.RequestID
+			obj.Error.Details = apiErr.Errors
+		}
+		json.NewEncoder(os.Stderr).Encode(obj)
+		return code
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if !commandStarted && cmd != nil {
+		fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", cmd.CommandPath())
+	}
+	return code
+}
+
+// errorsAsJSON reports whether JSON output was selected with the global
+// --output flag, the command's deprecated --json flag or the profile's
+// output preference.
+func errorsAsJSON(cmd *cobra.Command) bool {
+	spec := rootCmd.PersistentFlags().Lookup("output").Value.String()
+	if cmd != nil && spec == "" {
+		if jsonFlag := cmd.Flags().Lookup("json"); jsonFlag != nil && jsonFlag.Value.String() == "true" {
+			return true
+		}
+	}
+	if spec == "" && activeProfile != nil {
+		spec = activeProfile.Output
+	}
+	format, err := output.Parse(spec)
+	return err == nil && (format.Name == output.JSON || format.Name == output.NDJSON)
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/octoberswimmer/utka/asanatest"
+	"github.com/octoberswimmer/utka/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err      error
+		wantKind string
+		wantCode int
+	}{
+		{errors.New("boom"), kindError, exitError},
+		{validationErrorf("gid is required"), kindValidation, exitValidation},
+		{fmt.Errorf("failed to get task: %w", &client.APIError{StatusCode: 404}), kindNotFound, exitNotFound},
+		{&client.APIError{StatusCode: 400}, kindValidation, exitValidation},
+		{&client.APIError{StatusCode: 401}, kindAuth, exitAuth},
+		{&client.APIError{StatusCode: 403}, kindAuth, exitAuth},
+		{&client.APIError{StatusCode: 429}, kindRateLimited, exitRateLimited},
+		{&client.APIError{StatusCode: 500}, kindAPI, exitError},
+		{partialFailuref("1 of 2 failed"), kindPartial, exitPartial},
+		{fmt.Errorf("failed to list tasks: %w", context.Canceled), kindInterrupted, exitInterrupted},
+	}
+	for _, test := range tests {
+		kind, code := classifyError(test.err)
+		if kind != test.wantKind || code != test.wantCode {
+			t.Errorf("classifyError(%v) = %s, %d, want %s, %d", test.err, kind, code, test.wantKind, test.wantCode)
+		}
+	}
+}
+
+// runAgainstFake runs utka with a profile pointing at server and returns the
+// command's error. The profile's default workspace is workspace, if set.
+func runAgainstFake(t *testing.T, server *asanatest.Server, token, workspace string, args ...string) error {
+	t.Helper()
+
+	useFake(t, server, token, workspace)
+	return runUtka(t, args...)
+}
+
+// useFake makes the following runUtka calls use a fresh config whose
+// current profile points at server, and a fresh cache directory.
+func useFake(t *testing.T, server *asanatest.Server, token, workspace string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := fmt.Sprintf("current_profile: fake\nprofiles:\n  fake:\n    token: %s\n    base_url: %s/api/1.0\n    workspace: %q\n", token, server.URL, workspace)
+	if err := os.WriteFile(path, []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("UTKA_CONFIG", path)
+	t.Setenv("ASANA_PERSONAL_ACCESS_TOKEN", "")
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+}
+
+// runUtka runs utka with args, discarding its output, and returns the
+// command's error.
+func runUtka(t *testing.T, args ...string) error {
+	t.Helper()
+
+	stdout := os.Stdout
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	os.Stdout = devNull
+	defer func() { os.Stdout = stdout }()
+
+	t.Cleanup(func() { resetFlags(rootCmd) })
+
+	commandStarted = false
+	rootCmd.SetArgs(args)
+	err = rootCmd.Execute()
+	resetFlags(rootCmd)
+	return err
+}
+
+// resetFlags restores the flags of cmd and its subcommands to their
+// defaults, as cobra keeps flag values between executions.
+func resetFlags(cmd *cobra.Command) {
+	reset := func(flag *pflag.Flag) {
+		if !flag.Changed {
+			return
+		}
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			slice.Replace(nil)
+		} else {
+			flag.Value.Set(flag.DefValue)
+		}
+		flag.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+func TestCommandExitCodes(t *testing.T) {
+	server := asanatest.NewServer()
+	defer server.Close()
+	server.Token = "secret"
+	workspace := server.AddWorkspace(asanatest.Workspace{Name: "Acme"})
+	task := server.AddTask(asanatest.Task{Name: "Existing", Workspace: workspace.GID})
+
+	tests := []struct {
+		name  string
+		token string
+		args  []string
+		want  int
+	}{
+		{"not found", "secret", []string{"task", "get", "--gid", "404404"}, exitNotFound},
+		{"rejected token", "wrong", []string{"task", "get", "--gid", task.GID}, exitAuth},
+		{"missing required flag", "secret", []string{"webhook", "get"}, exitValidation},
+		{"invalid output format", "secret", []string{"workspace", "list", "-o", "xml"}, exitValidation},
+		{"record with replay", "secret", []string{"workspace", "list", "--record", "a.yaml", "--replay", "b.yaml"}, exitValidation},
+		{"unknown profile", "secret", []string{"config", "use", "missing"}, exitNotFound},
+		{"remove unknown profile", "secret", []string{"config", "remove", "missing"}, exitNotFound},
+		{"partial failure", "secret", []string{"task", "bulk", "--gids", task.GID + ",404404", "--completed"}, exitPartial},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := runAgainstFake(t, server, test.token, "", test.args...)
+			if err == nil {
+				t.Fatalf("utka %s succeeded", strings.Join(test.args, " "))
+			}
+			if !commandStarted {
+				err = &commandError{kind: kindValidation, code: exitValidation, err: err}
+			}
+			if _, code := classifyError(err); code != test.want {
+				t.Errorf("utka %s: exit code %d, want %d (%v)", strings.Join(test.args, " "), code, test.want, err)
+			}
+		})
+	}
+}
@@ -1,13 +1,24 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"iter"
 	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
-	"github.com/expr-lang/expr"
+	"github.com/octoberswimmer/utka/client"
 	eventsLib "github.com/octoberswimmer/utka/events"
+	"github.com/octoberswimmer/utka/events/filter"
+	"github.com/octoberswimmer/utka/output"
+	"github.com/octoberswimmer/utka/projects"
+	"github.com/octoberswimmer/utka/resolve"
 	"github.com/spf13/cobra"
 )
 
@@ -33,126 +44,499 @@ to the 'event' variable with all its fields. Examples:
   -f 'event.user.name == "John Doe"'
   -f 'event.action == "changed" && event.change.new_value.gid == "1210930954402852"'
   -f 'event.change.new_value.display_value == "Client Meeting or Introduction"'
+` + filterHelp + `
 
-Note: The filter will skip events where the expression cannot be evaluated.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		resource, _ := cmd.Flags().GetString("gid")
+Without --sync, the command continues from the sync token stored by the last
+'events get' or 'events poll' of the resource with the same profile, and
+stores the new one for the next call. The first call only stores a token to
+start from. Use --no-cursor to neither read nor store it.
+
+By default the whole response is printed as JSON, including the sync token for
+the next call. With --output, the events are printed in the selected format and
+the sync token is written to stderr.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resource, err := resolveFlag(cmd, "gid", resolve.Resource)
+		if err != nil {
+			return err
+		}
 		syncToken, _ := cmd.Flags().GetString("sync")
-		filterExpr, _ := cmd.Flags().GetString("filter")
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
 
 		if resource == "" {
-			log.Fatal("Resource GID is required")
+			return validationErrorf("resource GID is required")
 		}
-
-		events, err := eventManager.GetByResource(resource, syncToken)
+		filters, err := eventFilters(cmd)
 		if err != nil {
-			log.Fatalf("Failed to get events: %v", err)
+			return err
 		}
 
-		// Apply filter if provided
-		if filterExpr != "" {
-			// Compile the expression without type checking to work with dynamic maps
-			program, err := expr.Compile(filterExpr, expr.AsBool())
-			if err != nil {
-				log.Fatalf("Failed to compile filter expression: %v", err)
+		cursors := cursorStore(cmd)
+		if syncToken == "" && cursors != nil {
+			if syncToken, err = storedSyncToken(cursors, resource); err != nil {
+				return err
 			}
+		}
 
-			var filteredEvents []eventsLib.Event
-			for _, event := range events.Data {
-				// Convert event to a map for safer field access
-				eventJSON, _ := json.Marshal(event)
-				var eventMap map[string]interface{}
-				json.Unmarshal(eventJSON, &eventMap)
+		events, err := eventManager.GetByResourceContext(cmd.Context(), resource, syncToken)
+		var pageErr error
+		if err != nil && events != nil {
+			// A later page failed: the events before it are printed and
+			// their sync token saved before the failure is reported
+			pageErr, err = err, nil
+		}
+		if client.IsSyncTokenExpired(err) {
+			fresh, ok := eventsLib.FreshSyncToken(err)
+			if !ok {
+				return validationErrorf("sync token invalid or too old. Run 'utka events sync --gid %s' to get a fresh one", resource)
+			}
+			if cursors == nil {
+				return validationErrorf("sync token invalid or too old. Continue with the fresh sync token: utka events get --gid %s --sync %s", resource, fresh)
+			}
 
-				env := map[string]interface{}{
-					"event": eventMap,
-				}
+			if err := saveSyncToken(cursors, resource, fresh); err != nil {
+				return err
+			}
+			if syncToken != "" {
+				return validationErrorf("sync token invalid or too old, so events since it were missed. The stored sync token was replaced with a fresh one; run the command again for events from now on")
+			}
+			// Without a sync token, Asana answers with one to start from
+			events, err = &eventsLib.EventsResponse{Data: []eventsLib.Event{}, Sync: fresh}, nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get events: %w", err)
+		}
 
-				result, err := expr.Run(program, env)
-				if err != nil {
-					// Skip events that cause evaluation errors
-					continue
+		if !filters.Empty() {
+			matching := []eventsLib.Event{}
+			for _, event := range events.Data {
+				if filters.Match(eventsLib.SourcedEvent{Source: resource, Event: event}) {
+					matching = append(matching, event)
 				}
+			}
+			events.Data = matching
+		}
 
-				if result == true {
-					filteredEvents = append(filteredEvents, event)
+		if format.IsText() {
+			if err := printJSON(events); err != nil {
+				return err
+			}
+		} else {
+			printer := newPrinter(format, output.EventColumns)
+			for _, event := range events.Data {
+				if err := printItem(printer, event); err != nil {
+					return abortPrinter(printer, err)
 				}
 			}
-
-			// Update response with filtered events
-			events.Data = filteredEvents
+			if err := closePrinter(printer); err != nil {
+				return err
+			}
+			if events.Sync != "" {
+				fmt.Fprintf(os.Stderr, "Sync token: %s\n", events.Sync)
+			}
 		}
 
-		printJSON(events)
+		// Only move the cursor once the events are printed
+		if cursors != nil && events.Sync != "" {
+			if err := saveSyncToken(cursors, resource, events.Sync); err != nil {
+				return err
+			}
+		}
+		if pageErr != nil {
+			return fmt.Errorf("failed to get all events: %w", pageErr)
+		}
+		return nil
 	},
 }
 
 var eventsPollCmd = &cobra.Command{
 	Use:   "poll",
 	Short: "Poll events continuously",
-	Long: `Continuously poll for events from a specific resource.
+	Long: `Continuously poll for events from one or more resources.
+
+Resources are given with --gid (repeat it or separate GIDs with commas), read
+from a file with --gids-file (one per line, - for stdin), or taken from all
+projects of a workspace with --all-projects or of a team with --team. When
+watching a workspace or team, projects created while polling are picked up
+every --discover-interval. Up to --concurrency resources are polled at a time,
+and all requests share the profile's rate limit. Each event carries the GID of
+the resource it was read from in its "source" field.
+
+If no sync token is provided, polling of each resource resumes from the sync
+token stored by the last 'events get' or 'events poll' of the resource with the
+same profile, or starts from the current end of its events. The tokens are
+stored once events are printed and written to the sinks, so a restarted poll
+continues where the last one stopped; events handled just before a crash may be
+handled again. Use
+--no-cursor to neither read nor store them. --sync is only accepted when polling
+a single resource.
+
+If a sync token expires while polling, for example after the machine was
+asleep, a warning that events of the resource were missed is logged and polling
+continues from a fresh sync token. A resource that is deleted is no longer
+polled.
+Polling stops cleanly on Ctrl-C (SIGINT) or SIGTERM.
+
+Examples:
+  utka events poll --gid 111,222
+  utka events poll --all-projects --workspace Acme --concurrency 8
+  utka project list -o jsonpath=.gid | utka events poll --gids-file -
+  utka events poll --gid 111 --sink file:events.ndjson --sink http:https://example.com/hook
+  utka events poll --all-projects -f 'resourceIs("task")' --exclude 'by("me")'
+
+With --sink, events are sent to sinks instead of stdout (add --output to print
+them as well). Repeat --sink to send each event to several sinks:
+  file:PATH  append NDJSON to PATH, rotated at 100MB keeping 5 backups;
+             change with file:PATH?max-size=10MB&backups=3
+  exec:CMD   run a shell command per event, with the event as JSON on stdin
+             and the GID of its resource in $UTKA_EVENT_SOURCE
+  http:URL   POST each event as JSON, retrying failures with backoff
+  unix:PATH  write NDJSON to a Unix socket
+Prefix a sink with @NAME: to send it only the events matching the saved filter
+NAME, as in --sink @urgent:exec:notify-send. A failing sink is logged and does
+not stop polling or the other sinks. Each sink queues up to 1000 events while
+it falls behind; once a queue is full, polling waits for it. Sync tokens are
+only stored once the sinks have written the events before them, and on exit
+the queues get 10 seconds to be written, so events still queued then are
+polled again by the next run.
 
-If no sync token is provided, the command will automatically fetch one to start polling.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		resource, _ := cmd.Flags().GetString("gid")
+Use -f and --exclude to choose the events that are printed and sent to sinks.
+` + filterHelp + `
+
+With --output, events are printed in the selected format as they arrive and
+progress messages are written to stderr.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		syncToken, _ := cmd.Flags().GetString("sync")
 		interval, _ := cmd.Flags().GetDuration("interval")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		discoverInterval, _ := cmd.Flags().GetDuration("discover-interval")
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
 
-		if resource == "" {
-			log.Fatal("Resource GID is required")
+		if concurrency < 1 {
+			return validationErrorf("--concurrency must be at least 1")
+		}
+		resources, discover, err := pollResources(cmd)
+		if err != nil {
+			return err
 		}
+		if syncToken != "" && (len(resources) != 1 || discover != nil) {
+			return validationErrorf("--sync can only be used when polling a single resource")
+		}
+		filters, err := eventFilters(cmd)
+		if err != nil {
+			return err
+		}
+		sinkSpecs, _ := cmd.Flags().GetStringArray("sink")
+		sink, err := openSinks(cmd, sinkSpecs)
+		if err != nil {
+			return err
+		}
+		printEvents := sink == nil || cmd.Flags().Changed("output")
 
-		// If no sync token provided, get one automatically
-		if syncToken == "" {
-			fmt.Printf("No sync token provided. Fetching initial sync token for resource %s...\n", resource)
-			events, err := eventManager.InitializeSync(resource)
-			if err != nil {
-				log.Fatalf("Failed to initialize sync: %v", err)
+		// Keep stdout for the events themselves when they are meant for
+		// another program
+		status := io.Writer(os.Stdout)
+		var printer *output.Printer
+		if !format.IsText() {
+			status = os.Stderr
+			printer = newPrinter(format, output.SourcedEventColumns)
+		}
+		stop := func() error {
+			var errs []error
+			if printer != nil {
+				errs = append(errs, closePrinter(printer))
+			}
+			if sink != nil {
+				errs = append(errs, sink.Close())
+			}
+			return errors.Join(errs...)
+		}
+
+		cursors := cursorStore(cmd)
+		start := map[string]string{}
+		if syncToken != "" {
+			start[resources[0]] = syncToken
+		} else if cursors != nil {
+			for _, resource := range resources {
+				token, err := storedSyncToken(cursors, resource)
+				if err != nil {
+					return err
+				}
+				if token != "" {
+					start[resource] = token
+				}
+			}
+			if len(start) > 0 {
+				fmt.Fprintf(status, "Resuming %d of %d resource(s) from their stored sync tokens\n", len(start), len(resources))
 			}
-			syncToken = events.Sync
-			fmt.Printf("Got sync token: %s\n", syncToken)
-			fmt.Printf("Found %d events in current state\n\n", len(events.Data))
+		}
+		if cursors != nil {
+			eventManager.SetCursorStore(cursors, cursorProfile())
 		}
 
-		fmt.Printf("Starting to poll events for resource %s (interval: %v)...\n", resource, interval)
-		eventsChan, errorsChan := eventManager.Poll(resource, syncToken, interval)
+		if len(resources) == 1 && discover == nil {
+			fmt.Fprintf(status, "Starting to poll events for resource %s (interval: %v)...\n", resources[0], interval)
+		} else {
+			fmt.Fprintf(status, "Starting to poll events for %d resource(s) (interval: %v, concurrency: %d)...\n", len(resources), interval, concurrency)
+		}
+		eventsChan, errorsChan := eventManager.PollManyContext(cmd.Context(), resources, eventsLib.PollOptions{
+			Interval:         interval,
+			Workers:          concurrency,
+			Sync:             start,
+			Discover:         discover,
+			DiscoverInterval: discoverInterval,
+			Acknowledge:      true,
+		})
 
+		// lastErr is returned if polling ends because every resource was
+		// dropped
+		var lastErr error
 		for {
 			select {
 			case event, ok := <-eventsChan:
 				if !ok {
-					fmt.Println("Event channel closed")
-					return
+					if cmd.Context().Err() != nil {
+						fmt.Fprintln(status, "Polling stopped")
+						return stop()
+					}
+					fmt.Fprintln(status, "Event channel closed")
+					return errors.Join(stop(), lastErr)
+				}
+				// Events are acknowledged once printed and queued for the
+				// sinks, which acknowledge them again once written. An event
+				// that could not be handled is never acknowledged, so its
+				// sync token is not saved and a restart reads it again.
+				if !filters.Match(event) {
+					event.Ack()
+					continue
+				}
+				if sink != nil {
+					if err := sink.Write(cmd.Context(), event); err != nil {
+						if cmd.Context().Err() != nil {
+							fmt.Fprintln(status, "Polling stopped")
+							return stop()
+						}
+						return errors.Join(fmt.Errorf("failed to write event to sink: %w", err), stop())
+					}
+				}
+				if !printEvents {
+					event.Ack()
+					continue
+				}
+				if printer == nil {
+					if err := printJSON(event); err != nil {
+						return errors.Join(err, stop())
+					}
+					event.Ack()
+					continue
 				}
-				printJSON(event)
+				if err := printItem(printer, event); err != nil {
+					return errors.Join(err, stop())
+				}
+				if err := printer.Flush(); err != nil {
+					return errors.Join(fmt.Errorf("failed to write output: %w", err), stop())
+				}
+				event.Ack()
 			case err, ok := <-errorsChan:
 				if !ok {
-					fmt.Println("Error channel closed")
-					return
+					if cmd.Context().Err() != nil {
+						fmt.Fprintln(status, "Polling stopped")
+						return stop()
+					}
+					fmt.Fprintln(status, "Error channel closed")
+					return errors.Join(stop(), lastErr)
+				}
+				if eventsLib.IsGap(err) {
+					log.Printf("Warning: %v", err)
+					continue
 				}
+				lastErr = err
 				log.Printf("Error polling events: %v", err)
 			}
 		}
 	},
 }
 
+// openSinks creates the sinks given with --sink, combined into one when
+// there are several. It returns nil without any.
+func openSinks(cmd *cobra.Command, specs []string) (eventsLib.Sink, error) {
+	var sinks eventsLib.MultiSink
+	for _, spec := range specs {
+		sink, err := openSink(cmd, spec)
+		if err != nil {
+			sinks.Close()
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	switch len(sinks) {
+	case 0:
+		return nil, nil
+	case 1:
+		return sinks[0], nil
+	}
+	return sinks, nil
+}
+
+// openSink creates the sink of one --sink spec, which may start with the
+// @name: of a saved filter for the events it receives.
+func openSink(cmd *cobra.Command, spec string) (eventsLib.Sink, error) {
+	var set *filter.Set
+	if rest, ok := strings.CutPrefix(spec, "@"); ok {
+		name, sinkSpec, ok := strings.Cut(rest, ":")
+		if !ok {
+			return nil, validationErrorf("invalid --sink %q: expected @filter:sink", spec)
+		}
+		filters, err := compileFilters(cmd, []string{"@" + name})
+		if err != nil {
+			return nil, err
+		}
+		set = &filter.Set{Include: filters, OnError: filterErrorReporter(cmd)}
+		spec = sinkSpec
+	}
+
+	parsed, err := eventsLib.ParseSink(spec)
+	if err != nil {
+		return nil, validationErrorf("invalid --sink: %v", err)
+	}
+
+	// Each sink writes from its own queue, so a slow one holds up neither
+	// polling nor the other sinks
+	sink := eventsLib.Sink(eventsLib.NewQueueSink(parsed, eventsLib.DefaultQueueSize, func(err error) {
+		log.Printf("Error writing event to sink: %v", err)
+	}))
+	if set != nil {
+		return filter.Sink(sink, set), nil
+	}
+	return sink, nil
+}
+
+// pollResources returns the resources events poll starts with. With
+// --all-projects or --team, it also returns the function that lists the
+// projects again, so new ones are polled too.
+func pollResources(cmd *cobra.Command) ([]string, func(context.Context) ([]string, error), error) {
+	refs, _ := cmd.Flags().GetStringSlice("gid")
+	if path, _ := cmd.Flags().GetString("gids-file"); path != "" {
+		fileRefs, err := readGIDsFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read resource GIDs: %w", err)
+		}
+		refs = append(refs, fileRefs...)
+	}
+
+	var resources []string
+	seen := map[string]bool{}
+	add := func(gids ...string) {
+		for _, gid := range gids {
+			if !seen[gid] {
+				seen[gid] = true
+				resources = append(resources, gid)
+			}
+		}
+	}
+	for _, ref := range refs {
+		gid, err := resolveRef(cmd, resolve.Resource, ref)
+		if err != nil {
+			return nil, nil, err
+		}
+		add(gid)
+	}
+
+	var discover func(context.Context) ([]string, error)
+	manager := projects.NewProjectManager(asanaClient)
+	allProjects, _ := cmd.Flags().GetBool("all-projects")
+	team, err := resolveFlag(cmd, "team", resolve.Team)
+	if err != nil {
+		return nil, nil, err
+	}
+	if team != "" {
+		discover = func(ctx context.Context) ([]string, error) {
+			return projectGIDs(manager.IterByTeam(ctx, team, false, 0, nameField))
+		}
+	} else if allProjects {
+		workspace, err := workspaceFlag(cmd)
+		if err != nil {
+			return nil, nil, err
+		}
+		if workspace == "" {
+			return nil, nil, validationErrorf("--all-projects needs a workspace. Use --workspace or set one in the profile.")
+		}
+		discover = func(ctx context.Context) ([]string, error) {
+			return projectGIDs(manager.IterByWorkspace(ctx, workspace, false, 0, nameField))
+		}
+	}
+
+	if discover != nil {
+		gids, err := discover(cmd.Context())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list projects: %w", err)
+		}
+		add(gids...)
+	} else if len(resources) == 0 {
+		return nil, nil, validationErrorf("no resources to poll. Use --gid, --gids-file, --all-projects or --team.")
+	}
+	return resources, discover, nil
+}
+
+// readGIDsFile reads GIDs like readGIDs from a file, or from stdin for "-".
+func readGIDsFile(path string) ([]string, error) {
+	if path == "-" {
+		return readGIDs(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readGIDs(f)
+}
+
+func projectGIDs(all iter.Seq2[projects.Project, error]) ([]string, error) {
+	var gids []string
+	for project, err := range all {
+		if err != nil {
+			return nil, err
+		}
+		gids = append(gids, project.GID)
+	}
+	return gids, nil
+}
+
 var eventsSyncCmd = &cobra.Command{
 	Use:   "sync",
 	Short: "Initialize or refresh sync token for a resource",
 	Long: `Initialize or refresh the sync token for a resource. Use this when you get
 'Sync token invalid or too old' errors. The command will fetch the current state
 and return a fresh sync token for future polling.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		resource, _ := cmd.Flags().GetString("gid")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resource, err := resolveFlag(cmd, "gid", resolve.Resource)
+		if err != nil {
+			return err
+		}
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
 
 		if resource == "" {
-			log.Fatal("Resource GID is required")
+			return validationErrorf("resource GID is required")
 		}
 
 		// Use InitializeSync to properly handle 412 errors
-		events, err := eventManager.InitializeSync(resource)
+		events, err := eventManager.InitializeSyncContext(cmd.Context(), resource)
 		if err != nil {
-			log.Fatalf("Failed to initialize sync: %v", err)
+			return fmt.Errorf("failed to initialize sync: %w", err)
+		}
+
+		if !format.IsText() {
+			result := syncResult{Resource: resource, Sync: events.Sync, Events: len(events.Data)}
+			return printResource(format, syncResultColumns, result)
 		}
 
 		fmt.Printf("Sync initialized for resource %s\n", resource)
@@ -161,26 +545,318 @@ and return a fresh sync token for future polling.`,
 			fmt.Printf("\nUse this token with: utka events get --gid %s --sync %s\n", resource, events.Sync)
 		}
 		fmt.Printf("Events in current state: %d\n", len(events.Data))
+		return nil
+	},
+}
+
+var eventsCursorCmd = &cobra.Command{
+	Use:   "cursor",
+	Short: "Inspect and reset stored sync tokens",
+	Long: `'events get' and 'events poll' store the sync token of each resource they
+read, per profile, in cursors.json next to the config file, and resume from it
+next time. These commands show and clear the stored tokens.`,
+}
+
+var eventsCursorListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stored sync tokens",
+	Long:  `List the stored sync tokens of all profiles.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		cursors, err := newCursorStore().List()
+		if err != nil {
+			return err
+		}
+
+		if !format.IsText() {
+			printer := newPrinter(format, cursorColumns)
+			for _, cursor := range cursors {
+				if err := printItem(printer, cursor); err != nil {
+					return abortPrinter(printer, err)
+				}
+			}
+			return closePrinter(printer)
+		}
+
+		if len(cursors) == 0 {
+			fmt.Println("No stored sync tokens")
+			return nil
+		}
+		for _, cursor := range cursors {
+			fmt.Printf("• %s (profile %s)\n", cursor.Resource, valueOrDefault(cursor.Profile, "(environment)"))
+			fmt.Printf("  Sync: %s\n", cursor.Sync)
+			fmt.Printf("  Updated: %s\n", cursor.Updated.Local().Format(time.RFC3339))
+		}
+		fmt.Printf("\nFound %d sync token(s)\n", len(cursors))
+		return nil
+	},
+}
+
+var eventsCursorShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the stored sync token of a resource",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resource, err := resolveFlag(cmd, "gid", resolve.Resource)
+		if err != nil {
+			return err
+		}
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		cursor, err := newCursorStore().Get(cursorProfile(), resource)
+		if errors.Is(err, eventsLib.ErrNoCursor) {
+			return notFoundErrorf("no stored sync token for resource %s", resource)
+		}
+		if err != nil {
+			return err
+		}
+		return printResource(format, cursorColumns, cursor)
+	},
+}
+
+var eventsCursorResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Forget the stored sync token of a resource",
+	Long: `Forget the stored sync token of a resource, or with --all of every resource
+of the profile. The next 'events get' or 'events poll' starts from the
+current state of the resource.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resource, err := resolveFlag(cmd, "gid", resolve.Resource)
+		if err != nil {
+			return err
+		}
+		all, _ := cmd.Flags().GetBool("all")
+
+		store := newCursorStore()
+		resources := []string{resource}
+		if all {
+			cursors, err := store.List()
+			if err != nil {
+				return err
+			}
+			resources = nil
+			for _, cursor := range cursors {
+				if cursor.Profile == cursorProfile() {
+					resources = append(resources, cursor.Resource)
+				}
+			}
+		}
+
+		for _, resource := range resources {
+			if err := store.Delete(cursorProfile(), resource); err != nil {
+				return err
+			}
+			fmt.Printf("✓ Reset sync token for resource %s\n", resource)
+		}
+		if all && len(resources) == 0 {
+			fmt.Println("No stored sync tokens")
+		}
+		return nil
 	},
 }
 
+var cursorColumns = []output.Column{
+	{Header: "PROFILE", Path: "profile"},
+	{Header: "RESOURCE", Path: "resource"},
+	{Header: "SYNC", Path: "sync"},
+	{Header: "UPDATED", Path: "updated"},
+}
+
+// newCursorStore returns the store of sync tokens, cursors.json next to the
+// config file.
+func newCursorStore() *eventsLib.FileCursorStore {
+	return eventsLib.NewFileCursorStore(filepath.Join(filepath.Dir(appConfig.Path()), "cursors.json"))
+}
+
+// cursorStore returns the store events get and poll resume from, or nil
+// with --no-cursor or when replaying a cassette.
+func cursorStore(cmd *cobra.Command) eventsLib.CursorStore {
+	if noCursor, _ := cmd.Flags().GetBool("no-cursor"); noCursor || authMethod == "replay" {
+		return nil
+	}
+	return newCursorStore()
+}
+
+// cursorProfile is the profile sync tokens are stored under: the active
+// profile when its token is in use, and "" for a token from the environment.
+func cursorProfile() string {
+	if authMethod == "profile" || authMethod == "oauth" {
+		return activeProfileName
+	}
+	return ""
+}
+
+// storedSyncToken returns the stored sync token of resource, or "" if there
+// is none.
+func storedSyncToken(cursors eventsLib.CursorStore, resource string) (string, error) {
+	cursor, err := cursors.Get(cursorProfile(), resource)
+	if errors.Is(err, eventsLib.ErrNoCursor) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the stored sync token: %w", err)
+	}
+	return cursor.Sync, nil
+}
+
+func saveSyncToken(cursors eventsLib.CursorStore, resource, syncToken string) error {
+	if err := cursors.Put(eventsLib.Cursor{Profile: cursorProfile(), Resource: resource, Sync: syncToken}); err != nil {
+		return fmt.Errorf("failed to store the sync token: %w", err)
+	}
+	return nil
+}
+
+// filterHelp documents -f and --exclude for get and poll.
+const filterHelp = `
+Expressions are checked when the command starts, so a misspelt field is an
+error rather than a filter that never matches. Besides 'event', they can use:
+  changed("name")     the event changed the given field
+  by("me")            the event was made by a user, given by GID, name or "me"
+  within("2h")        the event happened in the last 2 hours (or "30m", "7d")
+  resourceIs("task")  the event is about a task (or "project", "story", ...)
+Repeat -f to require several expressions, and use --exclude to drop the events
+matching an expression. Either flag also takes @NAME, the name of a filter saved
+with 'utka config filter set'. Events an expression cannot be evaluated on, such
+as event.change.new_value.gid when there is no new value, are skipped by -f and
+kept by --exclude; use --filter-errors to log them, or ?. as in
+event.change.new_value?.gid to avoid the error.`
+
+// eventFilters compiles the -f and --exclude expressions of cmd.
+func eventFilters(cmd *cobra.Command) (*filter.Set, error) {
+	include, _ := cmd.Flags().GetStringArray("filter")
+	exclude, _ := cmd.Flags().GetStringArray("exclude")
+
+	set := &filter.Set{OnError: filterErrorReporter(cmd)}
+	var err error
+	if set.Include, err = compileFilters(cmd, include); err != nil {
+		return nil, err
+	}
+	if set.Exclude, err = compileFilters(cmd, exclude); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// filterErrorReporter logs evaluation errors with --filter-errors, and
+// otherwise leaves them unreported.
+func filterErrorReporter(cmd *cobra.Command) func(error) {
+	if report, _ := cmd.Flags().GetBool("filter-errors"); report {
+		return func(err error) { log.Printf("Warning: %v", err) }
+	}
+	return nil
+}
+
+// compileFilters compiles expressions, given directly or as @name of a
+// saved filter.
+func compileFilters(cmd *cobra.Command, refs []string) ([]*filter.Filter, error) {
+	var filters []*filter.Filter
+	for _, ref := range refs {
+		expression := ref
+		if name, ok := strings.CutPrefix(ref, "@"); ok {
+			var err error
+			if expression, err = appConfig.Filter(name); err != nil {
+				return nil, validationErrorf("%v. Save it with 'utka config filter set %s <expression>'", err, name)
+			}
+		}
+
+		opts, err := filterOptions(cmd, expression)
+		if err != nil {
+			return nil, err
+		}
+		compiled, err := filter.Compile(expression, opts)
+		if err != nil {
+			return nil, validationErrorf("%v", err)
+		}
+		filters = append(filters, compiled)
+	}
+	return filters, nil
+}
+
+var byMe = regexp.MustCompile("by\\(\\s*[\"'`]me[\"'`]\\s*\\)")
+
+// filterOptions returns the options to compile expression with. The
+// authenticated user is only looked up for expressions that use by("me").
+func filterOptions(cmd *cobra.Command, expression string) (filter.Options, error) {
+	if !byMe.MatchString(expression) {
+		return filter.Options{}, nil
+	}
+	me, err := resolveRef(cmd, resolve.User, "me")
+	if err != nil {
+		return filter.Options{}, fmt.Errorf("failed to look up the authenticated user for by(\"me\"): %w", err)
+	}
+	return filter.Options{Me: me}, nil
+}
+
+// syncResult is the structured output of events sync.
+type syncResult struct {
+	Resource string `json:"resource"`
+	Sync     string `json:"sync"`
+	Events   int    `json:"events"`
+}
+
+var syncResultColumns = []output.Column{
+	{Header: "RESOURCE", Path: "resource"},
+	{Header: "SYNC", Path: "sync"},
+	{Header: "EVENTS", Path: "events"},
+}
+
 func init() {
-	eventsGetCmd.Flags().String("gid", "", "Resource GID (project, task, portfolio, etc.)")
+	eventsGetCmd.Flags().String("gid", "", "Resource GID (project, task, portfolio, etc.), Asana URL or project name")
 	eventsGetCmd.Flags().String("sync", "", "Sync token")
-	eventsGetCmd.Flags().StringP("filter", "f", "", "Filter expression to apply to events")
+	eventsGetCmd.Flags().StringArrayP("filter", "f", nil, "Only show events matching an expression or @saved filter (repeatable)")
+	eventsGetCmd.Flags().StringArray("exclude", nil, "Drop events matching an expression or @saved filter (repeatable)")
+	eventsGetCmd.Flags().Bool("filter-errors", false, "Log events a filter expression cannot be evaluated on")
+	eventsGetCmd.Flags().Bool("no-cursor", false, "Do not resume from or store the sync token of the resource")
 	eventsGetCmd.MarkFlagRequired("gid")
+	eventsGetCmd.RegisterFlagCompletionFunc("gid", completeProjects)
 
-	eventsSyncCmd.Flags().String("gid", "", "Resource GID (project, task, portfolio, etc.)")
+	eventsSyncCmd.Flags().String("gid", "", "Resource GID (project, task, portfolio, etc.), Asana URL or project name")
 	eventsSyncCmd.MarkFlagRequired("gid")
+	eventsSyncCmd.RegisterFlagCompletionFunc("gid", completeProjects)
+
+	eventsPollCmd.Flags().StringSlice("gid", nil, "Resource GIDs (project, task, portfolio, etc.), Asana URLs or project names; repeat or separate with commas")
+	eventsPollCmd.Flags().String("gids-file", "", "Read resource GIDs from a file, one per line (- for stdin)")
+	eventsPollCmd.Flags().Bool("all-projects", false, "Poll all projects of the workspace, including projects created while polling")
+	eventsPollCmd.Flags().String("workspace", "", "Workspace GID or name for --all-projects and --team names (defaults to the profile workspace)")
+	eventsPollCmd.Flags().String("team", "", "Poll all projects of a team, given by GID or name, including projects created while polling")
+	eventsPollCmd.Flags().String("sync", "", "Initial sync token of a single resource (optional, will be fetched automatically if not provided)")
+	eventsPollCmd.Flags().Duration("interval", eventsLib.DefaultPollInterval, "Poll interval of each resource")
+	eventsPollCmd.Flags().Int("concurrency", eventsLib.DefaultWorkers, "Maximum number of resources polled at once")
+	eventsPollCmd.Flags().Duration("discover-interval", eventsLib.DefaultDiscoverInterval, "How often to look for new projects with --all-projects or --team")
+	eventsPollCmd.Flags().Bool("no-cursor", false, "Do not resume from or store the sync tokens of the resources")
+	eventsPollCmd.Flags().StringArrayP("filter", "f", nil, "Only pass on events matching an expression or @saved filter (repeatable)")
+	eventsPollCmd.Flags().StringArray("exclude", nil, "Drop events matching an expression or @saved filter (repeatable)")
+	eventsPollCmd.Flags().Bool("filter-errors", false, "Log events a filter expression cannot be evaluated on")
+	eventsPollCmd.Flags().StringArray("sink", nil, "Send events to a sink instead of stdout: file:PATH, exec:COMMAND, http:URL or unix:PATH (repeatable)")
+	eventsPollCmd.MarkFlagsOneRequired("gid", "gids-file", "all-projects", "team")
+	eventsPollCmd.MarkFlagsMutuallyExclusive("all-projects", "team")
+	eventsPollCmd.RegisterFlagCompletionFunc("gid", completeProjectList)
+	eventsPollCmd.RegisterFlagCompletionFunc("workspace", completeWorkspaces)
+
+	eventsCursorShowCmd.Flags().String("gid", "", "Resource GID, Asana URL or project name")
+	eventsCursorShowCmd.MarkFlagRequired("gid")
+	eventsCursorShowCmd.RegisterFlagCompletionFunc("gid", completeProjects)
+
+	eventsCursorResetCmd.Flags().String("gid", "", "Resource GID, Asana URL or project name")
+	eventsCursorResetCmd.Flags().Bool("all", false, "Reset the sync tokens of all resources of the profile")
+	eventsCursorResetCmd.MarkFlagsOneRequired("gid", "all")
+	eventsCursorResetCmd.MarkFlagsMutuallyExclusive("gid", "all")
+	eventsCursorResetCmd.RegisterFlagCompletionFunc("gid", completeProjects)
 
-	eventsPollCmd.Flags().String("gid", "", "Resource GID (project, task, portfolio, etc.)")
-	eventsPollCmd.Flags().String("sync", "", "Initial sync token (optional, will be fetched automatically if not provided)")
-	eventsPollCmd.Flags().Duration("interval", 5*time.Second, "Poll interval")
-	eventsPollCmd.MarkFlagRequired("gid")
+	eventsCursorCmd.AddCommand(eventsCursorListCmd)
+	eventsCursorCmd.AddCommand(eventsCursorShowCmd)
+	eventsCursorCmd.AddCommand(eventsCursorResetCmd)
 
 	eventsCmd.AddCommand(eventsGetCmd)
 	eventsCmd.AddCommand(eventsSyncCmd)
 	eventsCmd.AddCommand(eventsPollCmd)
+	eventsCmd.AddCommand(eventsCursorCmd)
 
 	rootCmd.AddCommand(eventsCmd)
 }
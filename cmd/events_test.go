@@ -1,318 +1,148 @@
 package cmd
 
 import (
-	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 
-	"github.com/expr-lang/expr"
+	"github.com/octoberswimmer/utka/asanatest"
 )
 
-func TestEventFiltering(t *testing.T) {
-	tests := []struct {
-		name      string
-		events    []map[string]interface{}
-		filter    string
-		wantCount int
-		wantError bool
-	}{
-		{
-			name: "filter by action",
-			events: []map[string]interface{}{
-				{"action": "changed", "type": "task"},
-				{"action": "added", "type": "task"},
-				{"action": "changed", "type": "task"},
-			},
-			filter:    `event.action == "changed"`,
-			wantCount: 2,
-			wantError: false,
-		},
-		{
-			name: "filter by nested field",
-			events: []map[string]interface{}{
-				{
-					"action": "changed",
-					"resource": map[string]interface{}{
-						"resource_subtype": "default_task",
-					},
-				},
-				{
-					"action": "changed",
-					"resource": map[string]interface{}{
-						"resource_subtype": "milestone",
-					},
-				},
-			},
-			filter:    `event.action == "changed" && event.resource.resource_subtype == "default_task"`,
-			wantCount: 1,
-			wantError: false,
-		},
-		{
-			name: "filter with nil change field",
-			events: []map[string]interface{}{
-				{
-					"action": "changed",
-					"change": map[string]interface{}{
-						"field": "name",
-					},
-				},
-				{
-					"action": "added",
-					// no change field
-				},
-			},
-			filter:    `event.change.field == "name"`,
-			wantCount: 1,
-			wantError: false,
-		},
-		{
-			name: "filter by custom field new_value",
-			events: []map[string]interface{}{
-				{
-					"action": "changed",
-					"change": map[string]interface{}{
-						"field": "custom_fields",
-						"new_value": map[string]interface{}{
-							"gid":           "123456",
-							"display_value": "In Progress",
-						},
-					},
-				},
-				{
-					"action": "changed",
-					"change": map[string]interface{}{
-						"field": "custom_fields",
-						"new_value": map[string]interface{}{
-							"gid":           "789012",
-							"display_value": "Done",
-						},
-					},
-				},
-			},
-			filter:    `event.change.new_value.gid == "123456"`,
-			wantCount: 1,
-			wantError: false,
-		},
-		{
-			name: "complex filter with multiple conditions",
-			events: []map[string]interface{}{
-				{
-					"action": "changed",
-					"type":   "task",
-					"user": map[string]interface{}{
-						"name": "John Doe",
-					},
-					"change": map[string]interface{}{
-						"field": "name",
-					},
-				},
-				{
-					"action": "changed",
-					"type":   "task",
-					"user": map[string]interface{}{
-						"name": "Jane Smith",
-					},
-					"change": map[string]interface{}{
-						"field": "name",
-					},
-				},
-				{
-					"action": "added",
-					"type":   "task",
-					"user": map[string]interface{}{
-						"name": "John Doe",
-					},
-				},
-			},
-			filter:    `event.action == "changed" && event.user.name == "John Doe" && event.change.field == "name"`,
-			wantCount: 1,
-			wantError: false,
-		},
-		{
-			name: "filter handles nil gracefully",
-			events: []map[string]interface{}{
-				{
-					"action": "changed",
-					"change": nil,
-				},
-				{
-					"action": "changed",
-					"change": map[string]interface{}{
-						"new_value": nil,
-					},
-				},
-				{
-					"action": "changed",
-					"change": map[string]interface{}{
-						"new_value": map[string]interface{}{
-							"gid": "valid",
-						},
-					},
-				},
-			},
-			filter:    `event.change.new_value.gid == "valid"`,
-			wantCount: 1,
-			wantError: false,
-		},
-		{
-			name: "invalid expression syntax",
-			events: []map[string]interface{}{
-				{"action": "changed"},
-			},
-			filter:    `event.action ==`,
-			wantCount: 0,
-			wantError: true,
-		},
+func TestEventCursors(t *testing.T) {
+	server := asanatest.NewServer()
+	defer server.Close()
+	server.Token = "secret"
+	workspace := server.AddWorkspace(asanatest.Workspace{Name: "Acme"})
+	project := server.AddProject(asanatest.Project{Name: "Launch", Workspace: workspace.GID})
+
+	useFake(t, server, "secret", workspace.GID)
+	stored := func() string {
+		t.Helper()
+		cursor, err := newCursorStore().Get("fake", project.GID)
+		if err != nil {
+			t.Fatalf("Reading the stored sync token: %v", err)
+		}
+		return cursor.Sync
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Compile the expression
-			program, err := expr.Compile(tt.filter, expr.AsBool())
-			if tt.wantError {
-				if err == nil {
-					t.Errorf("Expected compilation error but got none")
-				}
-				return
-			}
-			if err != nil {
-				t.Fatalf("Failed to compile expression: %v", err)
-			}
+	// The first call stores a token to start from
+	if err := runUtka(t, "events", "get", "--gid", project.GID); err != nil {
+		t.Fatalf("events get without a sync token: %v", err)
+	}
+	first := stored()
 
-			// Filter events
-			var filteredEvents []map[string]interface{}
-			for _, event := range tt.events {
-				env := map[string]interface{}{
-					"event": event,
-				}
+	server.AddTask(asanatest.Task{Name: "Draft", Memberships: []asanatest.Membership{{Project: project.GID}}})
+	if err := runUtka(t, "events", "get", "--gid", project.GID); err != nil {
+		t.Fatalf("events get resuming from the stored token: %v", err)
+	}
+	second := stored()
+	if second == first {
+		t.Errorf("Stored sync token did not advance past the new event")
+	}
 
-				result, err := expr.Run(program, env)
-				if err != nil {
-					// Skip events that cause evaluation errors
-					continue
-				}
+	// --no-cursor leaves the stored token alone
+	if err := runUtka(t, "events", "get", "--gid", project.GID, "--no-cursor", "--sync", first); err != nil {
+		t.Fatalf("events get --no-cursor: %v", err)
+	}
+	if stored() != second {
+		t.Errorf("events get --no-cursor changed the stored sync token")
+	}
 
-				if result == true {
-					filteredEvents = append(filteredEvents, event)
-				}
-			}
+	// An expired token is replaced, but the gap is reported
+	server.ExpireSyncTokens()
+	err := runUtka(t, "events", "get", "--gid", project.GID)
+	if _, code := classifyError(err); code != exitValidation {
+		t.Errorf("events get with an expired stored token: exit code %d, want %d (%v)", code, exitValidation, err)
+	}
+	if stored() == second {
+		t.Errorf("Expired sync token was not replaced")
+	}
+	if err := runUtka(t, "events", "get", "--gid", project.GID); err != nil {
+		t.Errorf("events get after an expired token: %v", err)
+	}
 
-			if len(filteredEvents) != tt.wantCount {
-				t.Errorf("Expected %d filtered events, got %d", tt.wantCount, len(filteredEvents))
-			}
-		})
+	if err := runUtka(t, "events", "cursor", "reset", "--all"); err != nil {
+		t.Fatalf("events cursor reset: %v", err)
+	}
+	if cursors, err := newCursorStore().List(); err != nil || len(cursors) != 0 {
+		t.Errorf("Cursors after reset = %v, %v, want none", cursors, err)
 	}
 }
 
-func TestEventFilteringWithRealJSON(t *testing.T) {
-	// Test with actual JSON structure from Asana
-	eventJSON := `[
-		{
-			"action": "changed",
-			"type": "task",
-			"created_at": "2025-09-09T15:29:56.550Z",
-			"change": {
-				"field": "custom_fields",
-				"action": "changed",
-				"new_value": {
-					"gid": "1210930954402852",
-					"name": "Marketing Materials Status",
-					"display_value": "Client Meeting or Introduction",
-					"enum_value": {
-						"gid": "1210930954402856",
-						"name": "Client Meeting or Introduction"
-					}
-				}
-			}
-		},
-		{
-			"action": "changed",
-			"type": "task",
-			"created_at": "2025-09-09T15:29:26.698Z",
-			"change": {
-				"field": "completed",
-				"action": "changed"
-			}
-		},
-		{
-			"action": "added",
-			"type": "task",
-			"created_at": "2025-09-09T15:14:59.602Z",
-			"resource": {
-				"gid": "1211305321446116",
-				"resource_type": "task",
-				"name": "Another subtask",
-				"resource_subtype": "default_task"
-			}
-		}
-	]`
-
-	var events []map[string]interface{}
-	if err := json.Unmarshal([]byte(eventJSON), &events); err != nil {
-		t.Fatalf("Failed to unmarshal events: %v", err)
+func TestEventsPollValidation(t *testing.T) {
+	server := asanatest.NewServer()
+	defer server.Close()
+	server.Token = "secret"
+	workspace := server.AddWorkspace(asanatest.Workspace{Name: "Acme"})
+	launch := server.AddProject(asanatest.Project{Name: "Launch", Workspace: workspace.GID})
+	press := server.AddProject(asanatest.Project{Name: "Press", Workspace: workspace.GID})
+
+	useFake(t, server, "secret", workspace.GID)
+	empty := filepath.Join(t.TempDir(), "gids.txt")
+	if err := os.WriteFile(empty, []byte("# no resources yet\n"), 0600); err != nil {
+		t.Fatal(err)
 	}
 
 	tests := []struct {
-		name      string
-		filter    string
-		wantCount int
+		name string
+		args []string
 	}{
-		{
-			name:      "filter by custom field gid",
-			filter:    `event.change.new_value.gid == "1210930954402852"`,
-			wantCount: 1,
-		},
-		{
-			name:      "filter by display value",
-			filter:    `event.change.new_value.display_value == "Client Meeting or Introduction"`,
-			wantCount: 1,
-		},
-		{
-			name:      "filter by change field",
-			filter:    `event.change.field == "completed"`,
-			wantCount: 1,
-		},
-		{
-			name:      "filter by action and resource subtype",
-			filter:    `event.action == "added" && event.resource.resource_subtype == "default_task"`,
-			wantCount: 1,
-		},
-		{
-			name:      "filter all changed events",
-			filter:    `event.action == "changed"`,
-			wantCount: 2,
-		},
+		{"sync with several resources", []string{"--gid", launch.GID + "," + press.GID, "--sync", "token"}},
+		{"sync with discovery", []string{"--all-projects", "--sync", "token"}},
+		{"no concurrency", []string{"--gid", launch.GID, "--concurrency", "0"}},
+		{"empty GIDs file", []string{"--gids-file", empty}},
+		{"unknown sink", []string{"--gid", launch.GID, "--sink", "kafka:events"}},
 	}
+	for _, test := range tests {
+		err := runUtka(t, append([]string{"events", "poll"}, test.args...)...)
+		if _, code := classifyError(err); code != exitValidation {
+			t.Errorf("%s: exit code %d, want %d (%v)", test.name, code, exitValidation, err)
+		}
+	}
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			program, err := expr.Compile(tt.filter, expr.AsBool())
-			if err != nil {
-				t.Fatalf("Failed to compile expression: %v", err)
-			}
-
-			var filteredEvents []map[string]interface{}
-			for _, event := range events {
-				env := map[string]interface{}{
-					"event": event,
-				}
+func TestEventFilters(t *testing.T) {
+	server := asanatest.NewServer()
+	defer server.Close()
+	server.Token = "secret"
+	workspace := server.AddWorkspace(asanatest.Workspace{Name: "Acme"})
+	alice := server.AddUser(asanatest.User{Name: "Alice"})
+	server.SetMe(alice.GID)
+	project := server.AddProject(asanatest.Project{Name: "Launch", Workspace: workspace.GID})
+
+	useFake(t, server, "secret", workspace.GID)
+	if err := runUtka(t, "config", "filter", "set", "mine", `by("me") && resourceIs("task")`); err != nil {
+		t.Fatalf("Saving a filter: %v", err)
+	}
+	if expression, err := appConfig.Filter("mine"); err != nil || expression != `by("me") && resourceIs("task")` {
+		t.Errorf("Saved filter = %q, %v", expression, err)
+	}
 
-				result, err := expr.Run(program, env)
-				if err != nil {
-					continue
-				}
+	if err := runUtka(t, "events", "get", "--gid", project.GID, "-f", "@mine", "--exclude", `changed("name")`, "--filter-errors"); err != nil {
+		t.Errorf("events get with saved and inline filters: %v", err)
+	}
 
-				if result == true {
-					filteredEvents = append(filteredEvents, event)
-				}
-			}
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"misspelt field", []string{"events", "get", "--gid", project.GID, "-f", `event.actoin == "added"`}},
+		{"unknown saved filter", []string{"events", "get", "--gid", project.GID, "--exclude", "@theirs"}},
+		{"unknown sink filter", []string{"events", "poll", "--gid", project.GID, "--sink", "@theirs:file:events.ndjson"}},
+		{"invalid filter name", []string{"config", "filter", "set", "a:b", `changed("name")`}},
+		{"invalid saved expression", []string{"config", "filter", "set", "broken", `changed(`}},
+	}
+	for _, test := range tests {
+		err := runUtka(t, test.args...)
+		if _, code := classifyError(err); code != exitValidation {
+			t.Errorf("%s: exit code %d, want %d (%v)", test.name, code, exitValidation, err)
+		}
+	}
 
-			if len(filteredEvents) != tt.wantCount {
-				t.Errorf("Expected %d filtered events, got %d", tt.wantCount, len(filteredEvents))
-				for i, event := range filteredEvents {
-					eventJSON, _ := json.MarshalIndent(event, "", "  ")
-					t.Logf("Event %d: %s", i, string(eventJSON))
-				}
-			}
-		})
+	if err := runUtka(t, "config", "filter", "remove", "mine"); err != nil {
+		t.Fatalf("Removing a filter: %v", err)
+	}
+	if names := appConfig.FilterNames(); len(names) != 0 {
+		t.Errorf("Filters after removal = %v", names)
 	}
 }
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/octoberswimmer/utka/asanatest"
+	"github.com/spf13/cobra"
+)
+
+var mockCmd = &cobra.Command{
+	Use:   "mock",
+	Short: "Run a local fake of the Asana API",
+	Long: `Commands for running a sandbox that behaves like the Asana API without
+touching a real workspace.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// The mock server needs no Asana credentials
+		return startCommand(cmd)
+	},
+}
+
+var mockServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a fake Asana API on localhost",
+	Long: `Serve an in-memory fake of the Asana API, seeded from a JSON or YAML
+fixture file. State is kept in memory and lost when the server stops.
+
+The fake serves the endpoints utka uses: workspaces, users, projects,
+sections, tasks, webhooks, events (with sync tokens) and batch requests.
+Changes are recorded as events, and webhooks created through the API are
+activated with the X-Hook-Secret handshake and receive signed deliveries
+(X-Hook-Signature) like real Asana webhooks.
+
+Point utka at the server with a profile:
+
+  utka config add sandbox --token sandbox --base-url http://127.0.0.1:8080/api/1.0
+
+Example fixture:
+
+  workspaces:
+    - {gid: "1", name: Acme}
+  users:
+    - {gid: "10", name: Ada Lovelace, email: ada@example.com}
+  projects:
+    - {gid: "100", name: Launch, workspace: "1"}
+  sections:
+    - {gid: "110", name: To do, project: "100"}
+  tasks:
+    - name: Draft announcement
+      assignee: "10"
+      due_on: "2025-01-31"
+      memberships: [{project: "100", section: "110"}]
+
+With --debug or --trace, webhook deliveries are logged.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fixturePath, _ := cmd.Flags().GetString("fixture")
+		addr, _ := cmd.Flags().GetString("addr")
+		token, _ := cmd.Flags().GetString("token")
+		noWebhooks, _ := cmd.Flags().GetBool("no-webhooks")
+
+		fake := asanatest.NewFake()
+		fake.Token = token
+		if fixturePath != "" {
+			fixture, err := asanatest.LoadFixture(fixturePath)
+			if err != nil {
+				return err
+			}
+			if err := fake.Load(fixture); err != nil {
+				return fmt.Errorf("invalid fixture %s: %w", fixturePath, err)
+			}
+		}
+
+		if !noWebhooks {
+			httpClient := &http.Client{}
+			middleware, err := debugMiddleware(cmd)
+			if err != nil {
+				return err
+			}
+			if middleware != nil {
+				httpClient.Transport = middleware(http.DefaultTransport)
+			}
+			fake.DeliverWebhooks(cmd.Context(), httpClient)
+		}
+
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		server := &http.Server{Handler: logRequests(fake)}
+
+		go func() {
+			<-cmd.Context().Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			server.Shutdown(ctx)
+		}()
+
+		fmt.Printf("Mock Asana API listening on http://%s/api/1.0\n", listener.Addr())
+		if token != "" {
+			fmt.Printf("Only the token %q is accepted\n", token)
+		}
+		fmt.Println("Press Ctrl+C to stop")
+
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		return nil
+	},
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests logs one line per request served by the mock server.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		log.Printf("%s %s %d (%s)", r.Method, r.URL.RequestURI(), rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+func init() {
+	mockServeCmd.Flags().String("fixture", "", "JSON or YAML file with the initial workspaces, users, projects, sections, tasks and webhooks")
+	mockServeCmd.Flags().String("addr", "127.0.0.1:8080", "Address to listen on")
+	mockServeCmd.Flags().String("token", "", "Only accept this bearer token (any token is accepted by default)")
+	mockServeCmd.Flags().Bool("no-webhooks", false, "Do not perform webhook handshakes or deliver events to webhook targets")
+
+	mockCmd.AddCommand(mockServeCmd)
+
+	rootCmd.AddCommand(mockCmd)
+}
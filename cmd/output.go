@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/octoberswimmer/utka/output"
+	"github.com/spf13/cobra"
+)
+
+// outputFormat returns the format selected with --output, falling back to
+// the command's deprecated --json flag and then to the active profile's
+// output preference.
+func outputFormat(cmd *cobra.Command) (output.Format, error) {
+	spec, _ := cmd.Flags().GetString("output")
+	if !cmd.Flags().Changed("output") {
+		if jsonFlag := cmd.Flags().Lookup("json"); jsonFlag != nil && jsonFlag.Value.String() == "true" {
+			spec = jsonFlag.Annotations["output"][0]
+		} else if activeProfile != nil {
+			spec = activeProfile.Output
+		}
+	}
+
+	format, err := output.Parse(spec)
+	if err != nil {
+		return format, validationErrorf("%w", err)
+	}
+	return format, nil
+}
+
+// deprecatedJSONFlag adds the --json flag some commands had before --output
+// existed, as an alias for --output format.
+func deprecatedJSONFlag(cmd *cobra.Command, format string) {
+	cmd.Flags().Bool("json", false, "Same as --output "+format)
+	cmd.Flags().SetAnnotation("json", "output", []string{format})
+	cmd.Flags().MarkDeprecated("json", "use --output "+format)
+}
+
+// printResource writes a single resource in the selected format. Text
+// output is indented JSON.
+func printResource(format output.Format, columns []output.Column, v interface{}) error {
+	if format.IsText() {
+		return printJSON(v)
+	}
+	if err := output.Print(os.Stdout, format, columns, v); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// newPrinter returns a printer for streaming a list to stdout.
+func newPrinter(format output.Format, columns []output.Column) *output.Printer {
+	return output.NewPrinter(os.Stdout, format, columns)
+}
+
+// printItem writes one element of a list.
+func printItem(printer *output.Printer, v interface{}) error {
+	if err := printer.Print(v); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// closePrinter ends a list.
+func closePrinter(printer *output.Printer) error {
+	if err := printer.Close(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// abortPrinter ends a list cut short by err, so that what was printed is
+// still a complete document, and returns err. printer may be nil.
+func abortPrinter(printer *output.Printer, err error) error {
+	if printer != nil {
+		printer.Close()
+	}
+	return err
+}
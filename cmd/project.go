@@ -2,10 +2,13 @@ package cmd
 
 import (
 	"fmt"
-	"log"
+	"iter"
 	"strings"
 
+	"github.com/octoberswimmer/utka/client"
+	"github.com/octoberswimmer/utka/output"
 	"github.com/octoberswimmer/utka/projects"
+	"github.com/octoberswimmer/utka/resolve"
 	"github.com/spf13/cobra"
 )
 
@@ -15,9 +18,12 @@ var projectCmd = &cobra.Command{
 	Use:   "project",
 	Short: "Manage Asana projects",
 	Long:  `Commands for listing and retrieving information about Asana projects.`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		rootCmd.PersistentPreRun(cmd, args)
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := rootCmd.PersistentPreRunE(cmd, args); err != nil {
+			return err
+		}
 		projectManager = projects.NewProjectManager(asanaClient)
+		return nil
 	},
 }
 
@@ -25,113 +31,151 @@ var projectListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List projects",
 	Long:  `List all projects in a workspace or team.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		workspace, _ := cmd.Flags().GetString("workspace")
-		team, _ := cmd.Flags().GetString("team")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// With --team, --workspace is only where the team name is looked up
+		team, err := resolveFlag(cmd, "team", resolve.Team)
+		if err != nil {
+			return err
+		}
+		var workspace string
+		if team == "" {
+			if workspace, err = workspaceFlag(cmd); err != nil {
+				return err
+			}
+		}
 		archived, _ := cmd.Flags().GetBool("archived")
 		limit, _ := cmd.Flags().GetInt("limit")
-		jsonOutput, _ := cmd.Flags().GetBool("json")
-
-		if workspace == "" && team == "" {
-			log.Fatal("Either workspace or team GID is required")
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
 		}
 
-		if workspace != "" && team != "" {
-			log.Fatal("Please specify either workspace or team, not both")
+		if workspace == "" && team == "" {
+			return validationErrorf("either --workspace or --team is required, as this account has several workspaces")
 		}
 
-		var projectsList []projects.Project
-		var err error
+		var projectIter iter.Seq2[projects.Project, error]
 
 		if workspace != "" {
-			projectsList, err = projectManager.ListByWorkspace(workspace, archived, limit)
+			projectIter = projectManager.IterByWorkspace(cmd.Context(), workspace, archived, limit, fieldOptions(cmd))
 		} else {
-			projectsList, err = projectManager.ListByTeam(team, archived, limit)
+			projectIter = projectManager.IterByTeam(cmd.Context(), team, archived, limit, fieldOptions(cmd))
 		}
 
-		if err != nil {
-			log.Fatalf("Failed to list projects: %v", err)
+		// Projects are printed as each page arrives
+		var printer *output.Printer
+		if !format.IsText() {
+			printer = newPrinter(format, output.ProjectColumns)
+		}
+
+		count := 0
+		for project, err := range projectIter {
+			if err != nil {
+				return abortPrinter(printer, fmt.Errorf("failed to list projects: %w", err))
+			}
+			count++
+
+			if printer != nil {
+				if err := printItem(printer, project); err != nil {
+					return abortPrinter(printer, err)
+				}
+				continue
+			}
+
+			printProject(project)
 		}
 
-		if jsonOutput {
-			printJSON(projectsList)
-			return
+		if printer != nil {
+			return closePrinter(printer)
 		}
 
-		// Pretty print projects
-		if len(projectsList) == 0 {
+		if count == 0 {
 			fmt.Println("No projects found")
-			return
+			return nil
 		}
 
-		fmt.Printf("Found %d project(s):\n\n", len(projectsList))
-		for _, project := range projectsList {
-			status := ""
-			if project.Archived {
-				status = " [ARCHIVED]"
-			}
+		fmt.Printf("Found %d project(s)\n", count)
+		return nil
+	},
+}
 
-			fmt.Printf("• %s%s\n", project.Name, status)
-			fmt.Printf("  GID: %s\n", project.GID)
+func printProject(project projects.Project) {
+	status := ""
+	if project.Archived {
+		status = " [ARCHIVED]"
+	}
 
-			if project.Color != "" {
-				fmt.Printf("  Color: %s\n", project.Color)
-			}
+	fmt.Printf("• %s%s\n", project.Name, status)
+	fmt.Printf("  GID: %s\n", project.GID)
 
-			if project.Owner != nil && project.Owner.Name != "" {
-				fmt.Printf("  Owner: %s\n", project.Owner.Name)
-			}
+	if project.Color != "" {
+		fmt.Printf("  Color: %s\n", project.Color)
+	}
 
-			if project.CurrentStatus != nil && project.CurrentStatus.Title != "" {
-				fmt.Printf("  Status: %s (%s)\n", project.CurrentStatus.Title, project.CurrentStatus.Color)
-			}
+	if project.Owner != nil && project.Owner.Name != "" {
+		fmt.Printf("  Owner: %s\n", project.Owner.Name)
+	}
 
-			if project.DueDate != "" {
-				fmt.Printf("  Due: %s\n", project.DueDate)
-			}
+	if project.CurrentStatus != nil && project.CurrentStatus.Title != "" {
+		fmt.Printf("  Status: %s (%s)\n", project.CurrentStatus.Title, project.CurrentStatus.Color)
+	}
 
-			if project.Notes != "" {
-				// Truncate notes if too long
-				notes := strings.ReplaceAll(project.Notes, "\n", " ")
-				if len(notes) > 100 {
-					notes = notes[:97] + "..."
-				}
-				fmt.Printf("  Notes: %s\n", notes)
-			}
+	if project.DueDate != "" {
+		fmt.Printf("  Due: %s\n", project.DueDate)
+	}
 
-			fmt.Println()
+	if project.Notes != "" {
+		// Truncate notes if too long
+		notes := strings.ReplaceAll(project.Notes, "\n", " ")
+		if len(notes) > 100 {
+			notes = notes[:97] + "..."
 		}
-	},
+		fmt.Printf("  Notes: %s\n", notes)
+	}
+
+	fmt.Println()
 }
 
 var projectGetCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Get project details",
 	Long:  `Retrieve detailed information about a specific project.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		gid, _ := cmd.Flags().GetString("gid")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		gid, err := resolveFlag(cmd, "gid", resolve.Project)
+		if err != nil {
+			return err
+		}
 		if gid == "" {
-			log.Fatal("Project GID is required")
+			return validationErrorf("project GID is required")
 		}
 
-		project, err := projectManager.Get(gid)
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		project, err := projectManager.GetContext(cmd.Context(), gid, fieldOptions(cmd))
+		if client.IsNotFound(err) {
+			return notFoundErrorf("project %s not found", gid)
+		}
 		if err != nil {
-			log.Fatalf("Failed to get project: %v", err)
+			return fmt.Errorf("failed to get project: %w", err)
 		}
 
-		printJSON(project)
+		return printResource(format, output.ProjectColumns, project)
 	},
 }
 
 func init() {
-	projectListCmd.Flags().String("workspace", "", "Workspace GID")
-	projectListCmd.Flags().String("team", "", "Team GID")
+	projectListCmd.Flags().String("workspace", "", "Workspace GID or name (defaults to the profile workspace; with --team, where the team name is looked up)")
+	projectListCmd.Flags().String("team", "", "Team GID or name")
 	projectListCmd.Flags().Bool("archived", false, "Include archived projects")
 	projectListCmd.Flags().Int("limit", 0, "Limit number of results (0 for all)")
-	projectListCmd.Flags().Bool("json", false, "Output as JSON")
+	deprecatedJSONFlag(projectListCmd, output.NDJSON)
+	projectListCmd.RegisterFlagCompletionFunc("workspace", completeWorkspaces)
 
-	projectGetCmd.Flags().String("gid", "", "Project GID")
+	projectGetCmd.Flags().String("gid", "", "Project GID, name or URL")
 	projectGetCmd.MarkFlagRequired("gid")
+	projectGetCmd.RegisterFlagCompletionFunc("gid", completeProjects)
 
 	projectCmd.AddCommand(projectListCmd)
 	projectCmd.AddCommand(projectGetCmd)
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/octoberswimmer/utka/client"
+	"github.com/octoberswimmer/utka/resolve"
+	"github.com/spf13/cobra"
+)
+
+// resolver turns the names, URLs, emails and "me" accepted by flags such as
+// --project and --assignee into GIDs.
+var resolver *resolve.Resolver
+
+// onlyWorkspace memoizes inferWorkspace for the rest of the command.
+var onlyWorkspace *resolve.Match
+
+// relatedResource is a task or project a command works on. Names given to
+// the command are looked up in its workspace when there is no --workspace.
+type relatedResource struct {
+	kind resolve.Kind
+	gid  string
+}
+
+// newResolver returns a resolver that caches names in the user cache
+// directory and, on a terminal, asks which match was meant when a name is
+// ambiguous.
+func newResolver(c *client.Client) *resolve.Resolver {
+	var cache *resolve.Cache
+	if path, err := resolve.DefaultCachePath(); err == nil {
+		cache = resolve.NewCache(path, resolve.DefaultTTL)
+	}
+
+	r := resolve.NewResolver(c, cache)
+	if isTerminal(os.Stdin) && isTerminal(os.Stderr) {
+		r.Choose = chooseMatch
+	}
+	return r
+}
+
+// resolveFlag returns the GID named by the value of a string flag.
+func resolveFlag(cmd *cobra.Command, name string, kind resolve.Kind, related ...relatedResource) (string, error) {
+	ref, _ := cmd.Flags().GetString(name)
+	return resolveRef(cmd, kind, ref, related...)
+}
+
+// resolveRef returns the GID that ref names. Names other than workspace
+// names are looked up in the workspace chosen by workspaceFlag.
+func resolveRef(cmd *cobra.Command, kind resolve.Kind, ref string, related ...relatedResource) (string, error) {
+	if ref == "" || resolve.IsGID(ref) {
+		return ref, nil
+	}
+
+	var workspace string
+	if resolve.NeedsWorkspace(kind, ref) {
+		var err error
+		if workspace, err = workspaceFlag(cmd, related...); err != nil {
+			return "", err
+		}
+	}
+
+	gid, err := resolver.Resolve(cmd.Context(), kind, ref, workspace)
+	if err != nil {
+		return "", resolveError(kind, ref, err)
+	}
+	return gid, nil
+}
+
+// resolveSection returns the GID of the section that ref names in project,
+// which must already be resolved.
+func resolveSection(cmd *cobra.Command, ref, project string) (string, error) {
+	gid, err := resolver.ResolveSection(cmd.Context(), ref, project)
+	if err != nil {
+		return "", resolveError(resolve.Section, ref, err)
+	}
+	return gid, nil
+}
+
+// resolveError classifies an error resolving ref so it exits with the
+// matching code.
+func resolveError(kind resolve.Kind, ref string, err error) error {
+	var notFound *resolve.NotFoundError
+	var ambiguous *resolve.AmbiguousError
+	var invalid *resolve.InvalidError
+	switch {
+	case errors.As(err, &notFound):
+		return notFoundErrorf("%w", err)
+	case errors.Is(err, resolve.ErrWorkspaceRequired):
+		return validationErrorf("%w; use --workspace, or set a default with 'utka workspace use'", err)
+	case errors.Is(err, resolve.ErrProjectRequired):
+		return validationErrorf("%w; use --project", err)
+	case errors.As(err, &ambiguous), errors.As(err, &invalid):
+		return validationErrorf("%w", err)
+	}
+	return fmt.Errorf("failed to look up %s %q: %w", kind, ref, err)
+}
+
+// inferWorkspace returns the token's workspace if it has only one, and
+// remembers it as the default workspace of the profile the token came from.
+// It returns "" if the token has several workspaces.
+func inferWorkspace(cmd *cobra.Command) (string, error) {
+	if onlyWorkspace != nil {
+		return onlyWorkspace.GID, nil
+	}
+
+	match, ok, err := resolver.OnlyWorkspace(cmd.Context())
+	if err != nil {
+		return "", fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	onlyWorkspace = &match
+	if !ok {
+		return "", nil
+	}
+
+	// Only remember the workspace for the profile whose token was used, and
+	// not while completing, when the note would garble the command line
+	if activeProfile != nil && (authMethod == "profile" || authMethod == "oauth") && !completing {
+		activeProfile.Workspace = match.GID
+		if err := saveConfig(activeProfileName, activeProfile); err != nil {
+			log.Printf("Warning: failed to save the default workspace: %v", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Using %s, the only workspace of this account, as the default workspace of profile %s\n", match, activeProfileName)
+		}
+	}
+	return match.GID, nil
+}
+
+// chooseMatch asks on the terminal which of several matches was meant.
+func chooseMatch(kind resolve.Kind, ref string, matches []resolve.Match) (resolve.Match, error) {
+	fmt.Fprintf(os.Stderr, "%q matches several %ss:\n", ref, kind)
+	for i, match := range matches {
+		fmt.Fprintf(os.Stderr, "  %d. %s\n", i+1, match)
+	}
+	fmt.Fprintf(os.Stderr, "Enter a number (1-%d): ", len(matches))
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return resolve.Match{}, &resolve.AmbiguousError{Kind: kind, Ref: ref, Matches: matches}
+	}
+	choice, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || choice < 1 || choice > len(matches) {
+		return resolve.Match{}, validationErrorf("invalid choice %q", strings.TrimSpace(line))
+	}
+	return matches[choice-1], nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/octoberswimmer/utka/asanatest"
+)
+
+func TestResolveFlags(t *testing.T) {
+	server := asanatest.NewServer()
+	defer server.Close()
+	server.Token = "secret"
+	workspace := server.AddWorkspace(asanatest.Workspace{Name: "Acme"})
+	alice := server.AddUser(asanatest.User{Name: "Alice", Email: "alice@example.com"})
+	server.SetMe(alice.GID)
+	project := server.AddProject(asanatest.Project{Name: "Launch", Workspace: workspace.GID})
+	announce := server.AddTask(asanatest.Task{Name: "Write announcement", Memberships: []asanatest.Membership{{Project: project.GID}}})
+	server.AddTask(asanatest.Task{Name: "Review copy", Workspace: workspace.GID})
+	server.AddTask(asanatest.Task{Name: "Review slides", Workspace: workspace.GID})
+
+	// The profile names its workspace instead of giving the GID
+	if err := runAgainstFake(t, server, "secret", "Acme", "task", "complete", "--gid", "write announcement"); err != nil {
+		t.Fatalf("Completing a task by name: %v", err)
+	}
+	url := "https://app.asana.com/0/" + project.GID + "/" + announce.GID
+	if err := runAgainstFake(t, server, "secret", "Acme", "task", "edit", "--gid", url, "--assignee", "me"); err != nil {
+		t.Fatalf("Editing a task by URL: %v", err)
+	}
+	task, _ := server.Task(announce.GID)
+	if !task.Completed || task.Assignee != alice.GID {
+		t.Errorf("Task after completing and assigning: completed %v, assignee %q", task.Completed, task.Assignee)
+	}
+
+	tests := []struct {
+		ref  string
+		want int
+	}{
+		{"Review", exitValidation},
+		{"Nonexistent", exitNotFound},
+		{"https://app.asana.com/0/" + project.GID + "/list", exitValidation},
+	}
+	for _, test := range tests {
+		err := runAgainstFake(t, server, "secret", "Acme", "task", "get", "--gid", test.ref)
+		if _, code := classifyError(err); err == nil || code != test.want {
+			t.Errorf("task get --gid %q: exit code %d, want %d (%v)", test.ref, code, test.want, err)
+		}
+	}
+}
+
+func TestResolveTeamAndSectionFlags(t *testing.T) {
+	server := asanatest.NewServer()
+	defer server.Close()
+	server.Token = "secret"
+	workspace := server.AddWorkspace(asanatest.Workspace{Name: "Acme"})
+	server.AddUser(asanatest.User{Name: "Alice"})
+	team := server.AddTeam(asanatest.Team{Name: "Marketing", Workspace: workspace.GID})
+	project := server.AddProject(asanatest.Project{Name: "Launch", Workspace: workspace.GID, Team: team.GID})
+	drafts := server.AddSection(asanatest.Section{Name: "Drafts", Project: project.GID})
+	server.AddTask(asanatest.Task{Name: "Outline", Memberships: []asanatest.Membership{{Project: project.GID, Section: drafts.GID}}})
+
+	for _, args := range [][]string{
+		{"project", "list", "--team", "marketing"},
+		{"task", "list", "--project", "Launch", "--section", "drafts"},
+		{"task", "list", "--section", drafts.GID},
+	} {
+		if err := runAgainstFake(t, server, "secret", "Acme", args...); err != nil {
+			t.Errorf("%s: %v", strings.Join(args, " "), err)
+		}
+	}
+
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"project", "list", "--team", "Sales"}, exitNotFound},
+		{[]string{"task", "list", "--section", "Drafts"}, exitValidation},
+		{[]string{"task", "list", "--project", "Launch", "--section", "Done"}, exitNotFound},
+		{[]string{"task", "list", "--section", "Drafts", "--assignee", "me"}, exitValidation},
+	}
+	for _, test := range tests {
+		err := runAgainstFake(t, server, "secret", "Acme", test.args...)
+		if _, code := classifyError(err); err == nil || code != test.want {
+			t.Errorf("%s: exit code %d, want %d (%v)", strings.Join(test.args, " "), code, test.want, err)
+		}
+	}
+}
+
+func TestWorkspaceInference(t *testing.T) {
+	server := asanatest.NewServer()
+	defer server.Close()
+	server.Token = "secret"
+	workspace := server.AddWorkspace(asanatest.Workspace{Name: "Acme"})
+	alice := server.AddUser(asanatest.User{Name: "Alice"})
+	server.SetMe(alice.GID)
+	project := server.AddProject(asanatest.Project{Name: "Launch", Workspace: workspace.GID})
+	server.AddTask(asanatest.Task{Name: "Draft", Assignee: alice.GID, Workspace: workspace.GID})
+	server.AddWebhook(asanatest.Webhook{Resource: project.GID, Target: "https://example.com/hook"})
+
+	// The only workspace is used, and remembered in the profile
+	if err := runAgainstFake(t, server, "secret", "", "task", "list", "--assignee", "me"); err != nil {
+		t.Fatalf("Listing assigned tasks without a workspace: %v", err)
+	}
+	saved, err := os.ReadFile(appConfig.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(saved), "workspace: \""+workspace.GID+"\"") {
+		t.Errorf("Inferred workspace was not saved:\n%s", saved)
+	}
+
+	// With several workspaces, a resource's workspace is derived from it
+	server.AddWorkspace(asanatest.Workspace{Name: "Personal"})
+	if err := runAgainstFake(t, server, "secret", "", "webhook", "list", "--resource", "Launch"); err == nil {
+		t.Errorf("Resolving a project name without a workspace succeeded")
+	}
+	if err := runAgainstFake(t, server, "secret", "", "webhook", "list", "--resource", project.GID); err != nil {
+		t.Errorf("Listing webhooks of a resource without a workspace: %v", err)
+	}
+	err = runAgainstFake(t, server, "secret", "", "project", "list")
+	if _, code := classifyError(err); code != exitValidation {
+		t.Errorf("project list with several workspaces: exit code %d, want %d (%v)", code, exitValidation, err)
+	}
+}
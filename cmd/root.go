@@ -1,13 +1,17 @@
 package cmd
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/joho/godotenv"
 	"github.com/octoberswimmer/utka/client"
+	"github.com/octoberswimmer/utka/config"
 	"github.com/octoberswimmer/utka/events"
+	"github.com/octoberswimmer/utka/output"
 	"github.com/octoberswimmer/utka/webhooks"
 	"github.com/spf13/cobra"
 )
@@ -16,6 +20,17 @@ var (
 	asanaClient    *client.Client
 	webhookManager *webhooks.WebhookManager
 	eventManager   *events.EventManager
+
+	// appConfig is the loaded config file and activeProfile the profile
+	// selected by --profile, $UTKA_PROFILE or the config's current profile.
+	// activeProfile is nil when no profile is in use.
+	appConfig         *config.Config
+	activeProfile     *config.Profile
+	activeProfileName string
+
+	// authMethod records where the access token came from: "environment",
+	// "profile" or "oauth".
+	authMethod string
 )
 
 var rootCmd = &cobra.Command{
@@ -23,29 +38,111 @@ var rootCmd = &cobra.Command{
 	Short: "Asana API client for managing webhooks and events",
 	Long: `utka is a command-line tool for interacting with the Asana API.
 It provides commands for managing webhooks and retrieving events from Asana.`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		if err := godotenv.Load(); err != nil {
-			log.Printf("Warning: .env file not found: %v", err)
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := startCommand(cmd); err != nil {
+			return err
 		}
+		return setupClient(cmd)
+	},
+}
+
+// setupClient loads the active profile and its credentials, and creates the
+// API client and the managers and resolver that use it.
+func setupClient(cmd *cobra.Command) error {
+	if err := godotenv.Load(); err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: failed to load .env file: %v", err)
+	}
 
-		token := os.Getenv("ASANA_PERSONAL_ACCESS_TOKEN")
-		if token == "" {
-			log.Fatal("ASANA_PERSONAL_ACCESS_TOKEN not set in environment or .env file")
+	if err := loadProfile(cmd); err != nil {
+		return err
+	}
+
+	// An explicitly selected profile wins over the environment; otherwise
+	// the environment wins over the config's current profile.
+	token := os.Getenv("ASANA_PERSONAL_ACCESS_TOKEN")
+	authMethod = "environment"
+	explicitProfile := cmd.Flags().Changed("profile") || os.Getenv("UTKA_PROFILE") != ""
+	useOAuth := false
+	if replay, _ := cmd.Flags().GetString("replay"); replay != "" {
+		// Replayed responses need no credentials
+		token = "replay"
+		authMethod = "replay"
+	} else if activeProfile != nil && (explicitProfile || token == "") {
+		if err := loadSecrets(activeProfileName, activeProfile); err != nil {
+			return err
+		}
+		switch {
+		case activeProfile.OAuth != nil && activeProfile.OAuth.AccessToken != "":
+			oauthToken, err := currentOAuthToken(cmd.Context())
+			if err != nil {
+				return err
+			}
+			token = oauthToken
+			authMethod = "oauth"
+			useOAuth = true
+		case activeProfile.Token != "":
+			token = activeProfile.Token
+			authMethod = "profile"
 		}
+	}
 
-		asanaClient = client.NewClient(token)
-		webhookManager = webhooks.NewWebhookManager(asanaClient)
-		eventManager = events.NewEventManager(asanaClient)
-	},
+	if token == "" {
+		return authErrorf("no Asana token found. Set ASANA_PERSONAL_ACCESS_TOKEN in the environment or a .env file, add a profile with 'utka config add', or run 'utka auth login'")
+	}
+
+	asanaClient = client.NewClient(token)
+	if activeProfile != nil && activeProfile.BaseURL != "" {
+		asanaClient.SetBaseURL(activeProfile.BaseURL)
+	}
+	if activeProfile != nil && activeProfile.RateLimit != nil {
+		asanaClient.SetLimiter(client.NewLimiter(profileLimits(activeProfile)))
+	}
+	if useOAuth {
+		asanaClient.SetTokenRefresher(refreshOAuthToken)
+	}
+	// Completions run on every press of Tab, so they neither log requests
+	// nor record them over the --record cassette
+	if !completing {
+		if err := applyDebugFlags(cmd, asanaClient); err != nil {
+			return err
+		}
+	}
+	if err := applyCassetteFlags(cmd, asanaClient); err != nil {
+		return err
+	}
+	webhookManager = webhooks.NewWebhookManager(asanaClient)
+	eventManager = events.NewEventManager(asanaClient)
+	resolver = newResolver(asanaClient)
+	onlyWorkspace = nil
+	return nil
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	// Cancel in-flight requests and stop pollers on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	stop()
+	closeDebugFile()
+
+	if err != nil {
+		os.Exit(renderError(cmd, err))
 	}
 }
 
 func init() {
-	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	// Errors are reported by renderError, and usage only for mistakes in
+	// the command line
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+
+	rootCmd.PersistentFlags().String("profile", "", "Config profile to use (defaults to $UTKA_PROFILE or the current profile)")
+	rootCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	rootCmd.PersistentFlags().String("config", "", "Config file path (defaults to $UTKA_CONFIG or $XDG_CONFIG_HOME/utka/config.yaml)")
+	rootCmd.PersistentFlags().StringP("output", "o", "", "Output format: "+output.Names+" (defaults to the profile output, otherwise text)")
+	rootCmd.PersistentFlags().String("fields", "", "Comma-separated opt_fields or presets (minimal, default, full) to request for tasks and projects, e.g. default,custom_fields.number_value")
+	rootCmd.PersistentFlags().Bool("debug", false, "Log API requests and responses to stderr (credentials are redacted)")
+	rootCmd.PersistentFlags().Bool("trace", false, "Like --debug, but also log headers and response bodies")
+	rootCmd.PersistentFlags().String("debug-file", "", "Write --debug/--trace output to this file instead of stderr")
+	rootCmd.PersistentFlags().String("record", "", "Record API interactions to a sanitized cassette file")
+	rootCmd.PersistentFlags().String("replay", "", "Answer API requests from a cassette file instead of contacting Asana")
 }
@@ -1,11 +1,17 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
-	"log"
+	"io"
+	"iter"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/octoberswimmer/utka/client"
+	"github.com/octoberswimmer/utka/output"
+	"github.com/octoberswimmer/utka/resolve"
 	"github.com/octoberswimmer/utka/tasks"
 	"github.com/spf13/cobra"
 )
@@ -16,9 +22,12 @@ var taskCmd = &cobra.Command{
 	Use:   "task",
 	Short: "Manage Asana tasks",
 	Long:  `Commands for listing and retrieving information about Asana tasks.`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		rootCmd.PersistentPreRun(cmd, args)
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := rootCmd.PersistentPreRunE(cmd, args); err != nil {
+			return err
+		}
 		taskManager = tasks.NewTaskManager(asanaClient)
+		return nil
 	},
 }
 
@@ -26,21 +35,21 @@ var taskListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List tasks",
 	Long:  `List tasks in a project, section, or assigned to a user.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		project, _ := cmd.Flags().GetString("project")
 		section, _ := cmd.Flags().GetString("section")
 		assignee, _ := cmd.Flags().GetString("assignee")
-		workspace, _ := cmd.Flags().GetString("workspace")
 		completedDays, _ := cmd.Flags().GetInt("completed")
 		limit, _ := cmd.Flags().GetInt("limit")
-		jsonOutput, _ := cmd.Flags().GetBool("json")
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
 
-		// Count how many filters are specified
+		// Count how many filters are specified. With --section, --project
+		// is only where the section name is looked up.
 		filtersSpecified := 0
-		if project != "" {
-			filtersSpecified++
-		}
-		if section != "" {
+		if project != "" || section != "" {
 			filtersSpecified++
 		}
 		if assignee != "" {
@@ -48,143 +57,167 @@ var taskListCmd = &cobra.Command{
 		}
 
 		if filtersSpecified == 0 {
-			log.Fatal("One of --project, --section, or --assignee is required")
+			return validationErrorf("one of --project, --section, or --assignee is required")
 		}
 
 		if filtersSpecified > 1 {
-			log.Fatal("Please specify only one of --project, --section, or --assignee")
+			return validationErrorf("please specify only one of --project, --section, or --assignee")
 		}
 
-		// If assignee is specified, workspace is required
-		if assignee != "" && workspace == "" {
-			log.Fatal("--workspace is required when using --assignee")
+		// Assigned tasks are listed per workspace
+		var workspace string
+		if assignee != "" {
+			if workspace, err = workspaceFlag(cmd); err != nil {
+				return err
+			}
+			if workspace == "" {
+				return validationErrorf("--workspace is required when using --assignee, as this account has several workspaces")
+			}
+		}
+
+		if project, err = resolveRef(cmd, resolve.Project, project); err != nil {
+			return err
+		}
+		if section, err = resolveSection(cmd, section, project); err != nil {
+			return err
+		}
+		if assignee, err = resolveRef(cmd, resolve.User, assignee); err != nil {
+			return err
 		}
 
-		var tasksList []tasks.Task
-		var err error
+		var taskIter iter.Seq2[tasks.Task, error]
 
 		switch {
-		case project != "":
-			tasksList, err = taskManager.ListByProject(project, completedDays, limit)
 		case section != "":
-			tasksList, err = taskManager.ListBySection(section, completedDays, limit)
+			taskIter = taskManager.IterBySection(cmd.Context(), section, completedDays, limit, fieldOptions(cmd))
+		case project != "":
+			taskIter = taskManager.IterByProject(cmd.Context(), project, completedDays, limit, fieldOptions(cmd))
 		case assignee != "":
-			tasksList, err = taskManager.ListByAssignee(assignee, workspace, completedDays, limit)
-		}
-
-		if err != nil {
-			log.Fatalf("Failed to list tasks: %v", err)
+			taskIter = taskManager.IterByAssignee(cmd.Context(), assignee, workspace, completedDays, limit, fieldOptions(cmd))
 		}
 
-		if jsonOutput {
-			printJSON(tasksList)
-			return
+		// Tasks are printed as each page arrives rather than after the whole
+		// list has been fetched
+		var printer *output.Printer
+		if !format.IsText() {
+			printer = newPrinter(format, output.TaskColumns)
 		}
 
-		// Pretty print tasks
-		if len(tasksList) == 0 {
-			fmt.Println("No tasks found")
-			return
-		}
-
-		fmt.Printf("Found %d task(s):\n\n", len(tasksList))
+		count := 0
+		currentSection := ""
+		for task, err := range taskIter {
+			if err != nil {
+				return abortPrinter(printer, fmt.Errorf("failed to list tasks: %w", err))
+			}
+			count++
 
-		// Group tasks by section if they have memberships
-		var sectionMap = make(map[string][]tasks.Task)
-		var noSection []tasks.Task
+			if printer != nil {
+				if err := printItem(printer, task); err != nil {
+					return abortPrinter(printer, err)
+				}
+				continue
+			}
 
-		for _, task := range tasksList {
+			// Start a new group whenever the section changes
 			sectionName := ""
 			if len(task.Memberships) > 0 && task.Memberships[0].Section != nil {
 				sectionName = task.Memberships[0].Section.Name
 			}
-
-			if sectionName != "" {
-				sectionMap[sectionName] = append(sectionMap[sectionName], task)
-			} else {
-				noSection = append(noSection, task)
+			if sectionName != "" && sectionName != currentSection {
+				fmt.Printf("\n📁 %s\n", sectionName)
+				fmt.Println(strings.Repeat("-", 40))
 			}
+			currentSection = sectionName
+
+			printTask(task)
 		}
 
-		// Print tasks without sections first
-		if len(noSection) > 0 {
-			printTaskList(noSection)
+		if printer != nil {
+			return closePrinter(printer)
 		}
 
-		// Print tasks by section
-		for sectionName, sectionTasks := range sectionMap {
-			if len(noSection) > 0 || len(sectionMap) > 1 {
-				fmt.Printf("\n📁 %s\n", sectionName)
-				fmt.Println(strings.Repeat("-", 40))
-			}
-			printTaskList(sectionTasks)
+		if count == 0 {
+			fmt.Println("No tasks found")
+			return nil
 		}
+
+		fmt.Printf("Found %d task(s)\n", count)
+		return nil
 	},
 }
 
-func printTaskList(tasksList []tasks.Task) {
-	for _, task := range tasksList {
-		// Task name with completion status
-		status := "[ ]"
-		if task.Completed {
-			status = "[✓]"
-		}
+func printTask(task tasks.Task) {
+	// Task name with completion status
+	status := "[ ]"
+	if task.Completed {
+		status = "[✓]"
+	}
 
-		taskType := ""
-		if task.ResourceSubtype == "milestone" {
-			taskType = " 🏁"
-		} else if task.NumSubtasks > 0 {
-			taskType = fmt.Sprintf(" (%d subtasks)", task.NumSubtasks)
-		}
+	taskType := ""
+	if task.ResourceSubtype == "milestone" {
+		taskType = " 🏁"
+	} else if task.NumSubtasks > 0 {
+		taskType = fmt.Sprintf(" (%d subtasks)", task.NumSubtasks)
+	}
 
-		fmt.Printf("%s %s%s\n", status, task.Name, taskType)
-		fmt.Printf("    GID: %s\n", task.GID)
+	fmt.Printf("%s %s%s\n", status, task.Name, taskType)
+	fmt.Printf("    GID: %s\n", task.GID)
 
-		// Assignee
-		if task.Assignee != nil && task.Assignee.Name != "" {
-			fmt.Printf("    Assignee: %s\n", task.Assignee.Name)
-		}
+	// Assignee
+	if task.Assignee != nil && task.Assignee.Name != "" {
+		fmt.Printf("    Assignee: %s\n", task.Assignee.Name)
+	}
 
-		// Due date
-		if task.DueOn != "" {
-			fmt.Printf("    Due: %s\n", task.DueOn)
-		} else if task.DueAt != "" {
-			fmt.Printf("    Due: %s\n", task.DueAt)
-		}
-
-		// Tags
-		if len(task.Tags) > 0 {
-			var tagNames []string
-			for _, tag := range task.Tags {
-				if tag.Color != "" {
-					tagNames = append(tagNames, fmt.Sprintf("%s (%s)", tag.Name, tag.Color))
-				} else {
-					tagNames = append(tagNames, tag.Name)
-				}
+	// Due date
+	if task.DueOn != "" {
+		fmt.Printf("    Due: %s\n", task.DueOn)
+	} else if task.DueAt != "" {
+		fmt.Printf("    Due: %s\n", task.DueAt)
+	}
+
+	// Tags
+	if len(task.Tags) > 0 {
+		var tagNames []string
+		for _, tag := range task.Tags {
+			if tag.Color != "" {
+				tagNames = append(tagNames, fmt.Sprintf("%s (%s)", tag.Name, tag.Color))
+			} else {
+				tagNames = append(tagNames, tag.Name)
 			}
-			fmt.Printf("    Tags: %s\n", strings.Join(tagNames, ", "))
 		}
+		fmt.Printf("    Tags: %s\n", strings.Join(tagNames, ", "))
+	}
 
-		// Notes (truncated)
-		if task.Notes != "" {
-			notes := strings.ReplaceAll(task.Notes, "\n", " ")
-			if len(notes) > 80 {
-				notes = notes[:77] + "..."
-			}
-			fmt.Printf("    Notes: %s\n", notes)
+	// Custom fields that have a value
+	var customFields []string
+	for _, field := range task.CustomFields {
+		if field.DisplayValue != "" {
+			customFields = append(customFields, fmt.Sprintf("%s: %s", field.Name, field.DisplayValue))
 		}
+	}
+	if len(customFields) > 0 {
+		fmt.Printf("    Fields: %s\n", strings.Join(customFields, ", "))
+	}
 
-		// Completed info
-		if task.Completed && task.CompletedAt != "" {
-			completedBy := ""
-			if task.CompletedBy != nil && task.CompletedBy.Name != "" {
-				completedBy = " by " + task.CompletedBy.Name
-			}
-			fmt.Printf("    Completed: %s%s\n", task.CompletedAt[:10], completedBy)
+	// Notes (truncated)
+	if task.Notes != "" {
+		notes := strings.ReplaceAll(task.Notes, "\n", " ")
+		if len(notes) > 80 {
+			notes = notes[:77] + "..."
 		}
+		fmt.Printf("    Notes: %s\n", notes)
+	}
 
-		fmt.Println()
+	// Completed info
+	if task.Completed && task.CompletedAt != "" {
+		completedBy := ""
+		if task.CompletedBy != nil && task.CompletedBy.Name != "" {
+			completedBy = " by " + task.CompletedBy.Name
+		}
+		fmt.Printf("    Completed: %s%s\n", task.CompletedAt[:10], completedBy)
 	}
+
+	fmt.Println()
 }
 
 func printTaskDetails(task *tasks.Task) {
@@ -343,24 +376,33 @@ var taskGetCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Get task details",
 	Long:  `Retrieve detailed information about a specific task.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		gid, _ := cmd.Flags().GetString("gid")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		gid, err := resolveFlag(cmd, "gid", resolve.Task)
+		if err != nil {
+			return err
+		}
 		if gid == "" {
-			log.Fatal("Task GID is required")
+			return validationErrorf("task GID is required")
+		}
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
 		}
-		jsonOutput, _ := cmd.Flags().GetBool("json")
 
-		task, err := taskManager.Get(gid)
+		task, err := taskManager.GetContext(cmd.Context(), gid, fieldOptions(cmd))
+		if client.IsNotFound(err) {
+			return notFoundErrorf("task %s not found", gid)
+		}
 		if err != nil {
-			log.Fatalf("Failed to get task: %v", err)
+			return fmt.Errorf("failed to get task: %w", err)
 		}
 
-		if jsonOutput {
-			printJSON(task)
-			return
+		if !format.IsText() {
+			return printResource(format, output.TaskColumns, task)
 		}
 
 		printTaskDetails(task)
+		return nil
 	},
 }
 
@@ -368,10 +410,13 @@ var taskEditCmd = &cobra.Command{
 	Use:   "edit",
 	Short: "Edit a task",
 	Long:  `Update task properties like name, notes, assignee, due date, etc.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		gid, _ := cmd.Flags().GetString("gid")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		gid, err := resolveFlag(cmd, "gid", resolve.Task)
+		if err != nil {
+			return err
+		}
 		if gid == "" {
-			log.Fatal("Task GID is required")
+			return validationErrorf("task GID is required")
 		}
 
 		// Build update struct with only the fields that were provided
@@ -391,7 +436,10 @@ var taskEditCmd = &cobra.Command{
 		}
 
 		if cmd.Flags().Changed("assignee") {
-			assignee, _ := cmd.Flags().GetString("assignee")
+			assignee, err := assigneeFlag(cmd, relatedResource{resolve.Task, gid})
+			if err != nil {
+				return err
+			}
 			update.Assignee = &assignee
 			hasUpdate = true
 		}
@@ -421,12 +469,20 @@ var taskEditCmd = &cobra.Command{
 		}
 
 		if !hasUpdate {
-			log.Fatal("No updates specified. Use flags to specify what to update.")
+			return validationErrorf("no updates specified. Use flags to specify what to update.")
 		}
 
-		task, err := taskManager.Update(gid, update)
+		format, err := outputFormat(cmd)
 		if err != nil {
-			log.Fatalf("Failed to update task: %v", err)
+			return err
+		}
+		task, err := taskManager.UpdateContext(cmd.Context(), gid, update)
+		if err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		}
+
+		if !format.IsText() {
+			return printResource(format, output.TaskColumns, task)
 		}
 
 		fmt.Printf("✓ Task updated successfully\n")
@@ -440,6 +496,7 @@ var taskEditCmd = &cobra.Command{
 		if task.Completed {
 			fmt.Printf("  Status: Completed\n")
 		}
+		return nil
 	},
 }
 
@@ -447,18 +504,30 @@ var taskCompleteCmd = &cobra.Command{
 	Use:   "complete",
 	Short: "Mark a task as complete",
 	Long:  `Mark a task as complete.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		gid, _ := cmd.Flags().GetString("gid")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		gid, err := resolveFlag(cmd, "gid", resolve.Task)
+		if err != nil {
+			return err
+		}
 		if gid == "" {
-			log.Fatal("Task GID is required")
+			return validationErrorf("task GID is required")
 		}
 
-		task, err := taskManager.Complete(gid)
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		task, err := taskManager.CompleteContext(cmd.Context(), gid)
 		if err != nil {
-			log.Fatalf("Failed to complete task: %v", err)
+			return fmt.Errorf("failed to complete task: %w", err)
+		}
+
+		if !format.IsText() {
+			return printResource(format, output.TaskColumns, task)
 		}
 
 		fmt.Printf("✓ Task completed: %s\n", task.Name)
+		return nil
 	},
 }
 
@@ -466,55 +535,253 @@ var taskUncompleteCmd = &cobra.Command{
 	Use:   "uncomplete",
 	Short: "Mark a task as incomplete",
 	Long:  `Mark a completed task as incomplete.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		gid, _ := cmd.Flags().GetString("gid")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		gid, err := resolveFlag(cmd, "gid", resolve.Task)
+		if err != nil {
+			return err
+		}
 		if gid == "" {
-			log.Fatal("Task GID is required")
+			return validationErrorf("task GID is required")
 		}
 
-		task, err := taskManager.Uncomplete(gid)
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		task, err := taskManager.UncompleteContext(cmd.Context(), gid)
 		if err != nil {
-			log.Fatalf("Failed to uncomplete task: %v", err)
+			return fmt.Errorf("failed to uncomplete task: %w", err)
+		}
+
+		if !format.IsText() {
+			return printResource(format, output.TaskColumns, task)
 		}
 
 		fmt.Printf("✓ Task marked as incomplete: %s\n", task.Name)
+		return nil
 	},
 }
 
+var taskBulkCmd = &cobra.Command{
+	Use:   "bulk",
+	Short: "Update many tasks at once",
+	Long: `Apply the same update to many tasks using Asana's batch API.
+
+Task GIDs are taken from --gids and/or from stdin with --stdin (one per line,
+blank lines and lines starting with # are ignored). Up to ten tasks are updated
+per request. Failures are reported per task and do not stop the remaining updates.
+
+Examples:
+  utka task bulk --gids 111,222,333 --completed
+  utka task list --project <project_gid> -o jsonpath=.gid | utka task bulk --stdin --assignee <user_gid>
+
+With --output, each result has the task GID, whether the update succeeded,
+and either the updated task or the error.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		gids, _ := cmd.Flags().GetStringSlice("gids")
+		fromStdin, _ := cmd.Flags().GetBool("stdin")
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		if fromStdin {
+			stdinGIDs, err := readGIDs(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("failed to read task GIDs from stdin: %w", err)
+			}
+			gids = append(gids, stdinGIDs...)
+		}
+
+		if len(gids) == 0 {
+			return validationErrorf("no task GIDs given. Use --gids or --stdin.")
+		}
+		for i, ref := range gids {
+			if gids[i], err = resolveRef(cmd, resolve.Task, ref); err != nil {
+				return err
+			}
+		}
+
+		update := &tasks.TaskUpdate{}
+		hasUpdate := false
+
+		if cmd.Flags().Changed("completed") {
+			completed, _ := cmd.Flags().GetBool("completed")
+			update.Completed = &completed
+			hasUpdate = true
+		}
+
+		if cmd.Flags().Changed("assignee") {
+			assignee, err := assigneeFlag(cmd, relatedResource{resolve.Task, gids[0]})
+			if err != nil {
+				return err
+			}
+			update.Assignee = &assignee
+			hasUpdate = true
+		}
+
+		if cmd.Flags().Changed("due-date") {
+			dueDate, _ := cmd.Flags().GetString("due-date")
+			update.DueOn = &dueDate
+			hasUpdate = true
+		}
+
+		if cmd.Flags().Changed("start-date") {
+			startDate, _ := cmd.Flags().GetString("start-date")
+			update.StartOn = &startDate
+			hasUpdate = true
+		}
+
+		if !hasUpdate {
+			return validationErrorf("no updates specified. Use flags to specify what to update.")
+		}
+
+		results, err := taskManager.UpdateMany(cmd.Context(), gids, update)
+
+		var printer *output.Printer
+		if !format.IsText() {
+			printer = newPrinter(format, bulkResultColumns)
+		}
+
+		failed := 0
+		for _, result := range results {
+			if result.Err != nil {
+				failed++
+			}
+
+			if printer != nil {
+				line := bulkResult{GID: result.GID, OK: result.Err == nil, Task: result.Task}
+				if result.Err != nil {
+					line.Error = result.Err.Error()
+				}
+				if err := printItem(printer, line); err != nil {
+					return abortPrinter(printer, err)
+				}
+				continue
+			}
+
+			if result.Err != nil {
+				fmt.Printf("✗ %s: %v\n", result.GID, result.Err)
+			} else {
+				fmt.Printf("✓ %s: %s\n", result.GID, result.Task.Name)
+			}
+		}
+
+		if printer != nil {
+			if err := closePrinter(printer); err != nil {
+				return err
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("bulk update interrupted: %w", err)
+		}
+
+		if printer == nil {
+			fmt.Printf("\nUpdated %d of %d task(s)\n", len(results)-failed, len(results))
+		}
+
+		if failed > 0 {
+			return partialFailuref("%d of %d task update(s) failed", failed, len(results))
+		}
+		return nil
+	},
+}
+
+// bulkResult is the structured output for one task of a bulk update.
+type bulkResult struct {
+	GID   string      `json:"gid"`
+	OK    bool        `json:"ok"`
+	Error string      `json:"error,omitempty"`
+	Task  *tasks.Task `json:"task,omitempty"`
+}
+
+var bulkResultColumns = []output.Column{
+	{Header: "GID", Path: "gid"},
+	{Header: "OK", Path: "ok"},
+	{Header: "NAME", Path: "task.name"},
+	{Header: "ERROR", Path: "error"},
+}
+
+// assigneeFlag returns the user named by --assignee. "null" is passed
+// through to unassign. User names are looked up in the workspace of the
+// task being updated.
+func assigneeFlag(cmd *cobra.Command, task relatedResource) (string, error) {
+	assignee, _ := cmd.Flags().GetString("assignee")
+	if assignee == "null" {
+		return assignee, nil
+	}
+	return resolveRef(cmd, resolve.User, assignee, task)
+}
+
+// readGIDs reads whitespace-separated GIDs, skipping lines starting with #.
+func readGIDs(r io.Reader) ([]string, error) {
+	var gids []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		gids = append(gids, strings.Fields(line)...)
+	}
+	return gids, scanner.Err()
+}
+
 func init() {
-	taskListCmd.Flags().String("project", "", "Project GID")
-	taskListCmd.Flags().String("section", "", "Section GID")
-	taskListCmd.Flags().String("assignee", "", "Assignee user GID")
-	taskListCmd.Flags().String("workspace", "", "Workspace GID (required with --assignee)")
+	taskListCmd.Flags().String("project", "", "Project GID, name or URL")
+	taskListCmd.Flags().String("section", "", "Section GID, or name in --project")
+	taskListCmd.Flags().String("assignee", "", "Assignee GID, name, email or 'me'")
+	taskListCmd.Flags().String("workspace", "", "Workspace GID or name (required with --assignee, defaults to the profile workspace)")
 	taskListCmd.Flags().Int("completed", 0, "Include completed tasks from N days ago (0 for incomplete only)")
 	taskListCmd.Flags().Int("limit", 0, "Limit number of results (0 for all)")
-	taskListCmd.Flags().Bool("json", false, "Output as JSON")
-
-	taskGetCmd.Flags().String("gid", "", "Task GID")
-	taskGetCmd.Flags().Bool("json", false, "Output as JSON")
+	taskListCmd.RegisterFlagCompletionFunc("project", completeProjects)
+	taskListCmd.RegisterFlagCompletionFunc("section", completeSections)
+	taskListCmd.RegisterFlagCompletionFunc("assignee", completeUsers)
+	taskListCmd.RegisterFlagCompletionFunc("workspace", completeWorkspaces)
+	deprecatedJSONFlag(taskListCmd, output.NDJSON)
+
+	taskGetCmd.Flags().String("gid", "", "Task GID, URL or name")
+	deprecatedJSONFlag(taskGetCmd, output.JSON)
 	taskGetCmd.MarkFlagRequired("gid")
+	taskGetCmd.RegisterFlagCompletionFunc("gid", completeTasks)
 
-	taskEditCmd.Flags().String("gid", "", "Task GID")
+	taskEditCmd.Flags().String("gid", "", "Task GID, URL or name")
 	taskEditCmd.Flags().String("name", "", "Task name")
 	taskEditCmd.Flags().String("notes", "", "Task notes")
-	taskEditCmd.Flags().String("assignee", "", "Assignee GID (use 'null' to unassign)")
+	taskEditCmd.Flags().String("assignee", "", "Assignee GID, name, email or 'me' (use 'null' to unassign)")
 	taskEditCmd.Flags().String("due-date", "", "Due date (YYYY-MM-DD format, or 'null' to remove)")
 	taskEditCmd.Flags().String("start-date", "", "Start date (YYYY-MM-DD format)")
 	taskEditCmd.Flags().Bool("completed", false, "Mark as completed")
 	taskEditCmd.Flags().StringSlice("tags", nil, "Tag GIDs (comma-separated)")
 	taskEditCmd.MarkFlagRequired("gid")
+	taskEditCmd.RegisterFlagCompletionFunc("gid", completeTasks)
+	taskEditCmd.RegisterFlagCompletionFunc("assignee", completeUsers)
 
-	taskCompleteCmd.Flags().String("gid", "", "Task GID")
+	taskCompleteCmd.Flags().String("gid", "", "Task GID, URL or name")
 	taskCompleteCmd.MarkFlagRequired("gid")
+	taskCompleteCmd.RegisterFlagCompletionFunc("gid", completeTasks)
 
-	taskUncompleteCmd.Flags().String("gid", "", "Task GID")
+	taskUncompleteCmd.Flags().String("gid", "", "Task GID, URL or name")
 	taskUncompleteCmd.MarkFlagRequired("gid")
+	taskUncompleteCmd.RegisterFlagCompletionFunc("gid", completeTasks)
+
+	taskBulkCmd.Flags().StringSlice("gids", nil, "Task GIDs or URLs (comma-separated)")
+	taskBulkCmd.Flags().Bool("stdin", false, "Read task GIDs from stdin, one per line")
+	taskBulkCmd.Flags().Bool("completed", false, "Mark as completed (use --completed=false to mark incomplete)")
+	taskBulkCmd.Flags().String("assignee", "", "Assignee GID, name, email or 'me' (use 'null' to unassign)")
+	taskBulkCmd.Flags().String("due-date", "", "Due date (YYYY-MM-DD format, or 'null' to remove)")
+	taskBulkCmd.Flags().String("start-date", "", "Start date (YYYY-MM-DD format)")
+	deprecatedJSONFlag(taskBulkCmd, output.NDJSON)
+	taskBulkCmd.RegisterFlagCompletionFunc("gids", completeTaskList)
+	taskBulkCmd.RegisterFlagCompletionFunc("assignee", completeUsers)
 
 	taskCmd.AddCommand(taskListCmd)
 	taskCmd.AddCommand(taskGetCmd)
 	taskCmd.AddCommand(taskEditCmd)
 	taskCmd.AddCommand(taskCompleteCmd)
 	taskCmd.AddCommand(taskUncompleteCmd)
+	taskCmd.AddCommand(taskBulkCmd)
 
 	rootCmd.AddCommand(taskCmd)
 }
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/octoberswimmer/utka/asanatest"
+)
+
+// runCommand runs utka with args against a fresh config and returns what it
+// printed to stdout.
+func runCommand(t *testing.T, args ...string) string {
+	t.Helper()
+
+	t.Setenv("UTKA_CONFIG", filepath.Join(t.TempDir(), "config.yaml"))
+	t.Setenv("ASANA_PERSONAL_ACCESS_TOKEN", "")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	rootCmd.SetArgs(args)
+	err = rootCmd.Execute()
+	w.Close()
+	if err != nil {
+		t.Fatalf("utka %s: %v", strings.Join(args, " "), err)
+	}
+	return <-output
+}
+
+func TestTaskGetReplay(t *testing.T) {
+	out := runCommand(t, "task", "get", "--gid", "1200000000000001", "--replay", "testdata/cassettes/task_get.yaml")
+
+	for _, want := range []string{
+		"[ ] Write quarterly report",
+		"Assignee:    User 1 (GID: 1100000000000001)",
+		"Due Date:    2026-10-30",
+		"  - Finance / In progress",
+		"Questions to user1@example.com",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestTaskGetOutputFormats(t *testing.T) {
+	t.Cleanup(func() {
+		flag := rootCmd.PersistentFlags().Lookup("output")
+		flag.Value.Set("")
+		flag.Changed = false
+	})
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"jsonpath={.name}{\"\\t\"}{.assignee.name}", "Write quarterly report\tUser 1\n"},
+		{"csv", "GID,NAME,COMPLETED,ASSIGNEE,DUE,PROJECTS,SECTIONS\n1200000000000001,Write quarterly report,false,User 1,2026-10-30,Finance,In progress\n"},
+		{"template={{.workspace.name}}", "Acme\n"},
+	}
+	for _, test := range tests {
+		out := runCommand(t, "task", "get", "--gid", "1200000000000001", "--replay", "testdata/cassettes/task_get.yaml", "--output", test.format)
+		if out != test.want {
+			t.Errorf("--output %s:\n%s\nwant:\n%s", test.format, out, test.want)
+		}
+	}
+}
+
+func TestTaskListFailedPageKeepsJSONValid(t *testing.T) {
+	fake := asanatest.NewFake()
+	workspace := fake.AddWorkspace(asanatest.Workspace{Name: "Acme"})
+	project := fake.AddProject(asanatest.Project{Name: "Launch", Workspace: workspace.GID})
+	for i := range 101 {
+		fake.AddTask(asanatest.Task{Name: fmt.Sprintf("Task %d", i), Memberships: []asanatest.Membership{{Project: project.GID}}})
+	}
+	// The second page of tasks fails
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":[{"message":"offset: Invalid"}]}`))
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+	useFake(t, &asanatest.Server{Fake: fake, URL: proxy.URL}, "secret", workspace.GID)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- data
+	}()
+
+	// runCommand leaves flags such as --replay set
+	resetFlags(rootCmd)
+	rootCmd.SetArgs([]string{"task", "list", "--project", project.GID, "-o", "json"})
+	err = rootCmd.Execute()
+	resetFlags(rootCmd)
+	w.Close()
+	if err == nil {
+		t.Fatal("task list succeeded despite the failed page")
+	}
+
+	var tasks []map[string]interface{}
+	if data := <-output; json.Unmarshal(data, &tasks) != nil || len(tasks) != 100 {
+		t.Errorf("Output is not a JSON list of the first page's 100 tasks:\n%.200s", data)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/octoberswimmer/utka/output"
 	"github.com/octoberswimmer/utka/users"
 	"github.com/octoberswimmer/utka/workspaces"
 	"github.com/spf13/cobra"
@@ -16,30 +17,56 @@ var userCmd = &cobra.Command{
 	Use:   "user",
 	Short: "Manage Asana users",
 	Long:  `Commands for listing and retrieving information about Asana users.`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		rootCmd.PersistentPreRun(cmd, args)
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := rootCmd.PersistentPreRunE(cmd, args); err != nil {
+			return err
+		}
 		userManager = users.NewUserManager(asanaClient)
 		userWorkspaceManager = workspaces.NewWorkspaceManager(asanaClient)
+		return nil
 	},
 }
 
 var userListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List users in workspace(s)",
-	Long:  `List all users in the specified workspace, or in all workspaces if --workspace is not specified.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		workspaceGID, _ := cmd.Flags().GetString("workspace")
+	Long: `List all users in the specified workspace, or in all workspaces if --workspace is not specified.
+
+With --output, each user includes the workspace it was listed in.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workspaceGID, err := workspaceFlag(cmd)
+		if err != nil {
+			return err
+		}
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		var printer *output.Printer
+		if !format.IsText() {
+			printer = newPrinter(format, output.UserColumns)
+		}
 
 		if workspaceGID != "" {
 			// List users for specific workspace
-			users, err := userManager.ListInWorkspace(workspaceGID)
+			users, err := userManager.ListInWorkspaceContext(cmd.Context(), workspaceGID)
 			if err != nil {
-				log.Fatalf("Failed to list users: %v", err)
+				return abortPrinter(printer, fmt.Errorf("failed to list users: %w", err))
+			}
+
+			if printer != nil {
+				for _, user := range users {
+					if err := printItem(printer, workspaceUser{User: user, Workspace: workspaceRef{GID: workspaceGID}}); err != nil {
+						return abortPrinter(printer, err)
+					}
+				}
+				return closePrinter(printer)
 			}
 
 			if len(users) == 0 {
 				fmt.Println("No users found in workspace")
-				return
+				return nil
 			}
 
 			fmt.Println("Users in workspace:")
@@ -48,20 +75,34 @@ var userListCmd = &cobra.Command{
 			}
 		} else {
 			// List users for all workspaces
-			workspaces, err := userWorkspaceManager.List()
+			workspaces, err := userWorkspaceManager.ListContext(cmd.Context())
 			if err != nil {
-				log.Fatalf("Failed to list workspaces: %v", err)
+				return abortPrinter(printer, fmt.Errorf("failed to list workspaces: %w", err))
 			}
 
-			if len(workspaces) == 0 {
+			if len(workspaces) == 0 && printer == nil {
 				fmt.Println("No workspaces found")
-				return
+				return nil
 			}
 
 			for _, workspace := range workspaces {
+				users, err := userManager.ListInWorkspaceContext(cmd.Context(), workspace.GID)
+
+				if printer != nil {
+					if err != nil {
+						log.Printf("Warning: Failed to list users in workspace %s: %v", workspace.Name, err)
+						continue
+					}
+					for _, user := range users {
+						if err := printItem(printer, workspaceUser{User: user, Workspace: workspaceRef{GID: workspace.GID, Name: workspace.Name}}); err != nil {
+							return abortPrinter(printer, err)
+						}
+					}
+					continue
+				}
+
 				fmt.Printf("\n%s (%s):\n", workspace.Name, workspace.GID)
 
-				users, err := userManager.ListInWorkspace(workspace.GID)
 				if err != nil {
 					fmt.Printf("  Error listing users: %v\n", err)
 					continue
@@ -76,12 +117,30 @@ var userListCmd = &cobra.Command{
 					fmt.Printf("  • %s (%s) - GID: %s\n", user.Name, user.Email, user.GID)
 				}
 			}
+
+			if printer != nil {
+				return closePrinter(printer)
+			}
 		}
+		return nil
 	},
 }
 
+// workspaceUser is a user together with the workspace it was listed in, so
+// users listed from several workspaces can be told apart.
+type workspaceUser struct {
+	users.User
+	Workspace workspaceRef `json:"workspace"`
+}
+
+type workspaceRef struct {
+	GID  string `json:"gid"`
+	Name string `json:"name,omitempty"`
+}
+
 func init() {
-	userListCmd.Flags().String("workspace", "", "Workspace GID (optional - if not specified, lists users from all workspaces)")
+	userListCmd.Flags().String("workspace", "", "Workspace GID or name (optional - defaults to the profile workspace, otherwise lists users from all workspaces)")
+	userListCmd.RegisterFlagCompletionFunc("workspace", completeWorkspaces)
 
 	userCmd.AddCommand(userListCmd)
 
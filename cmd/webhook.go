@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/octoberswimmer/utka/client"
+	"github.com/octoberswimmer/utka/output"
+	"github.com/octoberswimmer/utka/resolve"
 	"github.com/octoberswimmer/utka/webhooks"
 	"github.com/octoberswimmer/utka/workspaces"
 	"github.com/spf13/cobra"
@@ -21,75 +24,125 @@ var webhookListCmd = &cobra.Command{
 	Short: "List webhooks",
 	Long: `List all webhooks, optionally filtered by workspace or resource.
 
-If no workspace is specified, webhooks from all accessible workspaces will be listed.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		workspace, _ := cmd.Flags().GetString("workspace")
-		resource, _ := cmd.Flags().GetString("resource")
+The workspace defaults to that of --resource, then to the profile's default
+workspace. If neither is known and the account has several workspaces, webhooks
+from all accessible workspaces are listed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resource, err := resolveFlag(cmd, "resource", resolve.Resource)
+		if err != nil {
+			return err
+		}
+		// Asana lists webhooks per workspace, so a resource's webhooks are
+		// looked up in its own workspace
+		workspace, err := workspaceFlag(cmd, relatedResource{resolve.Resource, resource})
+		if err != nil {
+			return err
+		}
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
 
-		// If no workspace specified, fetch all workspaces and list webhooks for each
+		var webhookList []webhooks.Webhook
 		if workspace == "" && resource == "" {
+			// No workspace specified: list the webhooks of every workspace
 			workspaceManager := workspaces.NewWorkspaceManager(asanaClient)
-			workspaceList, err := workspaceManager.List()
+			workspaceList, err := workspaceManager.ListContext(cmd.Context())
 			if err != nil {
-				log.Fatalf("Failed to list workspaces: %v", err)
-			}
-
-			if len(workspaceList) == 0 {
-				fmt.Println("No workspaces found")
-				return
+				return fmt.Errorf("failed to list workspaces: %w", err)
 			}
 
-			// Collect all webhooks from all workspaces
-			var allWebhooks []webhooks.Webhook
 			for _, ws := range workspaceList {
-				fmt.Printf("Fetching webhooks for workspace: %s (%s)...\n", ws.Name, ws.GID)
-				webhooksList, err := webhookManager.List(ws.GID, "")
+				list, err := webhookManager.ListContext(cmd.Context(), ws.GID, "")
+				if client.IsForbidden(err) {
+					log.Printf("Warning: Not allowed to list webhooks for workspace %s, skipping", ws.Name)
+					continue
+				}
 				if err != nil {
 					log.Printf("Warning: Failed to list webhooks for workspace %s: %v", ws.Name, err)
 					continue
 				}
-				allWebhooks = append(allWebhooks, webhooksList...)
-			}
-
-			// Print all webhooks
-			if len(allWebhooks) == 0 {
-				fmt.Println("\nNo webhooks found in any workspace")
-			} else {
-				fmt.Printf("\nTotal webhooks found: %d\n", len(allWebhooks))
-				printJSON(struct {
-					Data []webhooks.Webhook `json:"data"`
-				}{Data: allWebhooks})
+				webhookList = append(webhookList, list...)
 			}
 		} else {
-			// Original behavior when workspace or resource is specified
-			webhooksList, err := webhookManager.List(workspace, resource)
+			list, err := webhookManager.ListContext(cmd.Context(), workspace, resource)
 			if err != nil {
-				log.Fatalf("Failed to list webhooks: %v", err)
+				return fmt.Errorf("failed to list webhooks: %w", err)
+			}
+			webhookList = list
+		}
+
+		if !format.IsText() {
+			printer := newPrinter(format, output.WebhookColumns)
+			for _, webhook := range webhookList {
+				if err := printItem(printer, webhook); err != nil {
+					return abortPrinter(printer, err)
+				}
 			}
+			return closePrinter(printer)
+		}
+
+		if len(webhookList) == 0 {
+			fmt.Println("No webhooks found")
+			return nil
+		}
 
-			printJSON(struct {
-				Data []webhooks.Webhook `json:"data"`
-			}{Data: webhooksList})
+		for _, webhook := range webhookList {
+			printWebhook(webhook)
 		}
+		fmt.Printf("Found %d webhook(s)\n", len(webhookList))
+		return nil
 	},
 }
 
+func printWebhook(webhook webhooks.Webhook) {
+	status := ""
+	if !webhook.Active {
+		status = " [INACTIVE]"
+	}
+
+	fmt.Printf("• %s%s\n", webhook.Target, status)
+	fmt.Printf("  GID: %s\n", webhook.GID)
+
+	if webhook.Resource != nil {
+		if webhook.Resource.Name != "" {
+			fmt.Printf("  Resource: %s (%s %s)\n", webhook.Resource.Name, webhook.Resource.ResourceType, webhook.Resource.GID)
+		} else {
+			fmt.Printf("  Resource: %s %s\n", webhook.Resource.ResourceType, webhook.Resource.GID)
+		}
+	}
+
+	if webhook.LastSuccessAt != "" {
+		fmt.Printf("  Last Success: %s\n", webhook.LastSuccessAt)
+	}
+
+	if webhook.LastFailureAt != "" {
+		fmt.Printf("  Last Failure: %s\n", webhook.LastFailureAt)
+	}
+
+	fmt.Println()
+}
+
 var webhookGetCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Get a specific webhook",
 	Long:  `Retrieve details of a specific webhook by its GID.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		gid, _ := cmd.Flags().GetString("gid")
 		if gid == "" {
-			log.Fatal("Webhook GID is required")
+			return validationErrorf("webhook GID is required")
 		}
 
-		webhook, err := webhookManager.Get(gid)
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		webhook, err := webhookManager.GetContext(cmd.Context(), gid)
 		if err != nil {
-			log.Fatalf("Failed to get webhook: %v", err)
+			return fmt.Errorf("failed to get webhook: %w", err)
 		}
 
-		printJSON(webhook)
+		return printResource(format, output.WebhookColumns, webhook)
 	},
 }
 
@@ -97,22 +150,29 @@ var webhookCreateCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Create a new webhook",
 	Long:  `Create a new webhook for a specific resource with a target URL.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		resource, _ := cmd.Flags().GetString("resource")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resource, err := resolveFlag(cmd, "resource", resolve.Resource)
+		if err != nil {
+			return err
+		}
 		target, _ := cmd.Flags().GetString("target")
 
 		if resource == "" || target == "" {
-			log.Fatal("Both resource GID and target URL are required")
+			return validationErrorf("both resource GID and target URL are required")
 		}
 
 		filters := []webhooks.WebhookFilter{}
 
-		webhook, err := webhookManager.Create(resource, target, filters)
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		webhook, err := webhookManager.CreateContext(cmd.Context(), resource, target, filters)
 		if err != nil {
-			log.Fatalf("Failed to create webhook: %v", err)
+			return fmt.Errorf("failed to create webhook: %w", err)
 		}
 
-		printJSON(webhook)
+		return printResource(format, output.WebhookColumns, webhook)
 	},
 }
 
@@ -120,18 +180,27 @@ var webhookDeleteCmd = &cobra.Command{
 	Use:   "delete",
 	Short: "Delete a webhook",
 	Long:  `Delete a specific webhook by its GID.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		gid, _ := cmd.Flags().GetString("gid")
 		if gid == "" {
-			log.Fatal("Webhook GID is required")
+			return validationErrorf("webhook GID is required")
 		}
 
-		err := webhookManager.Delete(gid)
+		format, err := outputFormat(cmd)
 		if err != nil {
-			log.Fatalf("Failed to delete webhook: %v", err)
+			return err
+		}
+
+		if err := webhookManager.DeleteContext(cmd.Context(), gid); err != nil {
+			return fmt.Errorf("failed to delete webhook: %w", err)
+		}
+
+		if !format.IsText() {
+			return printResource(format, deletedColumns, deleted{GID: gid, Deleted: true})
 		}
 
 		fmt.Println("Webhook deleted successfully")
+		return nil
 	},
 }
 
@@ -139,22 +208,28 @@ var webhookEditCmd = &cobra.Command{
 	Use:   "edit",
 	Short: "Edit a webhook (placeholder for future enhancements)",
 	Long:  `Edit a webhook's properties by its GID. Currently preserves existing configuration. Use 'webhook filter edit' to modify filters.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		gid, _ := cmd.Flags().GetString("gid")
 		if gid == "" {
-			log.Fatal("Webhook GID is required")
+			return validationErrorf("webhook GID is required")
 		}
 
 		// Get current webhook
-		webhook, err := webhookManager.Get(gid)
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		webhook, err := webhookManager.GetContext(cmd.Context(), gid)
 		if err != nil {
-			log.Fatalf("Failed to get webhook: %v", err)
+			return fmt.Errorf("failed to get webhook: %w", err)
 		}
 
 		// For now, just return the current webhook
 		// Future enhancements can modify other properties here
-		fmt.Println("Current webhook configuration (use 'webhook filter edit' to modify filters):")
-		printJSON(webhook)
+		if format.IsText() {
+			fmt.Println("Current webhook configuration (use 'webhook filter edit' to modify filters):")
+		}
+		return printResource(format, output.WebhookColumns, webhook)
 	},
 }
 
@@ -168,10 +243,10 @@ var webhookFilterAddCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Add a filter to a webhook",
 	Long:  `Add a new filter to a specific webhook by its GID.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		gid, _ := cmd.Flags().GetString("gid")
 		if gid == "" {
-			log.Fatal("Webhook GID is required")
+			return validationErrorf("webhook GID is required")
 		}
 
 		action, _ := cmd.Flags().GetString("action")
@@ -184,9 +259,13 @@ var webhookFilterAddCmd = &cobra.Command{
 		}
 
 		// Get current webhook to see existing filters
-		webhook, err := webhookManager.Get(gid)
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		webhook, err := webhookManager.GetContext(cmd.Context(), gid)
 		if err != nil {
-			log.Fatalf("Failed to get webhook: %v", err)
+			return fmt.Errorf("failed to get webhook: %w", err)
 		}
 
 		// Add the new filter to existing filters
@@ -199,13 +278,15 @@ var webhookFilterAddCmd = &cobra.Command{
 		filters := append(webhook.Filters, newFilter)
 
 		// Update the webhook with the new filters list
-		webhook, err = webhookManager.UpdateFilters(gid, filters)
+		webhook, err = webhookManager.UpdateFiltersContext(cmd.Context(), gid, filters)
 		if err != nil {
-			log.Fatalf("Failed to add filter to webhook: %v", err)
+			return fmt.Errorf("failed to add filter to webhook: %w", err)
 		}
 
-		fmt.Println("Filter added successfully:")
-		printJSON(webhook)
+		if format.IsText() {
+			fmt.Println("Filter added successfully:")
+		}
+		return printResource(format, output.WebhookColumns, webhook)
 	},
 }
 
@@ -213,16 +294,20 @@ var webhookFilterEditCmd = &cobra.Command{
 	Use:   "edit",
 	Short: "Edit webhook filters",
 	Long:  `Edit filters for a specific webhook by its GID.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		gid, _ := cmd.Flags().GetString("gid")
 		if gid == "" {
-			log.Fatal("Webhook GID is required")
+			return validationErrorf("webhook GID is required")
 		}
 
 		// Get current webhook to see existing filters
-		webhook, err := webhookManager.Get(gid)
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		webhook, err := webhookManager.GetContext(cmd.Context(), gid)
 		if err != nil {
-			log.Fatalf("Failed to get webhook: %v", err)
+			return fmt.Errorf("failed to get webhook: %w", err)
 		}
 
 		filters := webhook.Filters
@@ -239,7 +324,7 @@ var webhookFilterEditCmd = &cobra.Command{
 			fmt.Print("Enter the number of the filter to edit (or 0 to add a new filter): ")
 			_, err := fmt.Scanf("%d", &choice)
 			if err != nil {
-				log.Fatalf("Failed to read input: %v", err)
+				return fmt.Errorf("failed to read input: %w", err)
 			}
 
 			if choice == 0 {
@@ -279,7 +364,7 @@ var webhookFilterEditCmd = &cobra.Command{
 					filters[choice-1].ResourceSubtype = resourceSubtype
 				}
 			} else {
-				log.Fatal("Invalid choice")
+				return validationErrorf("invalid choice")
 			}
 		} else if len(filters) == 1 {
 			// Single filter, edit it directly
@@ -307,7 +392,7 @@ var webhookFilterEditCmd = &cobra.Command{
 			resourceSubtype, _ := cmd.Flags().GetString("resource-subtype")
 
 			if action == "" && resourceType == "" && resourceSubtype == "" {
-				log.Fatal("No filters to edit and no new filter values provided")
+				return validationErrorf("no filters to edit and no new filter values provided")
 			}
 
 			// Handle "all" action which means no action filter
@@ -324,12 +409,12 @@ var webhookFilterEditCmd = &cobra.Command{
 		}
 
 		// Update the webhook with modified filters (only filters, not active status)
-		webhook, err = webhookManager.UpdateFilters(gid, filters)
+		webhook, err = webhookManager.UpdateFiltersContext(cmd.Context(), gid, filters)
 		if err != nil {
-			log.Fatalf("Failed to update webhook filters: %v", err)
+			return fmt.Errorf("failed to update webhook filters: %w", err)
 		}
 
-		printJSON(webhook)
+		return printResource(format, output.WebhookColumns, webhook)
 	},
 }
 
@@ -337,23 +422,27 @@ var webhookFilterDeleteCmd = &cobra.Command{
 	Use:   "delete",
 	Short: "Delete a filter from a webhook",
 	Long:  `Delete a specific filter from a webhook by its GID.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		gid, _ := cmd.Flags().GetString("gid")
 		if gid == "" {
-			log.Fatal("Webhook GID is required")
+			return validationErrorf("webhook GID is required")
 		}
 
 		// Get current webhook to see existing filters
-		webhook, err := webhookManager.Get(gid)
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		webhook, err := webhookManager.GetContext(cmd.Context(), gid)
 		if err != nil {
-			log.Fatalf("Failed to get webhook: %v", err)
+			return fmt.Errorf("failed to get webhook: %w", err)
 		}
 
 		filters := webhook.Filters
 
 		if len(filters) == 0 {
 			fmt.Println("No filters found on this webhook")
-			return
+			return nil
 		}
 
 		if len(filters) == 1 {
@@ -366,7 +455,7 @@ var webhookFilterDeleteCmd = &cobra.Command{
 			fmt.Scanln(&response)
 			if response != "y" && response != "Y" {
 				fmt.Println("Filter deletion cancelled")
-				return
+				return nil
 			}
 
 			filters = []webhooks.WebhookFilter{}
@@ -382,11 +471,11 @@ var webhookFilterDeleteCmd = &cobra.Command{
 			fmt.Print("Enter the number of the filter to delete: ")
 			_, err := fmt.Scanf("%d", &choice)
 			if err != nil {
-				log.Fatalf("Failed to read input: %v", err)
+				return fmt.Errorf("failed to read input: %w", err)
 			}
 
 			if choice < 1 || choice > len(filters) {
-				log.Fatal("Invalid choice")
+				return validationErrorf("invalid choice")
 			}
 
 			// Remove the selected filter
@@ -394,13 +483,15 @@ var webhookFilterDeleteCmd = &cobra.Command{
 		}
 
 		// Update the webhook with the modified filters list
-		webhook, err = webhookManager.UpdateFilters(gid, filters)
+		webhook, err = webhookManager.UpdateFiltersContext(cmd.Context(), gid, filters)
 		if err != nil {
-			log.Fatalf("Failed to delete filter from webhook: %v", err)
+			return fmt.Errorf("failed to delete filter from webhook: %w", err)
 		}
 
-		fmt.Println("Filter deleted successfully:")
-		printJSON(webhook)
+		if format.IsText() {
+			fmt.Println("Filter deleted successfully:")
+		}
+		return printResource(format, output.WebhookColumns, webhook)
 	},
 }
 
@@ -408,15 +499,23 @@ var webhookStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show webhook delivery status and health details",
 	Long:  `Display detailed information about webhook delivery success, failures, and retry status.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		gid, _ := cmd.Flags().GetString("gid")
 		if gid == "" {
-			log.Fatal("Webhook GID is required")
+			return validationErrorf("webhook GID is required")
 		}
 
-		webhook, err := webhookManager.Get(gid)
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		webhook, err := webhookManager.GetContext(cmd.Context(), gid)
 		if err != nil {
-			log.Fatalf("Failed to get webhook: %v", err)
+			return fmt.Errorf("failed to get webhook: %w", err)
+		}
+
+		if !format.IsText() {
+			return printResource(format, output.WebhookColumns, webhook)
 		}
 
 		// Display webhook status information
@@ -485,52 +584,56 @@ var webhookStatusCmd = &cobra.Command{
 				fmt.Println()
 			}
 		}
-
-		// Option to show full JSON
-		if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
-			fmt.Printf("\nFull JSON Response:\n")
-			fmt.Printf("------------------\n")
-			printJSON(webhook)
-		}
+		return nil
 	},
 }
 
 func init() {
-	webhookListCmd.Flags().String("workspace", "", "Workspace GID (optional, lists all workspaces if not provided)")
-	webhookListCmd.Flags().String("resource", "", "Resource GID")
+	webhookListCmd.Flags().String("workspace", "", "Workspace GID or name (optional, defaults to the profile workspace, otherwise lists all workspaces)")
+	webhookListCmd.Flags().String("resource", "", "Resource GID, Asana URL or project name")
+	webhookListCmd.RegisterFlagCompletionFunc("workspace", completeWorkspaces)
+	webhookListCmd.RegisterFlagCompletionFunc("resource", completeProjects)
 
 	webhookGetCmd.Flags().String("gid", "", "Webhook GID")
 	webhookGetCmd.MarkFlagRequired("gid")
+	webhookGetCmd.RegisterFlagCompletionFunc("gid", completeWebhooks)
 
-	webhookCreateCmd.Flags().String("resource", "", "Resource GID")
+	webhookCreateCmd.Flags().String("resource", "", "Resource GID, Asana URL or project name")
 	webhookCreateCmd.Flags().String("target", "", "Target URL")
 	webhookCreateCmd.MarkFlagRequired("resource")
 	webhookCreateCmd.MarkFlagRequired("target")
+	webhookCreateCmd.RegisterFlagCompletionFunc("resource", completeProjects)
 
 	webhookDeleteCmd.Flags().String("gid", "", "Webhook GID")
 	webhookDeleteCmd.MarkFlagRequired("gid")
+	webhookDeleteCmd.RegisterFlagCompletionFunc("gid", completeWebhooks)
 
 	webhookEditCmd.Flags().String("gid", "", "Webhook GID")
 	webhookEditCmd.MarkFlagRequired("gid")
+	webhookEditCmd.RegisterFlagCompletionFunc("gid", completeWebhooks)
 
 	webhookFilterAddCmd.Flags().String("gid", "", "Webhook GID")
 	webhookFilterAddCmd.Flags().String("action", "", "Filter by action (changed, added, removed, deleted, undeleted, all) - 'all' means no action filter")
 	webhookFilterAddCmd.Flags().String("resource-type", "", "Resource type for the filter")
 	webhookFilterAddCmd.Flags().String("resource-subtype", "", "Resource subtype for the filter")
 	webhookFilterAddCmd.MarkFlagRequired("gid")
+	webhookFilterAddCmd.RegisterFlagCompletionFunc("gid", completeWebhooks)
 
 	webhookFilterEditCmd.Flags().String("gid", "", "Webhook GID")
 	webhookFilterEditCmd.Flags().String("action", "", "Filter by action (changed, added, removed, deleted, undeleted, all) - 'all' removes the action filter")
 	webhookFilterEditCmd.Flags().String("resource-type", "", "Resource type for the filter")
 	webhookFilterEditCmd.Flags().String("resource-subtype", "", "Resource subtype for the filter")
 	webhookFilterEditCmd.MarkFlagRequired("gid")
+	webhookFilterEditCmd.RegisterFlagCompletionFunc("gid", completeWebhooks)
 
 	webhookFilterDeleteCmd.Flags().String("gid", "", "Webhook GID")
 	webhookFilterDeleteCmd.MarkFlagRequired("gid")
+	webhookFilterDeleteCmd.RegisterFlagCompletionFunc("gid", completeWebhooks)
 
 	webhookStatusCmd.Flags().String("gid", "", "Webhook GID")
-	webhookStatusCmd.Flags().Bool("json", false, "Show full JSON response")
+	deprecatedJSONFlag(webhookStatusCmd, output.JSON)
 	webhookStatusCmd.MarkFlagRequired("gid")
+	webhookStatusCmd.RegisterFlagCompletionFunc("gid", completeWebhooks)
 
 	webhookFilterCmd.AddCommand(webhookFilterAddCmd)
 	webhookFilterCmd.AddCommand(webhookFilterEditCmd)
@@ -547,10 +650,22 @@ func init() {
 	rootCmd.AddCommand(webhookCmd)
 }
 
-func printJSON(v interface{}) {
+// deleted is the structured output of delete commands.
+type deleted struct {
+	GID     string `json:"gid"`
+	Deleted bool   `json:"deleted"`
+}
+
+var deletedColumns = []output.Column{
+	{Header: "GID", Path: "gid"},
+	{Header: "DELETED", Path: "deleted"},
+}
+
+func printJSON(v interface{}) error {
 	output, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
-		log.Fatalf("Failed to marshal JSON: %v", err)
+		return fmt.Errorf("failed to marshal JSON: %w", err)
 	}
 	fmt.Println(string(output))
+	return nil
 }
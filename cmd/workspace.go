@@ -2,8 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 
+	"github.com/octoberswimmer/utka/output"
+	"github.com/octoberswimmer/utka/resolve"
 	"github.com/octoberswimmer/utka/workspaces"
 	"github.com/spf13/cobra"
 )
@@ -14,9 +15,12 @@ var workspaceCmd = &cobra.Command{
 	Use:   "workspace",
 	Short: "Manage Asana workspaces",
 	Long:  `Commands for listing and retrieving information about Asana workspaces.`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		rootCmd.PersistentPreRun(cmd, args)
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := rootCmd.PersistentPreRunE(cmd, args); err != nil {
+			return err
+		}
 		workspaceManager = workspaces.NewWorkspaceManager(asanaClient)
+		return nil
 	},
 }
 
@@ -24,15 +28,29 @@ var workspaceListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all workspaces",
 	Long:  `List all workspaces accessible with your personal access token.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		workspaces, err := workspaceManager.List()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		workspaces, err := workspaceManager.ListContext(cmd.Context())
 		if err != nil {
-			log.Fatalf("Failed to list workspaces: %v", err)
+			return fmt.Errorf("failed to list workspaces: %w", err)
+		}
+
+		if !format.IsText() {
+			printer := newPrinter(format, output.WorkspaceColumns)
+			for _, ws := range workspaces {
+				if err := printItem(printer, ws); err != nil {
+					return abortPrinter(printer, err)
+				}
+			}
+			return closePrinter(printer)
 		}
 
 		if len(workspaces) == 0 {
 			fmt.Println("No workspaces found")
-			return
+			return nil
 		}
 
 		fmt.Println("Available workspaces:")
@@ -43,6 +61,7 @@ var workspaceListCmd = &cobra.Command{
 			}
 			fmt.Printf("  • %s (%s) - GID: %s\n", ws.Name, orgType, ws.GID)
 		}
+		return nil
 	},
 }
 
@@ -50,27 +69,71 @@ var workspaceGetCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Get workspace details",
 	Long:  `Retrieve detailed information about a specific workspace.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		gid, _ := cmd.Flags().GetString("gid")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		gid, err := resolveFlag(cmd, "gid", resolve.Workspace)
+		if err != nil {
+			return err
+		}
 		if gid == "" {
-			log.Fatal("Workspace GID is required")
+			return validationErrorf("workspace GID is required")
 		}
 
-		workspace, err := workspaceManager.Get(gid)
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		workspace, err := workspaceManager.GetContext(cmd.Context(), gid)
 		if err != nil {
-			log.Fatalf("Failed to get workspace: %v", err)
+			return fmt.Errorf("failed to get workspace: %w", err)
+		}
+
+		return printResource(format, output.WorkspaceColumns, workspace)
+	},
+}
+
+var workspaceUseCmd = &cobra.Command{
+	Use:   "use <workspace>",
+	Short: "Set the profile's default workspace",
+	Long: `Set the default workspace of the current profile, by GID or name. Commands
+use it whenever --workspace is omitted and the workspace cannot be derived
+from a task or project given to the command.
+
+Profiles whose token has a single workspace get it as their default
+automatically the first time a command needs one.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: firstArg(completeWorkspaces),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if activeProfile == nil {
+			return validationErrorf("no profile to set the workspace of. Add one with 'utka config add <name> --token <token>'")
+		}
+
+		gid, err := resolveRef(cmd, resolve.Workspace, args[0])
+		if err != nil {
+			return err
+		}
+		workspace, err := workspaceManager.GetContext(cmd.Context(), gid)
+		if err != nil {
+			return fmt.Errorf("failed to get workspace: %w", err)
+		}
+
+		activeProfile.Workspace = workspace.GID
+		if err := saveConfig(activeProfileName, activeProfile); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
 		}
 
-		printJSON(workspace)
+		fmt.Printf("✓ Default workspace of profile %s: %s (%s)\n", activeProfileName, workspace.Name, workspace.GID)
+		return nil
 	},
 }
 
 func init() {
-	workspaceGetCmd.Flags().String("gid", "", "Workspace GID")
+	workspaceGetCmd.Flags().String("gid", "", "Workspace GID or name")
 	workspaceGetCmd.MarkFlagRequired("gid")
+	workspaceGetCmd.RegisterFlagCompletionFunc("gid", completeWorkspaces)
 
 	workspaceCmd.AddCommand(workspaceListCmd)
 	workspaceCmd.AddCommand(workspaceGetCmd)
+	workspaceCmd.AddCommand(workspaceUseCmd)
 
 	rootCmd.AddCommand(workspaceCmd)
 }
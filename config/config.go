@@ -0,0 +1,238 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profile holds the settings for one Asana account.
+type Profile struct {
+	Token     string `yaml:"token,omitempty"`
+	Workspace string `yaml:"workspace,omitempty"`
+	BaseURL   string `yaml:"base_url,omitempty"`
+	Output    string `yaml:"output,omitempty"`
+	OAuth     *OAuth `yaml:"oauth,omitempty"`
+	// Credentials selects where the profile's tokens are kept instead of the
+	// Token and OAuth token fields of this file.
+	Credentials *Credentials `yaml:"credentials,omitempty"`
+	// RateLimit overrides the client-side rate limits for the profile's
+	// token.
+	RateLimit *RateLimit `yaml:"rate_limit,omitempty"`
+}
+
+// RateLimit configures client-side throttling. Zero fields keep the default
+// and negative fields disable that limit.
+type RateLimit struct {
+	RequestsPerMinute int `yaml:"requests_per_minute,omitempty"`
+	Burst             int `yaml:"burst,omitempty"`
+	MaxInFlight       int `yaml:"max_in_flight,omitempty"`
+	MaxInFlightWrites int `yaml:"max_in_flight_writes,omitempty"`
+}
+
+// Credential store backends.
+const (
+	BackendFile    = "file"
+	BackendCommand = "command"
+)
+
+// Credentials configures a profile's credential store. The file backend keeps
+// the personal access token and OAuth tokens in a passphrase-encrypted file;
+// the command backend runs Command to print the personal access token.
+type Credentials struct {
+	Backend string `yaml:"backend"`
+	Path    string `yaml:"path,omitempty"`
+	Command string `yaml:"command,omitempty"`
+}
+
+// OAuth holds the OAuth application settings and the tokens obtained with
+// 'utka auth login'.
+type OAuth struct {
+	ClientID     string    `yaml:"client_id"`
+	ClientSecret string    `yaml:"client_secret,omitempty"`
+	AuthURL      string    `yaml:"auth_url,omitempty"`
+	TokenURL     string    `yaml:"token_url,omitempty"`
+	RedirectPort int       `yaml:"redirect_port,omitempty"`
+	Scopes       []string  `yaml:"scopes,omitempty"`
+	AccessToken  string    `yaml:"access_token,omitempty"`
+	RefreshToken string    `yaml:"refresh_token,omitempty"`
+	Expiry       time.Time `yaml:"expiry,omitempty"`
+}
+
+type Config struct {
+	CurrentProfile string              `yaml:"current_profile,omitempty"`
+	Profiles       map[string]*Profile `yaml:"profiles,omitempty"`
+	// Filters are named event filter expressions, shared by all profiles.
+	Filters map[string]string `yaml:"filters,omitempty"`
+
+	path string
+}
+
+// DefaultPath returns the config file location: $UTKA_CONFIG if set, otherwise
+// utka/config.yaml under $XDG_CONFIG_HOME or the platform's user config directory.
+func DefaultPath() (string, error) {
+	if path := os.Getenv("UTKA_CONFIG"); path != "" {
+		return path, nil
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		var err error
+		dir, err = os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to determine config directory: %w", err)
+		}
+	}
+
+	return filepath.Join(dir, "utka", "config.yaml"), nil
+}
+
+// Load reads the config file at path. A missing file yields an empty config
+// that will be created on Save.
+func Load(path string) (*Config, error) {
+	cfg := &Config{path: path}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Save writes the config back to the file it was loaded from. The file holds
+// tokens, so it is only readable by the current user.
+func (c *Config) Save() error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), ".config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Profile returns the named profile.
+func (c *Config) Profile(name string) (*Profile, error) {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found", name)
+	}
+	return profile, nil
+}
+
+// ProfileNames returns the names of all profiles in sorted order.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetProfile adds or replaces a profile. The first profile added becomes the
+// current one.
+func (c *Config) SetProfile(name string, profile *Profile) {
+	if c.Profiles == nil {
+		c.Profiles = map[string]*Profile{}
+	}
+	c.Profiles[name] = profile
+	if c.CurrentProfile == "" {
+		c.CurrentProfile = name
+	}
+}
+
+func (c *Config) RemoveProfile(name string) error {
+	if _, ok := c.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+	delete(c.Profiles, name)
+	if c.CurrentProfile == name {
+		c.CurrentProfile = ""
+	}
+	return nil
+}
+
+// Use makes the named profile the current one.
+func (c *Config) Use(name string) error {
+	if _, ok := c.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+	c.CurrentProfile = name
+	return nil
+}
+
+// Filter returns the expression of the named event filter.
+func (c *Config) Filter(name string) (string, error) {
+	expression, ok := c.Filters[name]
+	if !ok {
+		return "", fmt.Errorf("filter %q not found", name)
+	}
+	return expression, nil
+}
+
+// FilterNames returns the names of all event filters in sorted order.
+func (c *Config) FilterNames() []string {
+	names := make([]string, 0, len(c.Filters))
+	for name := range c.Filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetFilter adds or replaces a named event filter.
+func (c *Config) SetFilter(name, expression string) {
+	if c.Filters == nil {
+		c.Filters = map[string]string{}
+	}
+	c.Filters[name] = expression
+}
+
+func (c *Config) RemoveFilter(name string) error {
+	if _, ok := c.Filters[name]; !ok {
+		return fmt.Errorf("filter %q not found", name)
+	}
+	delete(c.Filters, name)
+	return nil
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMissingFile(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing", "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Profiles) != 0 || cfg.CurrentProfile != "" {
+		t.Errorf("Expected empty config, got %+v", cfg)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "utka", "config.yaml")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	cfg.SetProfile("prod", &Profile{Token: "prod_token", Workspace: "111"})
+	cfg.SetProfile("sandbox", &Profile{Token: "sandbox_token", BaseURL: "http://localhost:8080", Output: "json"})
+	cfg.SetFilter("mine", `by("me")`)
+
+	if cfg.CurrentProfile != "prod" {
+		t.Errorf("CurrentProfile = %q, want first profile added", cfg.CurrentProfile)
+	}
+
+	if err := cfg.Use("sandbox"); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+	if err := cfg.Use("missing"); err == nil {
+		t.Error("Use() of an unknown profile should fail")
+	}
+
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Config file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if loaded.CurrentProfile != "sandbox" {
+		t.Errorf("CurrentProfile = %q, want sandbox", loaded.CurrentProfile)
+	}
+	if names := loaded.ProfileNames(); len(names) != 2 || names[0] != "prod" || names[1] != "sandbox" {
+		t.Errorf("ProfileNames() = %v", names)
+	}
+
+	sandbox, err := loaded.Profile("sandbox")
+	if err != nil {
+		t.Fatalf("Profile() error = %v", err)
+	}
+	if *sandbox != (Profile{Token: "sandbox_token", BaseURL: "http://localhost:8080", Output: "json"}) {
+		t.Errorf("Profile round trip mismatch: %+v", sandbox)
+	}
+
+	if expression, err := loaded.Filter("mine"); err != nil || expression != `by("me")` {
+		t.Errorf("Filter() = %q, %v after round trip", expression, err)
+	}
+	if err := loaded.RemoveFilter("mine"); err != nil || len(loaded.FilterNames()) != 0 {
+		t.Errorf("RemoveFilter() error = %v, filters left %v", err, loaded.FilterNames())
+	}
+	if _, err := loaded.Filter("mine"); err == nil {
+		t.Error("Filter() of a removed filter should fail")
+	}
+
+	if err := loaded.RemoveProfile("sandbox"); err != nil {
+		t.Fatalf("RemoveProfile() error = %v", err)
+	}
+	if loaded.CurrentProfile != "" {
+		t.Errorf("Removing the current profile should clear it, got %q", loaded.CurrentProfile)
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("UTKA_CONFIG", "")
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+
+	path, err := DefaultPath()
+	if err != nil {
+		t.Fatalf("DefaultPath() error = %v", err)
+	}
+	if path != filepath.Join("/tmp/xdg", "utka", "config.yaml") {
+		t.Errorf("DefaultPath() = %q", path)
+	}
+
+	t.Setenv("UTKA_CONFIG", "/etc/utka.yaml")
+	if path, _ := DefaultPath(); path != "/etc/utka.yaml" {
+		t.Errorf("DefaultPath() = %q, want $UTKA_CONFIG", path)
+	}
+}
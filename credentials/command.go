@@ -0,0 +1,69 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// commandTimeout bounds how long a helper program may take, e.g. while it
+// prompts for a GPG passphrase.
+const commandTimeout = 2 * time.Minute
+
+// CommandStore obtains secrets by running an external program, such as
+// "pass show asana/work", and reading the secret from its standard output. The
+// requested key is passed to the program in $UTKA_CREDENTIAL_KEY. Secrets are
+// managed with the program's own tooling, so Set and Delete return ErrReadOnly.
+type CommandStore struct {
+	Command string
+}
+
+func NewCommandStore(command string) *CommandStore {
+	return &CommandStore{Command: command}
+}
+
+func (s *CommandStore) Get(key string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", s.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", s.Command)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), "UTKA_CREDENTIAL_KEY="+key)
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("credential command %q failed: %w: %s", s.Command, err, msg)
+		}
+		return "", fmt.Errorf("credential command %q failed: %w", s.Command, err)
+	}
+
+	// Like git credential helpers and pass, only the first line is the secret
+	secret, _, _ := strings.Cut(stdout.String(), "\n")
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+func (s *CommandStore) Set(key, secret string) error {
+	return ErrReadOnly
+}
+
+func (s *CommandStore) Delete(key string) error {
+	return ErrReadOnly
+}
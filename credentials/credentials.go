@@ -0,0 +1,21 @@
+// Package credentials stores Asana tokens outside the plaintext config file.
+package credentials
+
+import (
+	"errors"
+)
+
+var (
+	// ErrNotFound is returned by Get when the store holds no secret for a key.
+	ErrNotFound = errors.New("credential not found")
+
+	// ErrReadOnly is returned by stores that can only supply secrets.
+	ErrReadOnly = errors.New("credential store is read-only")
+)
+
+// Store holds secrets by key.
+type Store interface {
+	Get(key string) (string, error)
+	Set(key, secret string) error
+	Delete(key string) error
+}
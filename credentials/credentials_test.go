@@ -0,0 +1,103 @@
+package credentials
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func passphrase(p string) func(bool) (string, error) {
+	return func(bool) (string, error) { return p, nil }
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "utka", "credentials.enc")
+
+	var created []bool
+	store := NewFileStore(path, func(create bool) (string, error) {
+		created = append(created, create)
+		return "correct horse", nil
+	})
+
+	if _, err := store.Get("work/token"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() on a new store error = %v, want ErrNotFound", err)
+	}
+	if err := store.Set("work/token", "secret_token"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := store.Set("work/oauth/refresh_token", "secret_refresh"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if len(created) != 1 || !created[0] {
+		t.Errorf("Passphrase requests = %v, want a single request for a new file", created)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Credentials file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	data, _ := os.ReadFile(path)
+	if bytes.Contains(data, []byte("secret_token")) {
+		t.Error("Credentials file contains the token in plaintext")
+	}
+
+	reopened := NewFileStore(path, passphrase("correct horse"))
+	if got, err := reopened.Get("work/token"); err != nil || got != "secret_token" {
+		t.Errorf("Get() = %q, %v, want secret_token", got, err)
+	}
+	if err := reopened.Delete("work/token"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	reopened = NewFileStore(path, passphrase("correct horse"))
+	if _, err := reopened.Get("work/token"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want ErrNotFound", err)
+	}
+	if got, _ := reopened.Get("work/oauth/refresh_token"); got != "secret_refresh" {
+		t.Errorf("Get() = %q, want secret_refresh", got)
+	}
+
+	wrong := NewFileStore(path, passphrase("battery staple"))
+	if _, err := wrong.Get("work/oauth/refresh_token"); err == nil {
+		t.Error("Expected an error for a wrong passphrase")
+	}
+
+	empty := NewFileStore(path, passphrase(""))
+	if _, err := empty.Get("work/oauth/refresh_token"); err == nil {
+		t.Error("Expected an error for an empty passphrase")
+	}
+}
+
+func TestCommandStore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	store := NewCommandStore(`printf 'token-for-%s\nmetadata: ignored\n' "$UTKA_CREDENTIAL_KEY"`)
+	got, err := store.Get("work/token")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got != "token-for-work/token" {
+		t.Errorf("Get() = %q, want token-for-work/token", got)
+	}
+
+	if err := store.Set("work/token", "x"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Set() error = %v, want ErrReadOnly", err)
+	}
+
+	if _, err := NewCommandStore("true").Get("work/token"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() with no output error = %v, want ErrNotFound", err)
+	}
+
+	if _, err := NewCommandStore("echo locked >&2; exit 1").Get("work/token"); err == nil {
+		t.Error("Expected an error from a failing command")
+	}
+}
@@ -0,0 +1,229 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	fileVersion = 1
+
+	// keyIterations is the PBKDF2-SHA256 work factor used to derive the
+	// encryption key from the passphrase.
+	keyIterations = 600000
+	saltSize      = 16
+	keySize       = 32
+)
+
+// encryptedFile is the on-disk format of a FileStore.
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// FileStore keeps secrets in a file encrypted with AES-256-GCM under a key
+// derived from a passphrase. The passphrase is requested the first time the
+// file is read or written; create tells the callback that the file does not
+// exist yet, so a new passphrase is being chosen.
+type FileStore struct {
+	path       string
+	passphrase func(create bool) (string, error)
+
+	key     []byte
+	salt    []byte
+	secrets map[string]string
+}
+
+func NewFileStore(path string, passphrase func(create bool) (string, error)) *FileStore {
+	return &FileStore{path: path, passphrase: passphrase}
+}
+
+func (s *FileStore) Path() string {
+	return s.path
+}
+
+func (s *FileStore) Get(key string) (string, error) {
+	if err := s.load(); err != nil {
+		return "", err
+	}
+
+	secret, ok := s.secrets[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+func (s *FileStore) Set(key, secret string) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	s.secrets[key] = secret
+	return s.save()
+}
+
+func (s *FileStore) Delete(key string) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	if _, ok := s.secrets[key]; !ok {
+		return nil
+	}
+	delete(s.secrets, key)
+	return s.save()
+}
+
+// load decrypts the file on first use. A missing file is treated as an empty
+// store with a fresh salt.
+func (s *FileStore) load() error {
+	if s.secrets != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return fmt.Errorf("failed to generate salt: %w", err)
+		}
+		key, err := s.deriveKey(salt, keyIterations, true)
+		if err != nil {
+			return err
+		}
+		s.key, s.salt, s.secrets = key, salt, map[string]string{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read credentials: %w", err)
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse credentials file %s: %w", s.path, err)
+	}
+	if file.Version != fileVersion {
+		return fmt.Errorf("unsupported credentials file version %d", file.Version)
+	}
+
+	key, err := s.deriveKey(file.Salt, file.Iterations, false)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return errors.New("failed to decrypt credentials: wrong passphrase or corrupted file")
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return fmt.Errorf("failed to parse credentials: %w", err)
+	}
+
+	s.key, s.salt, s.secrets = key, file.Salt, secrets
+	return nil
+}
+
+func (s *FileStore) save() error {
+	plaintext, err := json.Marshal(s.secrets)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+
+	gcm, err := newGCM(s.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data, err := json.Marshal(encryptedFile{
+		Version:    fileVersion,
+		Iterations: keyIterations,
+		Salt:       s.salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+
+	return writeFile(s.path, data)
+}
+
+func (s *FileStore) deriveKey(salt []byte, iterations int, create bool) ([]byte, error) {
+	passphrase, err := s.passphrase(create)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if passphrase == "" {
+		return nil, errors.New("a passphrase is required to unlock the credentials file")
+	}
+
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return gcm, nil
+}
+
+// writeFile replaces path atomically with a file only the current user can read.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".credentials-*")
+	if err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package events
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// ErrNoCursor is returned by CursorStore.Get when no cursor is stored for a
+// profile and resource.
+var ErrNoCursor = errors.New("no stored cursor")
+
+// Cursor is where a consumer of a resource's events left off: the sync token
+// to continue from, for the profile that read the events. Profile is "" for
+// tokens that did not come from a profile.
+type Cursor struct {
+	Profile  string    `json:"profile"`
+	Resource string    `json:"resource"`
+	Sync     string    `json:"sync"`
+	Updated  time.Time `json:"updated"`
+}
+
+// CursorStore keeps one cursor per profile and resource, so reading events
+// can resume where the last process stopped.
+type CursorStore interface {
+	Get(profile, resource string) (Cursor, error)
+	Put(cursor Cursor) error
+	Delete(profile, resource string) error
+	List() ([]Cursor, error)
+}
+
+const (
+	// lockTimeout bounds how long Put and Delete wait for another process
+	// to release the lock.
+	lockTimeout = 10 * time.Second
+
+	// staleLock is the age after which a lock file is assumed to be left
+	// behind by a process that crashed; holders only keep it for a write.
+	staleLock = 30 * time.Second
+
+	lockRetry = 20 * time.Millisecond
+)
+
+// FileCursorStore keeps cursors in a JSON file. Writes take a lock file next
+// to it, so concurrent pollers of different resources do not lose each
+// other's updates, and replace the file atomically, so readers never see a
+// partial file.
+type FileCursorStore struct {
+	path string
+	now  func() time.Time
+}
+
+func NewFileCursorStore(path string) *FileCursorStore {
+	return &FileCursorStore{path: path, now: time.Now}
+}
+
+func (s *FileCursorStore) Path() string {
+	return s.path
+}
+
+func (s *FileCursorStore) Get(profile, resource string) (Cursor, error) {
+	cursors, err := s.read()
+	if err != nil {
+		return Cursor{}, err
+	}
+
+	cursor, ok := cursors[cursorKey(profile, resource)]
+	if !ok {
+		return Cursor{}, ErrNoCursor
+	}
+	return cursor, nil
+}
+
+// Put stores cursor, replacing the previous cursor of its profile and
+// resource. Updated is set to the current time.
+func (s *FileCursorStore) Put(cursor Cursor) error {
+	cursor.Updated = s.now().UTC()
+	return s.update(func(cursors map[string]Cursor) {
+		cursors[cursorKey(cursor.Profile, cursor.Resource)] = cursor
+	})
+}
+
+// Delete removes the cursor of a profile and resource. Deleting a cursor
+// that is not stored is not an error.
+func (s *FileCursorStore) Delete(profile, resource string) error {
+	return s.update(func(cursors map[string]Cursor) {
+		delete(cursors, cursorKey(profile, resource))
+	})
+}
+
+// List returns all stored cursors, ordered by profile and resource.
+func (s *FileCursorStore) List() ([]Cursor, error) {
+	cursors, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Cursor, 0, len(cursors))
+	for _, cursor := range cursors {
+		list = append(list, cursor)
+	}
+	slices.SortFunc(list, func(a, b Cursor) int {
+		return cmp.Or(cmp.Compare(a.Profile, b.Profile), cmp.Compare(a.Resource, b.Resource))
+	})
+	return list, nil
+}
+
+func cursorKey(profile, resource string) string {
+	return profile + "/" + resource
+}
+
+// read loads the cursors file. A missing file holds no cursors.
+func (s *FileCursorStore) read() (map[string]Cursor, error) {
+	cursors := map[string]Cursor{}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cursors, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cursors: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cursors); err != nil {
+		return nil, fmt.Errorf("failed to parse cursors file %s: %w", s.path, err)
+	}
+	return cursors, nil
+}
+
+// update applies change to the stored cursors while holding the lock.
+func (s *FileCursorStore) update(change func(map[string]Cursor)) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cursors, err := s.read()
+	if err != nil {
+		return err
+	}
+	change(cursors)
+	return s.write(cursors)
+}
+
+func (s *FileCursorStore) write(cursors map[string]Cursor) error {
+	data, err := json.MarshalIndent(cursors, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cursors: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".cursors-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write cursors: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cursors: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cursors: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write cursors: %w", err)
+	}
+	return nil
+}
+
+// lock creates the lock file, waiting while another process holds it. The
+// returned function releases the lock.
+func (s *FileCursorStore) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create cursors directory: %w", err)
+	}
+
+	path := s.path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to lock cursors: %w", err)
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLock {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to lock cursors: %s is held by another process", path)
+		}
+		time.Sleep(lockRetry)
+	}
+}
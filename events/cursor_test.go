@@ -0,0 +1,94 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileCursorStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "utka", "cursors.json")
+	store := NewFileCursorStore(path)
+
+	if _, err := store.Get("work", "100"); !errors.Is(err, ErrNoCursor) {
+		t.Fatalf("Get() on a new store error = %v, want ErrNoCursor", err)
+	}
+	if err := store.Put(Cursor{Profile: "work", Resource: "100", Sync: "a"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := store.Put(Cursor{Profile: "work", Resource: "100", Sync: "b"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := store.Put(Cursor{Resource: "100", Sync: "c"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	reopened := NewFileCursorStore(path)
+	cursor, err := reopened.Get("work", "100")
+	if err != nil || cursor.Sync != "b" || cursor.Updated.IsZero() {
+		t.Errorf("Get() = %+v, %v, want sync token b with an update time", cursor, err)
+	}
+	if cursor, _ := reopened.Get("", "100"); cursor.Sync != "c" {
+		t.Errorf("Get() for another profile = %+v, want sync token c", cursor)
+	}
+
+	list, err := reopened.List()
+	if err != nil || len(list) != 2 || list[0].Profile != "" || list[1].Profile != "work" {
+		t.Errorf("List() = %+v, %v, want the cursors of both profiles in order", list, err)
+	}
+
+	if err := reopened.Delete("work", "100"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := reopened.Delete("work", "100"); err != nil {
+		t.Errorf("Delete() of a missing cursor error = %v", err)
+	}
+	if _, err := store.Get("work", "100"); !errors.Is(err, ErrNoCursor) {
+		t.Errorf("Get() after Delete() error = %v, want ErrNoCursor", err)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("Lock file was left behind: %v", err)
+	}
+}
+
+func TestFileCursorStoreConcurrentWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cursors.json")
+
+	// Stores opened by separate pollers must not lose each other's updates
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store := NewFileCursorStore(path)
+			if err := store.Put(Cursor{Profile: "work", Resource: fmt.Sprint(i), Sync: "token"}); err != nil {
+				t.Errorf("Put() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	list, err := NewFileCursorStore(path).List()
+	if err != nil || len(list) != 20 {
+		t.Errorf("List() returned %d cursors, %v, want 20", len(list), err)
+	}
+}
+
+func TestFileCursorStoreStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cursors.json")
+	if err := os.WriteFile(path+".lock", nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewFileCursorStore(path).Put(Cursor{Resource: "100", Sync: "a"}); err != nil {
+		t.Errorf("Put() with a stale lock error = %v", err)
+	}
+}
@@ -1,10 +1,10 @@
 package events
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
 	"time"
 
@@ -13,12 +13,22 @@ import (
 
 type EventManager struct {
 	client *client.Client
+
+	cursors CursorStore
+	profile string
 }
 
 func NewEventManager(c *client.Client) *EventManager {
 	return &EventManager{client: c}
 }
 
+// SetCursorStore makes Poll save its position in store as it goes, under
+// the given profile, so a later poll can resume from it.
+func (em *EventManager) SetCursorStore(store CursorStore, profile string) {
+	em.cursors = store
+	em.profile = profile
+}
+
 type Event struct {
 	User      *EventUser     `json:"user,omitempty"`
 	CreatedAt string         `json:"created_at,omitempty"`
@@ -30,31 +40,31 @@ type Event struct {
 }
 
 type EventUser struct {
-	GID          string `json:"gid"`
-	ResourceType string `json:"resource_type"`
-	Name         string `json:"name,omitempty"`
+	GID          string `json:"gid" expr:"gid"`
+	ResourceType string `json:"resource_type" expr:"resource_type"`
+	Name         string `json:"name,omitempty" expr:"name"`
 }
 
 type EventResource struct {
-	GID             string `json:"gid"`
-	ResourceType    string `json:"resource_type"`
-	Name            string `json:"name,omitempty"`
-	ResourceSubtype string `json:"resource_subtype,omitempty"`
+	GID             string `json:"gid" expr:"gid"`
+	ResourceType    string `json:"resource_type" expr:"resource_type"`
+	Name            string `json:"name,omitempty" expr:"name"`
+	ResourceSubtype string `json:"resource_subtype,omitempty" expr:"resource_subtype"`
 }
 
 type EventParent struct {
-	GID          string `json:"gid"`
-	ResourceType string `json:"resource_type"`
-	Name         string `json:"name,omitempty"`
+	GID          string `json:"gid" expr:"gid"`
+	ResourceType string `json:"resource_type" expr:"resource_type"`
+	Name         string `json:"name,omitempty" expr:"name"`
 }
 
 type EventChange struct {
-	Field        string      `json:"field,omitempty"`
-	Action       string      `json:"action,omitempty"`
-	OldValue     interface{} `json:"old_value,omitempty"`
-	NewValue     interface{} `json:"new_value,omitempty"`
-	AddedValue   interface{} `json:"added_value,omitempty"`
-	RemovedValue interface{} `json:"removed_value,omitempty"`
+	Field        string      `json:"field,omitempty" expr:"field"`
+	Action       string      `json:"action,omitempty" expr:"action"`
+	OldValue     interface{} `json:"old_value,omitempty" expr:"old_value"`
+	NewValue     interface{} `json:"new_value,omitempty" expr:"new_value"`
+	AddedValue   interface{} `json:"added_value,omitempty" expr:"added_value"`
+	RemovedValue interface{} `json:"removed_value,omitempty" expr:"removed_value"`
 }
 
 type EventsResponse struct {
@@ -64,13 +74,51 @@ type EventsResponse struct {
 	NextPage *NextPage `json:"next_page,omitempty"`
 }
 
-type NextPage struct {
-	Offset string `json:"offset,omitempty"`
-	Path   string `json:"path,omitempty"`
-	URI    string `json:"uri,omitempty"`
+type NextPage = client.NextPage
+
+// GapError is sent on Poll's error channel when Asana rejects the sync token
+// as too old. Events between the rejected token and NewSync were missed, so
+// consumers that mirror the resource should reconcile it in full. Polling
+// continues from NewSync.
+type GapError struct {
+	Resource string
+	Sync     string
+	NewSync  string
+}
+
+func (e *GapError) Error() string {
+	return fmt.Sprintf("sync token for resource %s expired, events were missed; continuing from a fresh sync token", e.Resource)
+}
+
+// IsGap reports whether err is a *GapError.
+func IsGap(err error) bool {
+	var gap *GapError
+	return errors.As(err, &gap)
+}
+
+// FreshSyncToken returns the sync token Asana includes in the 412 response
+// to a missing or expired sync token.
+func FreshSyncToken(err error) (string, bool) {
+	if !client.IsSyncTokenExpired(err) {
+		return "", false
+	}
+	apiErr, _ := client.AsAPIError(err)
+	var response EventsResponse
+	if json.Unmarshal(apiErr.Body, &response) != nil || response.Sync == "" {
+		return "", false
+	}
+	return response.Sync, true
 }
 
 func (em *EventManager) GetByResource(resourceGID string, syncToken string) (*EventsResponse, error) {
+	return em.GetByResourceContext(context.Background(), resourceGID, syncToken)
+}
+
+// GetByResourceContext returns the events of a resource since syncToken,
+// following has_more until Asana has no more. If a later page fails, the
+// events fetched before it are returned along with the error, and Sync is
+// the token to continue after them.
+func (em *EventManager) GetByResourceContext(ctx context.Context, resourceGID string, syncToken string) (*EventsResponse, error) {
 	endpoint := fmt.Sprintf("/events")
 	params := url.Values{}
 	params.Add("resource", resourceGID)
@@ -79,7 +127,7 @@ func (em *EventManager) GetByResource(resourceGID string, syncToken string) (*Ev
 		params.Add("sync", syncToken)
 	}
 
-	respBody, err := em.client.Get(endpoint, params)
+	respBody, err := em.client.GetContext(ctx, endpoint, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get events: %w", err)
 	}
@@ -93,14 +141,16 @@ func (em *EventManager) GetByResource(resourceGID string, syncToken string) (*Ev
 	for response.HasMore && response.Sync != "" {
 		params.Set("sync", response.Sync)
 
-		respBody, err := em.client.Get(endpoint, params)
+		respBody, err := em.client.GetContext(ctx, endpoint, params)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get next page of events: %w", err)
+			response.HasMore = false
+			return &response, fmt.Errorf("failed to get next page of events: %w", err)
 		}
 
 		var nextResponse EventsResponse
 		if err := json.Unmarshal(respBody, &nextResponse); err != nil {
-			return nil, fmt.Errorf("failed to parse next page response: %w", err)
+			response.HasMore = false
+			return &response, fmt.Errorf("failed to parse next page response: %w", err)
 		}
 
 		// Append the new events to our existing response
@@ -113,32 +163,23 @@ func (em *EventManager) GetByResource(resourceGID string, syncToken string) (*Ev
 }
 
 func (em *EventManager) InitializeSync(resourceGID string) (*EventsResponse, error) {
+	return em.InitializeSyncContext(context.Background(), resourceGID)
+}
+
+func (em *EventManager) InitializeSyncContext(ctx context.Context, resourceGID string) (*EventsResponse, error) {
 	// According to Asana docs, when you get a 412 error, the response includes a new sync token
-	// We need to handle this specially
-	endpoint := fmt.Sprintf("/events")
+	endpoint := "/events"
 	params := url.Values{}
 	params.Add("resource", resourceGID)
 
-	// Make raw request to handle 412 specially
-	fullURL := em.client.GetBaseURL() + endpoint + "?" + params.Encode()
-
-	req, err := http.NewRequest("GET", fullURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+em.client.GetAccessToken())
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := em.client.GetHTTPClient().Do(req)
+	respBody, err := em.client.GetContext(ctx, endpoint, params)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		if !client.IsSyncTokenExpired(err) {
+			return nil, fmt.Errorf("failed to initialize sync: %w", err)
+		}
+		// Even on 412, Asana returns the sync token in the response
+		apiErr, _ := client.AsAPIError(err)
+		respBody = apiErr.Body
 	}
 
 	var response EventsResponse
@@ -146,28 +187,21 @@ func (em *EventManager) InitializeSync(resourceGID string) (*EventsResponse, err
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	// Even on 412, Asana returns the sync token in the response
-	if resp.StatusCode == 412 {
-		// Return the response with the sync token
-		return &response, nil
-	}
-
-	if resp.StatusCode >= 400 {
-		var errorResp struct {
-			Errors []struct {
-				Message string `json:"message"`
-			} `json:"errors"`
-		}
-		if err := json.Unmarshal(respBody, &errorResp); err == nil && len(errorResp.Errors) > 0 {
-			return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errorResp.Errors[0].Message)
-		}
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
-	}
-
 	return &response, nil
 }
 
 func (em *EventManager) Poll(resourceGID string, syncToken string, pollInterval time.Duration) (<-chan Event, <-chan error) {
+	return em.PollContext(context.Background(), resourceGID, syncToken, pollInterval)
+}
+
+// PollContext polls the resource until ctx is done, at which point the
+// polling goroutine exits and both channels are closed. With a cursor store,
+// the sync token is saved after each batch of events.
+//
+// When the sync token expires, polling continues from the fresh token Asana
+// answers with, after sending a *GapError on the error channel. Without an
+// initial sync token, polling starts from the current end of the stream.
+func (em *EventManager) PollContext(ctx context.Context, resourceGID string, syncToken string, pollInterval time.Duration) (<-chan Event, <-chan error) {
 	eventsChan := make(chan Event)
 	errorsChan := make(chan error)
 
@@ -175,27 +209,100 @@ func (em *EventManager) Poll(resourceGID string, syncToken string, pollInterval
 		defer close(eventsChan)
 		defer close(errorsChan)
 
-		currentSync := syncToken
+		deliver := func(event Event) bool {
+			select {
+			case eventsChan <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		report := func(err error) bool {
+			select {
+			case errorsChan <- err:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
 
+		currentSync := syncToken
 		for {
-			response, err := em.GetByResource(resourceGID, currentSync)
-			if err != nil {
-				errorsChan <- err
-				time.Sleep(pollInterval)
-				continue
+			var ok bool
+			if currentSync, ok = em.pollOnce(ctx, resourceGID, currentSync, deliver, nil, report); !ok {
+				return
 			}
 
-			for _, event := range response.Data {
-				eventsChan <- event
+			timer := time.NewTimer(pollInterval)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
 			}
+		}
+	}()
 
-			if response.Sync != "" {
-				currentSync = response.Sync
+	return eventsChan, errorsChan
+}
+
+// pollOnce fetches the events of a resource since syncToken, passes them to
+// deliver and errors to report, and returns the sync token to continue from.
+// If handled is not nil, it must wait for the delivered events to be handled
+// before the sync token is saved. pollOnce returns false once ctx is done or
+// deliver, handled or report return false.
+func (em *EventManager) pollOnce(ctx context.Context, resourceGID, syncToken string, deliver func(Event) bool, handled func() bool, report func(error) bool) (string, bool) {
+	response, err := em.GetByResourceContext(ctx, resourceGID, syncToken)
+	if ctx.Err() != nil {
+		return syncToken, false
+	}
+
+	// When a later page fails, the events before it are still delivered
+	// and polling continues after them
+	var saveErr error
+	if response != nil {
+		for _, event := range response.Data {
+			if !deliver(event) {
+				return syncToken, false
 			}
+		}
+		if handled != nil && !handled() {
+			return syncToken, false
+		}
 
-			time.Sleep(pollInterval)
+		if response.Sync != "" && response.Sync != syncToken {
+			syncToken = response.Sync
+			// Saved once the events are delivered and handled, so a
+			// restart repeats events rather than missing them
+			saveErr = em.saveCursor(resourceGID, syncToken)
 		}
-	}()
+	}
 
-	return eventsChan, errorsChan
+	if fresh, ok := FreshSyncToken(err); ok {
+		// Without a token there was no position to lose
+		if syncToken != "" {
+			err = &GapError{Resource: resourceGID, Sync: syncToken, NewSync: fresh}
+		} else {
+			err = nil
+		}
+		syncToken = fresh
+		saveErr = errors.Join(saveErr, em.saveCursor(resourceGID, syncToken))
+	}
+	if saveErr != nil {
+		err = errors.Join(err, saveErr)
+	}
+	if err != nil {
+		return syncToken, report(err)
+	}
+	return syncToken, true
+}
+
+func (em *EventManager) saveCursor(resourceGID, syncToken string) error {
+	if em.cursors == nil {
+		return nil
+	}
+	if err := em.cursors.Put(Cursor{Profile: em.profile, Resource: resourceGID, Sync: syncToken}); err != nil {
+		return fmt.Errorf("failed to save sync token: %w", err)
+	}
+	return nil
 }
@@ -1,10 +1,14 @@
 package events
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/octoberswimmer/utka/client"
 )
@@ -230,3 +234,205 @@ func TestEventJSONMarshaling(t *testing.T) {
 		t.Error("NewValue should be a map")
 	}
 }
+
+func TestPollContextCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[{"action":"changed","type":"task"}],"sync":"token1","has_more":false}`))
+	}))
+	defer server.Close()
+
+	c := &client.Client{}
+	c.SetBaseURL(server.URL)
+	c.SetAccessToken("test_token")
+	c.SetHTTPClient(http.DefaultClient)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	em := NewEventManager(c)
+	eventsChan, errorsChan := em.PollContext(ctx, "123456", "token0", time.Millisecond)
+
+	select {
+	case <-eventsChan:
+	case err := <-errorsChan:
+		t.Fatalf("Unexpected error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for an event")
+	}
+
+	cancel()
+
+	deadline := time.After(5 * time.Second)
+	for eventsChan != nil || errorsChan != nil {
+		select {
+		case _, ok := <-eventsChan:
+			if !ok {
+				eventsChan = nil
+			}
+		case _, ok := <-errorsChan:
+			if !ok {
+				errorsChan = nil
+			}
+		case <-deadline:
+			t.Fatal("Channels were not closed after cancellation")
+		}
+	}
+}
+
+func TestPollSavesCursor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[{"action":"changed","type":"task"}],"sync":"token1","has_more":false}`))
+	}))
+	defer server.Close()
+
+	c := &client.Client{}
+	c.SetBaseURL(server.URL)
+	c.SetAccessToken("test_token")
+	c.SetHTTPClient(http.DefaultClient)
+
+	store := NewFileCursorStore(filepath.Join(t.TempDir(), "cursors.json"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	em := NewEventManager(c)
+	em.SetCursorStore(store, "work")
+	eventsChan, errorsChan := em.PollContext(ctx, "123456", "token0", time.Hour)
+
+	select {
+	case <-eventsChan:
+	case err := <-errorsChan:
+		t.Fatalf("Unexpected error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for an event")
+	}
+
+	// The cursor is saved once the batch has been delivered
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		cursor, err := store.Get("work", "123456")
+		if err == nil && cursor.Sync == "token1" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Stored cursor = %+v, %v, want token1", cursor, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPollResyncsAfterExpiredToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("sync") {
+		case "fresh":
+			w.Write([]byte(`{"data":[{"action":"changed","type":"task"}],"sync":"next","has_more":false}`))
+		case "next":
+			w.Write([]byte(`{"data":[],"sync":"next","has_more":false}`))
+		default:
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(`{"errors":[{"message":"Sync token invalid or too old"}],"sync":"fresh"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := &client.Client{}
+	c.SetBaseURL(server.URL)
+	c.SetAccessToken("test_token")
+	c.SetHTTPClient(http.DefaultClient)
+
+	tests := []struct {
+		name      string
+		syncToken string
+		wantGap   bool
+	}{
+		{"expired token", "expired", true},
+		{"no token", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewFileCursorStore(filepath.Join(t.TempDir(), "cursors.json"))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			em := NewEventManager(c)
+			em.SetCursorStore(store, "work")
+			eventsChan, errorsChan := em.PollContext(ctx, "123456", tt.syncToken, time.Millisecond)
+
+			var gap *GapError
+			for received := false; !received; {
+				select {
+				case <-eventsChan:
+					received = true
+				case err := <-errorsChan:
+					if !errors.As(err, &gap) {
+						t.Fatalf("Unexpected error: %v", err)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatal("Timed out waiting for events after the resync")
+				}
+			}
+
+			if (gap != nil) != tt.wantGap {
+				t.Errorf("Gap signalled = %v, want %v", gap != nil, tt.wantGap)
+			}
+			if gap != nil && (gap.Sync != "expired" || gap.NewSync != "fresh") {
+				t.Errorf("GapError = %+v, want expired replaced by fresh", gap)
+			}
+		})
+	}
+}
+
+func TestGetByResourceKeepsEventsBeforeFailedPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("sync") {
+		case "token0":
+			w.Write([]byte(`{"data":[{"action":"changed","type":"task"}],"sync":"token1","has_more":true}`))
+		case "fresh":
+			w.Write([]byte(`{"data":[],"sync":"fresh","has_more":false}`))
+		default:
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(`{"errors":[{"message":"Sync token invalid or too old"}],"sync":"fresh"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := &client.Client{}
+	c.SetBaseURL(server.URL)
+	c.SetAccessToken("test_token")
+	c.SetHTTPClient(http.DefaultClient)
+
+	em := NewEventManager(c)
+	result, err := em.GetByResource("123456", "token0")
+	if !client.IsSyncTokenExpired(err) {
+		t.Fatalf("GetByResource() error = %v, want the failed page's 412", err)
+	}
+	if result == nil || len(result.Data) != 1 || result.Sync != "token1" {
+		t.Fatalf("GetByResource() = %+v, want the first page's event and sync token", result)
+	}
+
+	// Polling delivers the event and saves its sync token before the gap
+	store := NewFileCursorStore(filepath.Join(t.TempDir(), "cursors.json"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	em.SetCursorStore(store, "work")
+	eventsChan, errorsChan := em.PollContext(ctx, "123456", "token0", time.Hour)
+
+	select {
+	case <-eventsChan:
+	case err := <-errorsChan:
+		t.Fatalf("Error before the event: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the event")
+	}
+	var gap *GapError
+	select {
+	case err := <-errorsChan:
+		if !errors.As(err, &gap) || gap.Sync != "token1" || gap.NewSync != "fresh" {
+			t.Fatalf("Error = %v, want a gap after token1", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the gap")
+	}
+	if cursor, err := store.Get("work", "123456"); err != nil || cursor.Sync != "fresh" {
+		t.Errorf("Stored cursor = %+v, %v, want fresh", cursor, err)
+	}
+}
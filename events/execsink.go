@@ -0,0 +1,62 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// DefaultExecTimeout bounds how long an ExecSink command may take for one
+// event.
+const DefaultExecTimeout = 30 * time.Second
+
+// ExecSink runs a shell command for each event, with the event as JSON on its
+// standard input and the resource it came from in $UTKA_EVENT_SOURCE. The
+// command's output goes to stderr, leaving stdout to the poller.
+type ExecSink struct {
+	Command string
+	Timeout time.Duration
+}
+
+func NewExecSink(command string) *ExecSink {
+	return &ExecSink{Command: command, Timeout: DefaultExecTimeout}
+}
+
+func (s *ExecSink) Write(ctx context.Context, event SourcedEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", s.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", s.Command)
+	}
+
+	cmd.Stdin = bytes.NewReader(append(data, '\n'))
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "UTKA_EVENT_SOURCE="+event.Source)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("event command %q failed: %w", s.Command, err)
+	}
+	return nil
+}
+
+func (s *ExecSink) Close() error {
+	return nil
+}
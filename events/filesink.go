@@ -0,0 +1,118 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+const (
+	// DefaultMaxFileSize is the size at which ParseSink's file sinks rotate.
+	DefaultMaxFileSize = 100 << 20
+
+	// DefaultMaxBackups is how many rotated files ParseSink's file sinks
+	// keep.
+	DefaultMaxBackups = 5
+)
+
+// FileSink appends events to a file as NDJSON. When a write would take the
+// file past its maximum size, the file is renamed to PATH.1, older backups
+// move up to PATH.2 and so on, and the oldest beyond the limit is removed.
+type FileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewFileSink opens path for appending. A maxSize of 0 disables rotation;
+// with maxBackups 0 the file is truncated instead of renamed.
+func NewFileSink(path string, maxSize int64, maxBackups int) (*FileSink, error) {
+	s := &FileSink{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) Write(ctx context.Context, event SourcedEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	line = append(line, '\n')
+
+	var rotateErr error
+	if s.file != nil && s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		rotateErr = s.rotate()
+	}
+	// The file is reopened after rotating, and by every write after that
+	// until opening it succeeds
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return errors.Join(rotateErr, err)
+		}
+	}
+
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return errors.Join(rotateErr, fmt.Errorf("failed to write event to %s: %w", s.path, err))
+	}
+	return rotateErr
+}
+
+func (s *FileSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open event file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open event file: %w", err)
+	}
+	s.file, s.size = file, info.Size()
+	return nil
+}
+
+// rotate closes the file and moves it out of the way, for Write to open a
+// new one. The sink keeps writing to a file even if moving it failed.
+func (s *FileSink) rotate() error {
+	closeErr := s.file.Close()
+	s.file = nil
+	if err := errors.Join(closeErr, s.moveBackups()); err != nil {
+		return fmt.Errorf("failed to rotate %s: %w", s.path, err)
+	}
+	return nil
+}
+
+func (s *FileSink) moveBackups() error {
+	if s.maxBackups == 0 {
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	backup := func(n int) string { return fmt.Sprintf("%s.%d", s.path, n) }
+	for n := s.maxBackups - 1; n >= 1; n-- {
+		if err := os.Rename(backup(n), backup(n+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(s.path, backup(1))
+}
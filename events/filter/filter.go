@@ -0,0 +1,238 @@
+// Package filter selects events with expressions such as
+// `event.action == "changed" && by("me")`, compiled once with expr against
+// the typed environment Env.
+package filter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/octoberswimmer/utka/events"
+)
+
+// Event is an event as expressions see it, with its JSON field names. Parts
+// missing from the event are zero values rather than nil, so
+// `event.change.field == "name"` is false for an event without a change.
+// Untyped values such as change.new_value may still be nil; use ?. for them,
+// as in `event.change.new_value?.gid`.
+type Event struct {
+	Source    string               `expr:"source"`
+	Action    string               `expr:"action"`
+	Type      string               `expr:"type"`
+	CreatedAt string               `expr:"created_at"`
+	User      events.EventUser     `expr:"user"`
+	Resource  events.EventResource `expr:"resource"`
+	Parent    events.EventParent   `expr:"parent"`
+	Change    events.EventChange   `expr:"change"`
+}
+
+// Env is the environment expressions are compiled against: the event and
+// helper functions about it.
+type Env struct {
+	Event Event `expr:"event"`
+
+	// Changed reports whether the event changed the named field.
+	Changed func(field string) bool `expr:"changed"`
+	// By reports whether the event was made by a user, given by GID, by
+	// name (ignoring case) or as "me".
+	By func(user string) bool `expr:"by"`
+	// Within reports whether the event happened within a duration of now,
+	// such as "90m", "2h" or "7d".
+	Within func(duration string) (bool, error) `expr:"within"`
+	// ResourceIs reports whether the event is about a type of resource,
+	// such as "task" or "story".
+	ResourceIs func(resourceType string) bool `expr:"resourceIs"`
+}
+
+// Options configures Compile.
+type Options struct {
+	// Me is the GID of the user by("me") matches. Without it, by("me")
+	// matches nothing.
+	Me string
+	// Now returns the time within() counts back from. It defaults to
+	// time.Now.
+	Now func() time.Time
+}
+
+// Filter is a compiled expression.
+type Filter struct {
+	expression string
+	program    *vm.Program
+	opts       Options
+}
+
+// Compile checks expression against Env and compiles it. Unknown fields,
+// unknown functions and expressions that are not boolean are errors here
+// rather than on every event.
+func Compile(expression string, opts Options) (*Filter, error) {
+	program, err := expr.Compile(expression, expr.Env(Env{}), expr.AsBool())
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter expression: %w", err)
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Filter{expression: expression, program: program, opts: opts}, nil
+}
+
+// String returns the expression the filter was compiled from.
+func (f *Filter) String() string {
+	return f.expression
+}
+
+// Match reports whether event satisfies the expression. It fails when the
+// expression cannot be evaluated on the event, such as when it reads a
+// field of a missing change value.
+func (f *Filter) Match(event events.SourcedEvent) (bool, error) {
+	result, err := expr.Run(f.program, f.env(event))
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate filter %q: %w", f.expression, err)
+	}
+	return result.(bool), nil
+}
+
+func (f *Filter) env(sourced events.SourcedEvent) Env {
+	event := Event{
+		Source:    sourced.Source,
+		Action:    sourced.Action,
+		Type:      sourced.Type,
+		CreatedAt: sourced.CreatedAt,
+	}
+	if sourced.User != nil {
+		event.User = *sourced.User
+	}
+	if sourced.Resource != nil {
+		event.Resource = *sourced.Resource
+	}
+	if sourced.Parent != nil {
+		event.Parent = *sourced.Parent
+	}
+	if sourced.Change != nil {
+		event.Change = *sourced.Change
+	}
+
+	return Env{
+		Event: event,
+		Changed: func(field string) bool {
+			return event.Change.Field == field
+		},
+		By: func(user string) bool {
+			switch {
+			case event.User.GID == "":
+				return false
+			case user == "me":
+				return f.opts.Me != "" && event.User.GID == f.opts.Me
+			}
+			return event.User.GID == user || strings.EqualFold(event.User.Name, user)
+		},
+		Within: func(duration string) (bool, error) {
+			d, err := parseDuration(duration)
+			if err != nil {
+				return false, err
+			}
+			created, err := time.Parse(time.RFC3339, event.CreatedAt)
+			if err != nil {
+				return false, nil
+			}
+			return f.opts.Now().Sub(created) <= d, nil
+		},
+		ResourceIs: func(resourceType string) bool {
+			if event.Resource.ResourceType != "" {
+				return event.Resource.ResourceType == resourceType
+			}
+			return event.Type == resourceType
+		},
+	}
+}
+
+// parseDuration parses a Go duration, or a number of days such as "7d".
+func parseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return d, nil
+}
+
+// Set applies several filters together. An event passes when it matches
+// every include filter and no exclude filter. An event an include filter
+// cannot be evaluated on does not pass; one an exclude filter cannot be
+// evaluated on is not excluded by it.
+type Set struct {
+	Include []*Filter
+	Exclude []*Filter
+
+	// OnError, if set, is called with each evaluation error.
+	OnError func(error)
+}
+
+// Empty reports whether the set lets every event through.
+func (s *Set) Empty() bool {
+	return s == nil || len(s.Include) == 0 && len(s.Exclude) == 0
+}
+
+func (s *Set) Match(event events.SourcedEvent) bool {
+	if s == nil {
+		return true
+	}
+
+	for _, filter := range s.Include {
+		ok, err := filter.Match(event)
+		if err != nil {
+			s.report(err)
+		}
+		if !ok {
+			return false
+		}
+	}
+	for _, filter := range s.Exclude {
+		ok, err := filter.Match(event)
+		if err != nil {
+			s.report(err)
+		}
+		if ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Set) report(err error) {
+	if s.OnError != nil {
+		s.OnError(err)
+	}
+}
+
+// Sink passes the events that match set on to sink.
+func Sink(sink events.Sink, set *Set) events.Sink {
+	return &filteredSink{sink: sink, set: set}
+}
+
+type filteredSink struct {
+	sink events.Sink
+	set  *Set
+}
+
+func (s *filteredSink) Write(ctx context.Context, event events.SourcedEvent) error {
+	if !s.set.Match(event) {
+		return nil
+	}
+	return s.sink.Write(ctx, event)
+}
+
+func (s *filteredSink) Close() error {
+	return s.sink.Close()
+}
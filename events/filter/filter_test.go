@@ -0,0 +1,447 @@
+package filter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/octoberswimmer/utka/events"
+)
+
+func TestEventFiltering(t *testing.T) {
+	tests := []struct {
+		name      string
+		events    []map[string]interface{}
+		filter    string
+		wantCount int
+		wantError bool
+	}{
+		{
+			name: "filter by action",
+			events: []map[string]interface{}{
+				{"action": "changed", "type": "task"},
+				{"action": "added", "type": "task"},
+				{"action": "changed", "type": "task"},
+			},
+			filter:    `event.action == "changed"`,
+			wantCount: 2,
+			wantError: false,
+		},
+		{
+			name: "filter by nested field",
+			events: []map[string]interface{}{
+				{
+					"action": "changed",
+					"resource": map[string]interface{}{
+						"resource_subtype": "default_task",
+					},
+				},
+				{
+					"action": "changed",
+					"resource": map[string]interface{}{
+						"resource_subtype": "milestone",
+					},
+				},
+			},
+			filter:    `event.action == "changed" && event.resource.resource_subtype == "default_task"`,
+			wantCount: 1,
+			wantError: false,
+		},
+		{
+			name: "filter with nil change field",
+			events: []map[string]interface{}{
+				{
+					"action": "changed",
+					"change": map[string]interface{}{
+						"field": "name",
+					},
+				},
+				{
+					"action": "added",
+					// no change field
+				},
+			},
+			filter:    `event.change.field == "name"`,
+			wantCount: 1,
+			wantError: false,
+		},
+		{
+			name: "filter by custom field new_value",
+			events: []map[string]interface{}{
+				{
+					"action": "changed",
+					"change": map[string]interface{}{
+						"field": "custom_fields",
+						"new_value": map[string]interface{}{
+							"gid":           "123456",
+							"display_value": "In Progress",
+						},
+					},
+				},
+				{
+					"action": "changed",
+					"change": map[string]interface{}{
+						"field": "custom_fields",
+						"new_value": map[string]interface{}{
+							"gid":           "789012",
+							"display_value": "Done",
+						},
+					},
+				},
+			},
+			filter:    `event.change.new_value.gid == "123456"`,
+			wantCount: 1,
+			wantError: false,
+		},
+		{
+			name: "complex filter with multiple conditions",
+			events: []map[string]interface{}{
+				{
+					"action": "changed",
+					"type":   "task",
+					"user": map[string]interface{}{
+						"name": "John Doe",
+					},
+					"change": map[string]interface{}{
+						"field": "name",
+					},
+				},
+				{
+					"action": "changed",
+					"type":   "task",
+					"user": map[string]interface{}{
+						"name": "Jane Smith",
+					},
+					"change": map[string]interface{}{
+						"field": "name",
+					},
+				},
+				{
+					"action": "added",
+					"type":   "task",
+					"user": map[string]interface{}{
+						"name": "John Doe",
+					},
+				},
+			},
+			filter:    `event.action == "changed" && event.user.name == "John Doe" && event.change.field == "name"`,
+			wantCount: 1,
+			wantError: false,
+		},
+		{
+			name: "filter handles nil gracefully",
+			events: []map[string]interface{}{
+				{
+					"action": "changed",
+					"change": nil,
+				},
+				{
+					"action": "changed",
+					"change": map[string]interface{}{
+						"new_value": nil,
+					},
+				},
+				{
+					"action": "changed",
+					"change": map[string]interface{}{
+						"new_value": map[string]interface{}{
+							"gid": "valid",
+						},
+					},
+				},
+			},
+			filter:    `event.change.new_value.gid == "valid"`,
+			wantCount: 1,
+			wantError: false,
+		},
+		{
+			name: "invalid expression syntax",
+			events: []map[string]interface{}{
+				{"action": "changed"},
+			},
+			filter:    `event.action ==`,
+			wantCount: 0,
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Compile the expression
+			filter, err := Compile(tt.filter, Options{})
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("Expected compilation error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to compile expression: %v", err)
+			}
+
+			// Filter events, skipping those the expression cannot be
+			// evaluated on
+			var filteredEvents []map[string]interface{}
+			for _, event := range tt.events {
+				if ok, err := filter.Match(toEvent(t, event)); err == nil && ok {
+					filteredEvents = append(filteredEvents, event)
+				}
+			}
+
+			if len(filteredEvents) != tt.wantCount {
+				t.Errorf("Expected %d filtered events, got %d", tt.wantCount, len(filteredEvents))
+			}
+		})
+	}
+}
+
+func TestEventFilteringWithRealJSON(t *testing.T) {
+	// Test with actual JSON structure from Asana
+	eventJSON := `[
+		{
+			"action": "changed",
+			"type": "task",
+			"created_at": "2025-09-09T15:29:56.550Z",
+			"change": {
+				"field": "custom_fields",
+				"action": "changed",
+				"new_value": {
+					"gid": "1210930954402852",
+					"name": "Marketing Materials Status",
+					"display_value": "Client Meeting or Introduction",
+					"enum_value": {
+						"gid": "1210930954402856",
+						"name": "Client Meeting or Introduction"
+					}
+				}
+			}
+		},
+		{
+			"action": "changed",
+			"type": "task",
+			"created_at": "2025-09-09T15:29:26.698Z",
+			"change": {
+				"field": "completed",
+				"action": "changed"
+			}
+		},
+		{
+			"action": "added",
+			"type": "task",
+			"created_at": "2025-09-09T15:14:59.602Z",
+			"resource": {
+				"gid": "1211305321446116",
+				"resource_type": "task",
+				"name": "Another subtask",
+				"resource_subtype": "default_task"
+			}
+		}
+	]`
+
+	var events []map[string]interface{}
+	if err := json.Unmarshal([]byte(eventJSON), &events); err != nil {
+		t.Fatalf("Failed to unmarshal events: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		filter    string
+		wantCount int
+	}{
+		{
+			name:      "filter by custom field gid",
+			filter:    `event.change.new_value.gid == "1210930954402852"`,
+			wantCount: 1,
+		},
+		{
+			name:      "filter by display value",
+			filter:    `event.change.new_value.display_value == "Client Meeting or Introduction"`,
+			wantCount: 1,
+		},
+		{
+			name:      "filter by change field",
+			filter:    `event.change.field == "completed"`,
+			wantCount: 1,
+		},
+		{
+			name:      "filter by action and resource subtype",
+			filter:    `event.action == "added" && event.resource.resource_subtype == "default_task"`,
+			wantCount: 1,
+		},
+		{
+			name:      "filter all changed events",
+			filter:    `event.action == "changed"`,
+			wantCount: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := Compile(tt.filter, Options{})
+			if err != nil {
+				t.Fatalf("Failed to compile expression: %v", err)
+			}
+
+			var filteredEvents []map[string]interface{}
+			for _, event := range events {
+				if ok, err := filter.Match(toEvent(t, event)); err == nil && ok {
+					filteredEvents = append(filteredEvents, event)
+				}
+			}
+
+			if len(filteredEvents) != tt.wantCount {
+				t.Errorf("Expected %d filtered events, got %d", tt.wantCount, len(filteredEvents))
+				for i, event := range filteredEvents {
+					eventJSON, _ := json.MarshalIndent(event, "", "  ")
+					t.Logf("Event %d: %s", i, string(eventJSON))
+				}
+			}
+		})
+	}
+}
+
+// toEvent converts an event in its JSON form, as Asana sends it.
+func toEvent(t *testing.T, fields map[string]interface{}) events.SourcedEvent {
+	t.Helper()
+	data, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event events.SourcedEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestHelpers(t *testing.T) {
+	now := time.Date(2025, 9, 9, 16, 0, 0, 0, time.UTC)
+	event := toEvent(t, map[string]interface{}{
+		"action":     "changed",
+		"type":       "task",
+		"created_at": "2025-09-09T15:29:56.550Z",
+		"user":       map[string]interface{}{"gid": "11", "name": "Ada Lovelace"},
+		"resource":   map[string]interface{}{"gid": "1000", "resource_type": "task"},
+		"change":     map[string]interface{}{"field": "name", "action": "changed"},
+	})
+	event.Source = "100"
+
+	tests := []struct {
+		expression string
+		want       bool
+		wantErr    bool
+	}{
+		{expression: `changed("name")`, want: true},
+		{expression: `changed("completed")`, want: false},
+		{expression: `by("11")`, want: true},
+		{expression: `by("ada lovelace")`, want: true},
+		{expression: `by("me")`, want: true},
+		{expression: `by("12")`, want: false},
+		{expression: `within("1h")`, want: true},
+		{expression: `within("10m")`, want: false},
+		{expression: `within("1d")`, want: true},
+		{expression: `within("soon")`, wantErr: true},
+		{expression: `resourceIs("task")`, want: true},
+		{expression: `resourceIs("story")`, want: false},
+		{expression: `event.source == "100" && changed("name") && by("me")`, want: true},
+		{expression: `event.parent.gid == ""`, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			filter, err := Compile(tt.expression, Options{Me: "11", Now: func() time.Time { return now }})
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			got, err := filter.Match(event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Match() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileChecksTypes(t *testing.T) {
+	for _, expression := range []string{
+		`event.actoin == "changed"`,
+		`changed(1)`,
+		`modified("name")`,
+		`event.action`,
+	} {
+		if _, err := Compile(expression, Options{}); err == nil {
+			t.Errorf("Compile(%q) succeeded", expression)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	compile := func(expression string) *Filter {
+		t.Helper()
+		filter, err := Compile(expression, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return filter
+	}
+	var errs []error
+	set := &Set{
+		Include: []*Filter{compile(`resourceIs("task")`)},
+		Exclude: []*Filter{compile(`changed("followers")`), compile(`event.change.new_value.gid == "1"`)},
+		OnError: func(err error) { errs = append(errs, err) },
+	}
+
+	tests := []struct {
+		name  string
+		event map[string]interface{}
+		want  bool
+	}{
+		{"included", map[string]interface{}{"type": "task", "change": map[string]interface{}{"field": "name", "new_value": map[string]interface{}{"gid": "2"}}}, true},
+		{"not included", map[string]interface{}{"type": "story"}, false},
+		{"excluded", map[string]interface{}{"type": "task", "change": map[string]interface{}{"field": "followers", "new_value": map[string]interface{}{"gid": "2"}}}, false},
+		{"exclude fails", map[string]interface{}{"type": "task"}, true},
+	}
+	for _, tt := range tests {
+		errs = nil
+		if got := set.Match(toEvent(t, tt.event)); got != tt.want {
+			t.Errorf("%s: Match() = %v, want %v", tt.name, got, tt.want)
+		}
+		if wantErr := tt.name == "exclude fails"; (len(errs) > 0) != wantErr {
+			t.Errorf("%s: reported errors %v", tt.name, errs)
+		}
+	}
+}
+
+type recordingSink struct{ events []events.SourcedEvent }
+
+func (s *recordingSink) Write(ctx context.Context, event events.SourcedEvent) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *recordingSink) Close() error { return errors.New("closed") }
+
+func TestSink(t *testing.T) {
+	filter, err := Compile(`event.action == "added"`, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := &recordingSink{}
+	sink := Sink(recorded, &Set{Include: []*Filter{filter}})
+
+	for _, action := range []string{"added", "changed", "added"} {
+		if err := sink.Write(context.Background(), events.SourcedEvent{Event: events.Event{Action: action}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(recorded.events) != 2 {
+		t.Errorf("Sink received %d events, want 2", len(recorded.events))
+	}
+	if err := sink.Close(); err == nil || !strings.Contains(err.Error(), "closed") {
+		t.Errorf("Close() = %v, want the wrapped sink's error", err)
+	}
+}
@@ -0,0 +1,112 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/octoberswimmer/utka/client"
+)
+
+// HTTPSink POSTs each event as JSON to a URL. Network errors, 429 and 5xx
+// responses are retried with exponential backoff, honouring Retry-After;
+// other responses outside 2xx fail the event at once.
+type HTTPSink struct {
+	URL    string
+	Client *http.Client
+	Header http.Header
+
+	// MaxAttempts is the total number of attempts per event, including the
+	// first one.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. It doubles on
+	// every subsequent attempt, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+func NewHTTPSink(url string) *HTTPSink {
+	return &HTTPSink{
+		URL:            url,
+		Client:         &http.Client{Timeout: 30 * time.Second},
+		Header:         http.Header{},
+		MaxAttempts:    5,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+	}
+}
+
+func (s *HTTPSink) Write(ctx context.Context, event SourcedEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	backoff := s.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		delay, err := s.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if delay < 0 || attempt >= s.MaxAttempts {
+			return fmt.Errorf("failed to post event to %s: %w", s.URL, err)
+		}
+
+		if delay == 0 {
+			delay = backoff
+			if backoff *= 2; s.MaxBackoff > 0 && backoff > s.MaxBackoff {
+				backoff = s.MaxBackoff
+			}
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return fmt.Errorf("failed to post event to %s: %w", s.URL, err)
+		}
+	}
+}
+
+// post makes one attempt. On failure, it returns the delay the server asked
+// for, 0 to back off as usual, or -1 if retrying is pointless.
+func (s *HTTPSink) post(ctx context.Context, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+	for name, values := range s.Header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	httpClient := s.Client
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return -1, err
+		}
+		return 0, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		delay, _ := client.ParseRetryAfter(resp.Header.Get("Retry-After"))
+		return delay, fmt.Errorf("server answered %s", resp.Status)
+	default:
+		return -1, fmt.Errorf("server answered %s", resp.Status)
+	}
+}
+
+func (s *HTTPSink) Close() error {
+	return nil
+}
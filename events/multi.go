@@ -0,0 +1,348 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/octoberswimmer/utka/client"
+)
+
+const (
+	// DefaultPollInterval is the time between polls of a resource when
+	// PollOptions does not set one.
+	DefaultPollInterval = 5 * time.Second
+
+	// DefaultWorkers is how many resources PollMany polls at once when
+	// PollOptions does not say.
+	DefaultWorkers = 4
+
+	// DefaultDiscoverInterval is how often PollMany looks for new resources
+	// when PollOptions does not say.
+	DefaultDiscoverInterval = time.Minute
+)
+
+// SourcedEvent is an event delivered by PollMany, tagged with the resource
+// whose event stream it was read from.
+type SourcedEvent struct {
+	Source string `json:"source"`
+	Event
+
+	// acks counts who is still handling the event, with
+	// PollOptions.Acknowledge.
+	acks *acks
+}
+
+// Hold delays the acknowledgement of an event delivered with
+// PollOptions.Acknowledge until a matching Ack, for handlers that finish
+// with it later, such as QueueSink. It does nothing for other events.
+func (e SourcedEvent) Hold() {
+	if e.acks != nil {
+		e.acks.add(1)
+	}
+}
+
+// Ack acknowledges that an event delivered with PollOptions.Acknowledge has
+// been handled, releasing the poller's hold or one from Hold. It does
+// nothing for other events.
+func (e SourcedEvent) Ack() {
+	if e.acks != nil {
+		e.acks.add(-1)
+	}
+}
+
+// acks counts the holds on the events delivered by one poll of a resource.
+type acks struct {
+	mu      sync.Mutex
+	pending int
+	// done is closed while nothing is pending.
+	done chan struct{}
+}
+
+func newAcks() *acks {
+	a := &acks{done: make(chan struct{})}
+	close(a.done)
+	return a
+}
+
+func (a *acks) add(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.pending == 0 && n > 0 {
+		a.done = make(chan struct{})
+	}
+	a.pending += n
+	if a.pending == 0 {
+		close(a.done)
+	}
+}
+
+// wait blocks until every hold is released, and returns false if ctx is
+// done first.
+func (a *acks) wait(ctx context.Context) bool {
+	a.mu.Lock()
+	done := a.done
+	a.mu.Unlock()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// PollOptions configures PollMany.
+type PollOptions struct {
+	// Interval is the time between polls of each resource.
+	Interval time.Duration
+
+	// Workers bounds how many resources are polled at once. Requests are
+	// also subject to the client's rate limiter.
+	Workers int
+
+	// Sync holds the sync token to start from for each resource. Resources
+	// without one start from the current end of their event stream.
+	Sync map[string]string
+
+	// Discover, if set, is called every DiscoverInterval to list the
+	// resources to watch, such as all projects of a workspace. Resources it
+	// returns that are not polled yet are added, starting from the current
+	// end of their event stream.
+	Discover         func(ctx context.Context) ([]string, error)
+	DiscoverInterval time.Duration
+
+	// Acknowledge makes the consumer acknowledge every event with
+	// SourcedEvent.Ack once it has handled it. A resource's sync token is
+	// then saved only after its events delivered before it are acknowledged,
+	// so events still being written when polling stops are read again.
+	Acknowledge bool
+}
+
+func (em *EventManager) PollMany(resourceGIDs []string, opts PollOptions) (<-chan SourcedEvent, <-chan error) {
+	return em.PollManyContext(context.Background(), resourceGIDs, opts)
+}
+
+// PollManyContext polls several resources until ctx is done, at which point
+// both channels are closed. Each resource is polled every opts.Interval by
+// one of a bounded pool of workers, and behaves as with PollContext: expired
+// sync tokens are replaced after a *GapError, and with a cursor store the
+// sync tokens are saved as events are delivered. Errors name the resource
+// they concern. A resource that no longer exists is dropped, and polling
+// ends early once no resources are left and there is no Discover.
+func (em *EventManager) PollManyContext(ctx context.Context, resourceGIDs []string, opts PollOptions) (<-chan SourcedEvent, <-chan error) {
+	eventsChan := make(chan SourcedEvent)
+	errorsChan := make(chan error)
+
+	go func() {
+		defer close(eventsChan)
+		defer close(errorsChan)
+
+		p := &multiPoller{manager: em, opts: opts, events: eventsChan, errors: errorsChan}
+		p.run(ctx, resourceGIDs)
+	}()
+
+	return eventsChan, errorsChan
+}
+
+type multiPoller struct {
+	manager *EventManager
+	opts    PollOptions
+	events  chan<- SourcedEvent
+	errors  chan<- error
+}
+
+// watched is the polling state of one resource. It is only touched by the
+// worker polling it, or by the scheduler between polls.
+type watched struct {
+	resource string
+	sync     string
+	next     time.Time
+	dropped  bool
+}
+
+// run schedules the resources on the workers until ctx is done, and returns
+// once every goroutine it started has exited.
+func (p *multiPoller) run(ctx context.Context, resourceGIDs []string) {
+	interval := p.opts.Interval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	workers := p.opts.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	jobs := make(chan *watched)
+	done := make(chan *watched)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer close(jobs)
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for w := range jobs {
+				p.poll(ctx, w)
+				select {
+				case done <- w:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	var discovered chan []string
+	if p.opts.Discover != nil {
+		discovered = make(chan []string)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.discover(ctx, discovered)
+		}()
+	}
+
+	// Resources are queued when due and wait in idle between polls
+	all := map[string]bool{}
+	var queue []*watched
+	idle := map[*watched]bool{}
+	add := func(resource string) {
+		if all[resource] {
+			return
+		}
+		all[resource] = true
+		queue = append(queue, &watched{resource: resource, sync: p.opts.Sync[resource]})
+	}
+	for _, resource := range resourceGIDs {
+		add(resource)
+	}
+
+	for {
+		if len(all) == 0 && discovered == nil {
+			return
+		}
+
+		now := time.Now()
+		var wake time.Time
+		for w := range idle {
+			if !w.next.After(now) {
+				delete(idle, w)
+				queue = append(queue, w)
+			} else if wake.IsZero() || w.next.Before(wake) {
+				wake = w.next
+			}
+		}
+
+		var timer *time.Timer
+		var wakeC <-chan time.Time
+		if !wake.IsZero() {
+			timer = time.NewTimer(wake.Sub(now))
+			wakeC = timer.C
+		}
+		var send chan<- *watched
+		var head *watched
+		if len(queue) > 0 {
+			send, head = jobs, queue[0]
+		}
+
+		select {
+		case send <- head:
+			queue = queue[1:]
+		case w := <-done:
+			if w.dropped {
+				delete(all, w.resource)
+			} else {
+				w.next = time.Now().Add(interval)
+				idle[w] = true
+			}
+		case resources := <-discovered:
+			for _, resource := range resources {
+				add(resource)
+			}
+		case <-wakeC:
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// poll fetches and delivers the new events of one resource.
+func (p *multiPoller) poll(ctx context.Context, w *watched) {
+	var acked *acks
+	var handled func() bool
+	if p.opts.Acknowledge {
+		acked = newAcks()
+		handled = func() bool { return acked.wait(ctx) }
+	}
+	deliver := func(event Event) bool {
+		sourced := SourcedEvent{Source: w.resource, Event: event, acks: acked}
+		sourced.Hold()
+		select {
+		case p.events <- sourced:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	report := func(err error) bool {
+		switch {
+		case client.IsNotFound(err):
+			w.dropped = true
+			err = fmt.Errorf("stopped polling resource %s: %w", w.resource, err)
+		case !IsGap(err):
+			err = fmt.Errorf("resource %s: %w", w.resource, err)
+		}
+		return p.report(ctx, err)
+	}
+
+	w.sync, _ = p.manager.pollOnce(ctx, w.resource, w.sync, deliver, handled, report)
+}
+
+// discover lists the resources to watch every DiscoverInterval and sends
+// them to found.
+func (p *multiPoller) discover(ctx context.Context, found chan<- []string) {
+	interval := p.opts.DiscoverInterval
+	if interval <= 0 {
+		interval = DefaultDiscoverInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		resources, err := p.opts.Discover(ctx)
+		if err != nil {
+			if ctx.Err() != nil || !p.report(ctx, fmt.Errorf("failed to discover resources: %w", err)) {
+				return
+			}
+			continue
+		}
+		select {
+		case found <- resources:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *multiPoller) report(ctx context.Context, err error) bool {
+	select {
+	case p.errors <- err:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,181 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/octoberswimmer/utka/client"
+)
+
+// newMultiServer serves one event per resource after its initial sync
+// token, and 404 for the resource "gone". It records the most requests it
+// handled at once in maxInFlight.
+func newMultiServer(t *testing.T, maxInFlight *atomic.Int32) *client.Client {
+	var inFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		resource := r.URL.Query().Get("resource")
+		w.Header().Set("Content-Type", "application/json")
+		switch sync := r.URL.Query().Get("sync"); {
+		case resource == "gone":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"message":"Unknown object"}]}`))
+		case sync == "start-"+resource:
+			fmt.Fprintf(w, `{"data":[{"action":"changed","type":"task","resource":{"gid":"%s"}}],"sync":"next-%s","has_more":false}`, resource, resource)
+		case strings.HasPrefix(sync, "next-"):
+			fmt.Fprintf(w, `{"data":[],"sync":"%s","has_more":false}`, sync)
+		default:
+			w.WriteHeader(http.StatusPreconditionFailed)
+			fmt.Fprintf(w, `{"errors":[{"message":"Sync token invalid or too old"}],"sync":"start-%s"}`, resource)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c := &client.Client{}
+	c.SetBaseURL(server.URL)
+	c.SetAccessToken("test_token")
+	c.SetHTTPClient(http.DefaultClient)
+	return c
+}
+
+func TestPollMany(t *testing.T) {
+	var maxInFlight atomic.Int32
+	em := NewEventManager(newMultiServer(t, &maxInFlight))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	discovered := []string{"3", "4", "5"}
+	eventsChan, errorsChan := em.PollManyContext(ctx, []string{"1", "2", "gone"}, PollOptions{
+		Interval: time.Millisecond,
+		Workers:  2,
+		Sync:     map[string]string{"1": "start-1"},
+		Discover: func(ctx context.Context) ([]string, error) {
+			return discovered, nil
+		},
+		DiscoverInterval: 10 * time.Millisecond,
+	})
+
+	// Each resource has one event, tagged with it
+	sources := map[string]bool{}
+	var dropped error
+	for len(sources) < 5 {
+		select {
+		case event := <-eventsChan:
+			if event.Resource == nil || event.Resource.GID != event.Source {
+				t.Fatalf("Event from %s has resource %+v", event.Source, event.Resource)
+			}
+			if sources[event.Source] {
+				t.Fatalf("Event from %s was delivered twice", event.Source)
+			}
+			sources[event.Source] = true
+		case err := <-errorsChan:
+			if IsGap(err) || !client.IsNotFound(err) || !strings.Contains(err.Error(), "gone") {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if dropped != nil {
+				t.Fatalf("Resource was polled after it was dropped: %v", err)
+			}
+			dropped = err
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out with events from %v", sources)
+		}
+	}
+	if dropped == nil {
+		t.Error("Missing resource was not reported")
+	}
+	if peak := maxInFlight.Load(); peak > 2 {
+		t.Errorf("%d resources were polled at once, want at most 2", peak)
+	}
+
+	cancel()
+	for range eventsChan {
+	}
+	if _, ok := <-errorsChan; ok {
+		t.Error("Error channel still open after cancellation")
+	}
+}
+
+func TestPollManyEndsWithoutResources(t *testing.T) {
+	var maxInFlight atomic.Int32
+	em := NewEventManager(newMultiServer(t, &maxInFlight))
+
+	eventsChan, errorsChan := em.PollManyContext(context.Background(), []string{"gone"}, PollOptions{Interval: time.Millisecond})
+
+	var errs []error
+	for err := range errorsChan {
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || !client.IsNotFound(errs[0]) {
+		t.Errorf("Errors = %v, want one not found error", errs)
+	}
+	if _, ok := <-eventsChan; ok {
+		t.Error("Event channel still open after the last resource was dropped")
+	}
+}
+
+func TestPollManyAcknowledge(t *testing.T) {
+	var maxInFlight atomic.Int32
+	em := NewEventManager(newMultiServer(t, &maxInFlight))
+	store := NewFileCursorStore(filepath.Join(t.TempDir(), "cursors.json"))
+	em.SetCursorStore(store, "work")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	eventsChan, _ := em.PollManyContext(ctx, []string{"1"}, PollOptions{
+		Interval:    time.Millisecond,
+		Sync:        map[string]string{"1": "start-1"},
+		Acknowledge: true,
+	})
+
+	var event SourcedEvent
+	select {
+	case event = <-eventsChan:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the event")
+	}
+
+	// The sync token after the event waits until the event is handled
+	time.Sleep(50 * time.Millisecond)
+	if cursor, err := store.Get("work", "1"); !errors.Is(err, ErrNoCursor) {
+		t.Fatalf("Cursor saved before the event was acknowledged: %+v, %v", cursor, err)
+	}
+	event.Hold()
+	event.Ack()
+	time.Sleep(50 * time.Millisecond)
+	if cursor, err := store.Get("work", "1"); !errors.Is(err, ErrNoCursor) {
+		t.Fatalf("Cursor saved while the event was still held: %+v, %v", cursor, err)
+	}
+
+	event.Ack()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		cursor, err := store.Get("work", "1")
+		if err != nil && !errors.Is(err, ErrNoCursor) {
+			t.Fatal(err)
+		}
+		if cursor.Sync == "next-1" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Cursor after the event was acknowledged = %+v, want next-1", cursor)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
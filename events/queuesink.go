@@ -0,0 +1,108 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultQueueSize is how many events a QueueSink holds for a sink that
+	// falls behind.
+	DefaultQueueSize = 1000
+
+	// DefaultDrainTimeout is the DrainTimeout of a new QueueSink.
+	DefaultDrainTimeout = 10 * time.Second
+)
+
+// QueueSink writes events to another sink from its own goroutine, so a slow
+// sink, such as an HTTP endpoint being retried, does not hold up polling or
+// other sinks. Write only queues the event, waiting while the queue is full.
+// Errors of the wrapped sink go to onError.
+//
+// Queued events are held (see SourcedEvent.Hold) until the wrapped sink has
+// written them, so with PollOptions.Acknowledge the sync tokens do not move
+// past events still in the queue.
+type QueueSink struct {
+	// DrainTimeout bounds how long Close waits for the queued events to be
+	// written.
+	DrainTimeout time.Duration
+
+	sink    Sink
+	queue   chan SourcedEvent
+	onError func(error)
+	cancel  context.CancelFunc
+	done    chan struct{}
+	dropped int
+}
+
+// NewQueueSink starts writing to sink the events queued with Write, holding
+// up to size of them. onError may be nil.
+func NewQueueSink(sink Sink, size int, onError func(error)) *QueueSink {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &QueueSink{
+		DrainTimeout: DefaultDrainTimeout,
+		sink:         sink,
+		queue:        make(chan SourcedEvent, size),
+		onError:      onError,
+		cancel:       cancel,
+		done:         make(chan struct{}),
+	}
+	go s.run(ctx)
+	return s
+}
+
+func (s *QueueSink) run(ctx context.Context) {
+	defer close(s.done)
+	for event := range s.queue {
+		if ctx.Err() != nil {
+			s.dropped++
+			continue
+		}
+		if err := s.sink.Write(ctx, event); err != nil {
+			if ctx.Err() != nil {
+				// Abandoned by Close while being written
+				s.dropped++
+				continue
+			}
+			if s.onError != nil {
+				s.onError(err)
+			}
+		}
+		event.Ack()
+	}
+}
+
+func (s *QueueSink) Write(ctx context.Context, event SourcedEvent) error {
+	event.Hold()
+	select {
+	case s.queue <- event:
+		return nil
+	case <-ctx.Done():
+		event.Ack()
+		return fmt.Errorf("failed to queue event of resource %s: %w", event.Source, ctx.Err())
+	}
+}
+
+// Close waits up to DrainTimeout for the queued events to be written,
+// abandons the rest and closes the wrapped sink. Abandoned events are never
+// acknowledged. Write must not be called after Close.
+func (s *QueueSink) Close() error {
+	close(s.queue)
+	timer := time.NewTimer(s.DrainTimeout)
+	defer timer.Stop()
+	select {
+	case <-s.done:
+	case <-timer.C:
+		s.cancel()
+		<-s.done
+	}
+	s.cancel()
+
+	err := s.sink.Close()
+	if s.dropped > 0 {
+		err = errors.Join(fmt.Errorf("%d queued event(s) were not written before closing the sink", s.dropped), err)
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Sink receives polled events to store or forward them. Write is called
+// with one event at a time, in the order the events arrive.
+type Sink interface {
+	Write(ctx context.Context, event SourcedEvent) error
+	Close() error
+}
+
+// MultiSink fans events out to several sinks. A sink that fails does not
+// keep the event from the others; their errors are joined.
+type MultiSink []Sink
+
+func (m MultiSink) Write(ctx context.Context, event SourcedEvent) error {
+	var errs []error
+	for _, sink := range m {
+		if err := sink.Write(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m MultiSink) Close() error {
+	var errs []error
+	for _, sink := range m {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ParseSink creates the sink a spec describes:
+//
+//	file:PATH[?max-size=SIZE&backups=N]  append NDJSON to a rotating file
+//	exec:COMMAND                         pipe each event to a shell command
+//	http:URL                             POST each event, retrying failures
+//	unix:PATH                            write NDJSON to a Unix socket
+//
+// For http, both "http:https://example.com/hook" and the URL alone are
+// accepted. SIZE is a number of bytes with an optional KB, MB or GB suffix.
+func ParseSink(spec string) (Sink, error) {
+	kind, target, _ := strings.Cut(spec, ":")
+	if target == "" {
+		return nil, fmt.Errorf("invalid sink %q: expected file:, exec:, http: or unix: followed by a target", spec)
+	}
+
+	switch kind {
+	case "file":
+		return parseFileSink(target)
+	case "exec":
+		return NewExecSink(target), nil
+	case "http", "https":
+		raw := target
+		if strings.HasPrefix(target, "//") {
+			raw = spec
+		}
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid sink %q: expected an http or https URL", spec)
+		}
+		return NewHTTPSink(u.String()), nil
+	case "unix":
+		return NewSocketSink(target), nil
+	default:
+		return nil, fmt.Errorf("unknown sink %q: expected file:, exec:, http: or unix:", kind)
+	}
+}
+
+// parseFileSink opens a file sink, reading the rotation settings from the
+// query after the path.
+func parseFileSink(target string) (Sink, error) {
+	path, query, _ := strings.Cut(target, "?")
+	options, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file sink options %q: %w", query, err)
+	}
+
+	maxSize := int64(DefaultMaxFileSize)
+	if value := options.Get("max-size"); value != "" {
+		if maxSize, err = parseSize(value); err != nil {
+			return nil, err
+		}
+	}
+	backups := DefaultMaxBackups
+	if value := options.Get("backups"); value != "" {
+		if backups, err = strconv.Atoi(value); err != nil || backups < 0 {
+			return nil, fmt.Errorf("invalid backups %q: expected a number of files", value)
+		}
+	}
+	for name := range options {
+		if name != "max-size" && name != "backups" {
+			return nil, fmt.Errorf("unknown file sink option %q: expected max-size or backups", name)
+		}
+	}
+
+	return NewFileSink(path, maxSize, backups)
+}
+
+// parseSize parses a number of bytes such as "512", "64KB" or "10MB".
+func parseSize(value string) (int64, error) {
+	number, unit := strings.ToUpper(value), int64(1)
+	for _, suffix := range []struct {
+		name string
+		unit int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if trimmed, ok := strings.CutSuffix(number, suffix.name); ok {
+			number, unit = trimmed, suffix.unit
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q: expected bytes with an optional KB, MB or GB suffix", value)
+	}
+	return n * unit, nil
+}
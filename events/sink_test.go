@@ -0,0 +1,395 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testEvent(source string) SourcedEvent {
+	return SourcedEvent{Source: source, Event: Event{Action: "changed", Type: "task"}}
+}
+
+func TestParseSink(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{spec: "file:" + filepath.Join(dir, "a.ndjson"), want: "*events.FileSink"},
+		{spec: "file:" + filepath.Join(dir, "b.ndjson") + "?max-size=10MB&backups=2", want: "*events.FileSink"},
+		{spec: "file:" + filepath.Join(dir, "c.ndjson") + "?max-size=ten", wantErr: true},
+		{spec: "file:" + filepath.Join(dir, "d.ndjson") + "?keep=2", wantErr: true},
+		{spec: "exec:jq .action", want: "*events.ExecSink"},
+		{spec: "http:https://example.com/hook", want: "*events.HTTPSink"},
+		{spec: "https://example.com/hook", want: "*events.HTTPSink"},
+		{spec: "http:example.com", wantErr: true},
+		{spec: "unix:/tmp/events.sock", want: "*events.SocketSink"},
+		{spec: "kafka:events", wantErr: true},
+		{spec: "file:", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			sink, err := ParseSink(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSink() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer sink.Close()
+			if got := fmt.Sprintf("%T", sink); got != tt.want {
+				t.Errorf("ParseSink() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{"512": 512, "64KB": 64 << 10, "10mb": 10 << 20, "1GB": 1 << 30, "0": 0}
+	for value, want := range tests {
+		if got, err := parseSize(value); err != nil || got != want {
+			t.Errorf("parseSize(%q) = %d, %v, want %d", value, got, err, want)
+		}
+	}
+	if _, err := parseSize("-1MB"); err == nil {
+		t.Error("parseSize accepted a negative size")
+	}
+}
+
+func TestFileSinkRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	line, _ := json.Marshal(testEvent("1"))
+	sink, err := NewFileSink(path, int64(2*(len(line)+1)), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Two events fit in a file, so seven events leave 1 in the file, 2 in
+	// each backup and drop the oldest 2
+	for range 7 {
+		if err := sink.Write(context.Background(), testEvent("1")); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]int{path: 1, path + ".1": 2, path + ".2": 2} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Count(string(data), "\n"); got != want {
+			t.Errorf("%s has %d events, want %d", filepath.Base(name), got, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Backup beyond the limit exists: %v", err)
+	}
+}
+
+func TestFileSinkReopensAfterFailedRotation(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("open files cannot be removed")
+	}
+	dir := filepath.Join(t.TempDir(), "events")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "events.ndjson")
+	line, _ := json.Marshal(testEvent("1"))
+	sink, err := NewFileSink(path, int64(len(line)+1), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	if err := sink.Write(context.Background(), testEvent("1")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	// Without its directory, the file can be neither moved nor reopened
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(context.Background(), testEvent("2")); err == nil {
+		t.Fatal("Write() succeeded without the directory")
+	}
+
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(context.Background(), testEvent("3")); err != nil {
+		t.Fatalf("Write() after the directory came back: %v", err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), `"source":"3"`) {
+		t.Errorf("File contains %q, want the last event", data)
+	}
+}
+
+func TestExecSink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	out := filepath.Join(t.TempDir(), "out")
+	sink := NewExecSink(`cat > "` + out + `"; echo "$UTKA_EVENT_SOURCE" >> "` + out + `"`)
+
+	if err := sink.Write(context.Background(), testEvent("42")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"source":"42"`) || !strings.HasSuffix(string(data), "\n42\n") {
+		t.Errorf("Command received %q", data)
+	}
+
+	if err := NewExecSink("exit 3").Write(context.Background(), testEvent("42")); err == nil {
+		t.Error("Write() succeeded for a failing command")
+	}
+}
+
+func TestHTTPSinkRetries(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		switch attempts.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			var event SourcedEvent
+			if err := json.NewDecoder(r.Body).Decode(&event); err != nil || event.Source != "7" {
+				t.Errorf("Posted event = %+v, %v", event, err)
+			}
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(server.URL)
+	sink.InitialBackoff = time.Millisecond
+	if err := sink.Write(context.Background(), testEvent("7")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("Attempts = %d, want 3", got)
+	}
+
+	// Client errors are not retried
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer rejecting.Close()
+	attempts.Store(0)
+	sink = NewHTTPSink(rejecting.URL)
+	sink.InitialBackoff = time.Millisecond
+	if err := sink.Write(context.Background(), testEvent("7")); err == nil || attempts.Load() != 1 {
+		t.Errorf("Write() = %v after %d attempts, want an error after 1", err, attempts.Load())
+	}
+
+	// Retry-After may be a date, which is waited for instead of backing off
+	busy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer busy.Close()
+	attempts.Store(0)
+	sink = NewHTTPSink(busy.URL)
+	sink.InitialBackoff = time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := sink.Write(ctx, testEvent("7")); err == nil || attempts.Load() != 1 {
+		t.Errorf("Write() = %v after %d attempts, want an error after waiting for Retry-After", err, attempts.Load())
+	}
+}
+
+func TestSocketSinkReconnects(t *testing.T) {
+	// Socket paths are limited to about 100 bytes, which t.TempDir can exceed
+	dir, err := os.MkdirTemp("", "sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.sock")
+
+	listen := func() (net.Listener, <-chan string) {
+		t.Helper()
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			t.Skipf("Unix sockets unavailable: %v", err)
+		}
+		lines := make(chan string, 10)
+		go func() {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				lines <- scanner.Text()
+			}
+		}()
+		return listener, lines
+	}
+
+	sink := NewSocketSink(path)
+	defer sink.Close()
+	for _, source := range []string{"1", "2"} {
+		listener, lines := listen()
+		if err := sink.Write(context.Background(), testEvent(source)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		select {
+		case line := <-lines:
+			if !strings.Contains(line, `"source":"`+source+`"`) {
+				t.Errorf("Listener received %q", line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for the event")
+		}
+
+		// Restart the listener, dropping the connection the sink holds
+		listener.Close()
+		os.Remove(path)
+		sink.conn.Close()
+	}
+}
+
+type failingSink struct{ writes int }
+
+func (s *failingSink) Write(ctx context.Context, event SourcedEvent) error {
+	s.writes++
+	return errors.New("unavailable")
+}
+
+func (s *failingSink) Close() error { return nil }
+
+func TestMultiSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	file, err := NewFileSink(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failing := &failingSink{}
+	sink := MultiSink{failing, file}
+
+	if err := sink.Write(context.Background(), testEvent("1")); err == nil {
+		t.Error("Write() hid the failing sink's error")
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); failing.writes != 1 || !strings.Contains(string(data), `"source":"1"`) {
+		t.Errorf("Sinks got %d writes and %q, want the event in both", failing.writes, data)
+	}
+}
+
+// blockingSink records events, starting each write by signalling started and
+// finishing it once release is closed or ctx is done.
+type blockingSink struct {
+	started chan string
+	release chan struct{}
+	written []string
+}
+
+func (s *blockingSink) Write(ctx context.Context, event SourcedEvent) error {
+	s.started <- event.Source
+	select {
+	case <-s.release:
+		s.written = append(s.written, event.Source)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *blockingSink) Close() error { return nil }
+
+func TestQueueSink(t *testing.T) {
+	slow := &blockingSink{started: make(chan string, 10), release: make(chan struct{})}
+	sink := NewQueueSink(slow, 1, nil)
+	acked := newAcks()
+	event := func(source string) SourcedEvent {
+		e := testEvent(source)
+		e.acks = acked
+		return e
+	}
+	pending := func() int {
+		acked.mu.Lock()
+		defer acked.mu.Unlock()
+		return acked.pending
+	}
+
+	// One event is being written and one waits, so a third waits for room
+	if err := sink.Write(context.Background(), event("1")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	<-slow.started
+	if err := sink.Write(context.Background(), event("2")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := sink.Write(ctx, event("3")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Write() with a full queue = %v, want it to wait until ctx is done", err)
+	}
+	if n := pending(); n != 2 {
+		t.Errorf("%d events held, want the 2 queued ones", n)
+	}
+
+	close(slow.release)
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if len(slow.written) != 2 || slow.written[0] != "1" || slow.written[1] != "2" {
+		t.Errorf("Written events = %v, want 1 and 2", slow.written)
+	}
+	if n := pending(); n != 0 {
+		t.Errorf("%d events still held after they were written", n)
+	}
+
+	// Closing gives up on events the sink does not take in time, and
+	// leaves them unacknowledged
+	stuck := &blockingSink{started: make(chan string, 10), release: make(chan struct{})}
+	var errs []error
+	acked = newAcks()
+	sink = NewQueueSink(stuck, 10, func(err error) { errs = append(errs, err) })
+	sink.DrainTimeout = 10 * time.Millisecond
+	for _, source := range []string{"1", "2"} {
+		if err := sink.Write(context.Background(), event(source)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	<-stuck.started
+	if err := sink.Close(); err == nil || !strings.Contains(err.Error(), "2 queued event") {
+		t.Errorf("Close() error = %v, want two events not written", err)
+	}
+	if len(errs) != 0 {
+		t.Errorf("Reported errors = %v, want none for abandoned events", errs)
+	}
+	if n := pending(); n != 2 {
+		t.Errorf("%d events held after closing, want the 2 abandoned ones", n)
+	}
+}
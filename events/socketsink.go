@@ -0,0 +1,74 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// socketTimeout bounds connecting to and writing an event to the socket, so
+// a listener that stopped reading cannot stall polling.
+const socketTimeout = 10 * time.Second
+
+// SocketSink writes events as NDJSON to a Unix socket. It connects on the
+// first event, so the listener may start after the poller, and reconnects
+// once when writing fails, for listeners that were restarted.
+type SocketSink struct {
+	path string
+	conn net.Conn
+}
+
+func NewSocketSink(path string) *SocketSink {
+	return &SocketSink{path: path}
+}
+
+func (s *SocketSink) Write(ctx context.Context, event SourcedEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	line = append(line, '\n')
+
+	err = s.write(ctx, line)
+	if err != nil && ctx.Err() == nil {
+		err = s.write(ctx, line)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write event to socket %s: %w", s.path, err)
+	}
+	return nil
+}
+
+func (s *SocketSink) write(ctx context.Context, line []byte) error {
+	if s.conn == nil {
+		dialer := net.Dialer{Timeout: socketTimeout}
+		conn, err := dialer.DialContext(ctx, "unix", s.path)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(socketTimeout))
+	if _, err := s.conn.Write(line); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (s *SocketSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
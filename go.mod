@@ -6,9 +6,10 @@ require (
 	github.com/expr-lang/expr v1.17.6
 	github.com/joho/godotenv v1.5.1
 	github.com/spf13/cobra v1.10.1
+	github.com/spf13/pflag v1.0.9
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/pflag v1.0.9 // indirect
 )
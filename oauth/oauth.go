@@ -0,0 +1,269 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	AuthURL  = "https://app.asana.com/-/oauth_authorize"
+	TokenURL = "https://app.asana.com/-/oauth_token"
+)
+
+// Config describes an OAuth application registered with the authorization server.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	Scopes       []string
+	// RedirectPort is the loopback port the callback server listens on. Zero
+	// picks a free port, which only works if the authorization server accepts
+	// any port for loopback redirect URIs.
+	RedirectPort int
+	HTTPClient   *http.Client
+}
+
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+	Expiry       time.Time `json:"-"`
+}
+
+// Expired reports whether the access token has expired or will within a minute.
+func (t *Token) Expired() bool {
+	return !t.Expiry.IsZero() && time.Now().Add(time.Minute).After(t.Expiry)
+}
+
+func (c *Config) authURL() string {
+	if c.AuthURL != "" {
+		return c.AuthURL
+	}
+	return AuthURL
+}
+
+func (c *Config) tokenURL() string {
+	if c.TokenURL != "" {
+		return c.TokenURL
+	}
+	return TokenURL
+}
+
+func (c *Config) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+// NewVerifier returns a random PKCE code verifier.
+func NewVerifier() (string, error) {
+	return randomString(32)
+}
+
+// Challenge derives the S256 PKCE code challenge from a verifier.
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random string: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// AuthCodeURL builds the URL the user visits to authorize the application.
+func (c *Config) AuthCodeURL(state, verifier, redirectURI string) string {
+	params := url.Values{}
+	params.Set("client_id", c.ClientID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("response_type", "code")
+	params.Set("state", state)
+	params.Set("code_challenge", Challenge(verifier))
+	params.Set("code_challenge_method", "S256")
+	if len(c.Scopes) > 0 {
+		params.Set("scope", strings.Join(c.Scopes, " "))
+	}
+
+	sep := "?"
+	if strings.Contains(c.authURL(), "?") {
+		sep = "&"
+	}
+	return c.authURL() + sep + params.Encode()
+}
+
+// Exchange trades an authorization code for a token.
+func (c *Config) Exchange(ctx context.Context, code, verifier, redirectURI string) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("code_verifier", verifier)
+	form.Set("redirect_uri", redirectURI)
+
+	token, err := c.requestToken(ctx, form)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	return token, nil
+}
+
+// Refresh obtains a new access token using a refresh token. Servers that do
+// not rotate refresh tokens omit it from the response, in which case the
+// original refresh token is kept.
+func (c *Config) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+
+	token, err := c.requestToken(ctx, form)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+func (c *Config) requestToken(ctx context.Context, form url.Values) (*Token, error) {
+	form.Set("client_id", c.ClientID)
+	if c.ClientSecret != "" {
+		form.Set("client_secret", c.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.tokenURL(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var tokenResp struct {
+		Token
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(respBody, &tokenResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response (%d): %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	if resp.StatusCode >= 400 || tokenResp.Error != "" {
+		if tokenResp.ErrorDescription != "" {
+			return nil, fmt.Errorf("%s: %s", tokenResp.Error, tokenResp.ErrorDescription)
+		}
+		if tokenResp.Error != "" {
+			return nil, fmt.Errorf("%s", tokenResp.Error)
+		}
+		return nil, fmt.Errorf("token endpoint returned %d", resp.StatusCode)
+	}
+
+	if tokenResp.AccessToken == "" {
+		return nil, fmt.Errorf("token endpoint returned no access token")
+	}
+
+	token := tokenResp.Token
+	if tokenResp.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	}
+	return &token, nil
+}
+
+// Login runs the authorization-code flow with PKCE. It starts a callback
+// server on the loopback interface, hands the authorization URL to
+// openBrowser and waits for the redirect carrying the authorization code.
+func (c *Config) Login(ctx context.Context, openBrowser func(authURL string) error) (*Token, error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", c.RedirectPort))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start callback server: %w", err)
+	}
+	defer listener.Close()
+
+	redirectURI := fmt.Sprintf("http://%s/callback", listener.Addr().String())
+
+	state, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := NewVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	type callbackResult struct {
+		code string
+		err  error
+	}
+	results := make(chan callbackResult, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		var result callbackResult
+		switch {
+		case query.Get("error") != "":
+			result.err = fmt.Errorf("authorization failed: %s %s", query.Get("error"), query.Get("error_description"))
+		case query.Get("state") != state:
+			result.err = fmt.Errorf("authorization failed: state mismatch")
+		case query.Get("code") == "":
+			result.err = fmt.Errorf("authorization failed: no code in callback")
+		default:
+			result.code = query.Get("code")
+		}
+
+		if result.err != nil {
+			http.Error(w, result.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Authorization complete. You can close this window and return to the terminal.")
+		}
+
+		select {
+		case results <- result:
+		default:
+		}
+	})
+
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	if err := openBrowser(c.AuthCodeURL(state, verifier, redirectURI)); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-results:
+		if result.err != nil {
+			return nil, result.err
+		}
+		return c.Exchange(ctx, result.code, verifier, redirectURI)
+	}
+}
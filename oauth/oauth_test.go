@@ -0,0 +1,177 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// newTestServer fakes the authorization and token endpoints. The authorize
+// endpoint redirects straight back with a code, remembering the PKCE challenge
+// so the token endpoint can check the verifier.
+func newTestServer(t *testing.T) (*httptest.Server, *Config) {
+	t.Helper()
+
+	var challenge string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("code_challenge_method") != "S256" {
+			t.Errorf("code_challenge_method = %q, want S256", query.Get("code_challenge_method"))
+		}
+		challenge = query.Get("code_challenge")
+
+		redirect, _ := url.Parse(query.Get("redirect_uri"))
+		params := url.Values{}
+		params.Set("code", "auth_code")
+		params.Set("state", query.Get("state"))
+		redirect.RawQuery = params.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.PostForm.Get("grant_type") {
+		case "authorization_code":
+			if r.PostForm.Get("code") != "auth_code" || Challenge(r.PostForm.Get("code_verifier")) != challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "bad code or verifier"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token":  "access_1",
+				"refresh_token": "refresh_1",
+				"token_type":    "bearer",
+				"expires_in":    3600,
+			})
+		case "refresh_token":
+			if r.PostForm.Get("refresh_token") != "refresh_1" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token": "access_2",
+				"token_type":   "bearer",
+				"expires_in":   3600,
+			})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "unsupported_grant_type"})
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server, &Config{
+		ClientID: "client_id",
+		AuthURL:  server.URL + "/authorize",
+		TokenURL: server.URL + "/token",
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	// base64url(sha256(verifier)) without padding
+	got := Challenge("dBjftJeZ4CVP-mJ0kzrSQ2Jbzh6NAWUTtRbYoqJL2jw")
+	want := "id0NlSDhUXg7hQGn1DV1Nxgq5KeaZXlzHsENzRsus6k"
+	if got != want {
+		t.Errorf("Challenge() = %q, want %q", got, want)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	_, cfg := newTestServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Following the authorization URL lands on the callback server
+	openBrowser := func(authURL string) error {
+		resp, err := http.Get(authURL)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	token, err := cfg.Login(ctx, openBrowser)
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if token.AccessToken != "access_1" || token.RefreshToken != "refresh_1" {
+		t.Errorf("Login() token = %+v", token)
+	}
+	if token.Expired() {
+		t.Error("Expected token not to be expired")
+	}
+}
+
+func TestLoginStateMismatch(t *testing.T) {
+	_, cfg := newTestServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	openBrowser := func(authURL string) error {
+		u, _ := url.Parse(authURL)
+		go func() {
+			resp, err := http.Get(u.Query().Get("redirect_uri") + "?code=auth_code&state=forged")
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+		return nil
+	}
+
+	if _, err := cfg.Login(ctx, openBrowser); err == nil {
+		t.Error("Expected a state mismatch error")
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	_, cfg := newTestServer(t)
+
+	token, err := cfg.Refresh(context.Background(), "refresh_1")
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if token.AccessToken != "access_2" {
+		t.Errorf("AccessToken = %q, want access_2", token.AccessToken)
+	}
+	if token.RefreshToken != "refresh_1" {
+		t.Errorf("RefreshToken = %q, want the original refresh token kept", token.RefreshToken)
+	}
+
+	if _, err := cfg.Refresh(context.Background(), "revoked"); err == nil {
+		t.Error("Expected an error for an invalid refresh token")
+	}
+}
+
+func TestTokenExpired(t *testing.T) {
+	tests := []struct {
+		name   string
+		expiry time.Time
+		want   bool
+	}{
+		{"no expiry", time.Time{}, false},
+		{"future", time.Now().Add(time.Hour), false},
+		{"within skew", time.Now().Add(30 * time.Second), true},
+		{"past", time.Now().Add(-time.Hour), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &Token{AccessToken: "token", Expiry: tt.expiry}
+			if got := token.Expired(); got != tt.want {
+				t.Errorf("Expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Column is one column of table, CSV and TSV output. Path is a JSONPath
+// expression into the resource's JSON; when it matches several values, for
+// example "projects[*].name", they are joined with ", ".
+type Column struct {
+	Header string
+	Path   string
+}
+
+// value renders the column for data, the generic form of a resource.
+func (c Column) value(data interface{}) string {
+	steps, err := parseSteps(c.Path)
+	if err != nil {
+		panic(fmt.Sprintf("output: invalid path %q for column %s: %v", c.Path, c.Header, err))
+	}
+	values := evaluate(steps, data)
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = formatValue(value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Column sets for each resource type. They are part of utka's interface:
+// scripts reading CSV or TSV output may depend on them, so columns are only
+// ever added at the end.
+var (
+	TaskColumns = []Column{
+		{"GID", "gid"},
+		{"NAME", "name"},
+		{"COMPLETED", "completed"},
+		{"ASSIGNEE", "assignee.name"},
+		{"DUE", "due_on"},
+		{"PROJECTS", "projects[*].name"},
+		{"SECTIONS", "memberships[*].section.name"},
+	}
+
+	ProjectColumns = []Column{
+		{"GID", "gid"},
+		{"NAME", "name"},
+		{"ARCHIVED", "archived"},
+		{"OWNER", "owner.name"},
+		{"STATUS", "current_status.title"},
+		{"DUE", "due_date"},
+	}
+
+	UserColumns = []Column{
+		{"GID", "gid"},
+		{"NAME", "name"},
+		{"EMAIL", "email"},
+		{"WORKSPACE", "workspace.name"},
+	}
+
+	WorkspaceColumns = []Column{
+		{"GID", "gid"},
+		{"NAME", "name"},
+		{"ORGANIZATION", "is_organization"},
+	}
+
+	WebhookColumns = []Column{
+		{"GID", "gid"},
+		{"ACTIVE", "active"},
+		{"RESOURCE", "resource.gid"},
+		{"RESOURCE_NAME", "resource.name"},
+		{"TARGET", "target"},
+		{"LAST_SUCCESS", "last_success_at"},
+		{"LAST_FAILURE", "last_failure_at"},
+	}
+
+	EventColumns = []Column{
+		{"CREATED", "created_at"},
+		{"ACTION", "action"},
+		{"TYPE", "resource.resource_type"},
+		{"RESOURCE", "resource.gid"},
+		{"NAME", "resource.name"},
+		{"FIELD", "change.field"},
+		{"USER", "user.name"},
+	}
+
+	// SourcedEventColumns are the columns of events polled from several
+	// resources, led by the resource each came from.
+	SourcedEventColumns = append([]Column{{"SOURCE", "source"}}, EventColumns...)
+)
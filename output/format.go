@@ -0,0 +1,92 @@
+// Package output renders API resources in the formats selected with utka's
+// --output flag: table, json, ndjson, yaml, csv, tsv, Go templates and
+// JSONPath expressions.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Format names accepted by Parse.
+const (
+	Text     = "text"
+	Table    = "table"
+	JSON     = "json"
+	NDJSON   = "ndjson"
+	YAML     = "yaml"
+	CSV      = "csv"
+	TSV      = "tsv"
+	Template = "template"
+	JSONPath = "jsonpath"
+)
+
+// Names lists the accepted formats for help and error messages.
+const Names = "text, table, json, ndjson, yaml, csv, tsv, template=<go template> or jsonpath=<expression>"
+
+// Format is a parsed --output value.
+type Format struct {
+	// Name is one of the format constants.
+	Name string
+
+	template *template.Template
+	path     *Path
+}
+
+// Parse parses an --output value. The empty string selects Text, which
+// leaves the rendering to each command's human-readable output.
+func Parse(spec string) (Format, error) {
+	name, arg, hasArg := strings.Cut(spec, "=")
+	switch name {
+	case "":
+		return Format{Name: Text}, nil
+	case Text, Table, JSON, NDJSON, YAML, CSV, TSV:
+		if hasArg {
+			return Format{}, fmt.Errorf("output format %s takes no argument", name)
+		}
+		return Format{Name: name}, nil
+	case Template:
+		if arg == "" {
+			return Format{}, fmt.Errorf("output format template needs a template, e.g. template='{{.name}}'")
+		}
+		tmpl, err := template.New("output").Funcs(templateFuncs).Parse(arg)
+		if err != nil {
+			return Format{}, fmt.Errorf("invalid output template: %w", err)
+		}
+		return Format{Name: Template, template: tmpl}, nil
+	case JSONPath:
+		if arg == "" {
+			return Format{}, fmt.Errorf("output format jsonpath needs an expression, e.g. jsonpath='{.gid}'")
+		}
+		path, err := ParsePath(arg)
+		if err != nil {
+			return Format{}, err
+		}
+		return Format{Name: JSONPath, path: path}, nil
+	}
+	return Format{}, fmt.Errorf("unknown output format %q: must be %s", spec, Names)
+}
+
+// IsText reports whether the command's own human-readable output should be
+// used.
+func (f Format) IsText() bool {
+	return f.Name == "" || f.Name == Text
+}
+
+var templateFuncs = template.FuncMap{
+	// json renders a value as compact JSON
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	// join joins the elements of a list with sep
+	"join": func(sep string, list []interface{}) string {
+		values := make([]string, len(list))
+		for i, v := range list {
+			values[i] = formatValue(v)
+		}
+		return strings.Join(values, sep)
+	},
+}
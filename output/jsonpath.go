@@ -0,0 +1,236 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Path is a JSONPath template in the style of kubectl: literal text with
+// expressions in braces, e.g. '{.gid}{"\t"}{.assignee.name}'. An expression
+// is a quoted string or a path of .field, [index] and [*] steps, optionally
+// starting with $. A template without braces is a single expression, so
+// '.gid' and '{.gid}' are the same.
+//
+// Fields that are missing, for example because they were not requested with
+// opt_fields, evaluate to nothing rather than an error.
+type Path struct {
+	parts []pathPart
+}
+
+type pathPart struct {
+	literal string
+	steps   []step
+	isPath  bool
+}
+
+type step struct {
+	field string
+	index int
+	kind  stepKind
+}
+
+type stepKind int
+
+const (
+	stepField stepKind = iota
+	stepIndex
+	stepAll
+)
+
+// ParsePath parses a JSONPath template.
+func ParsePath(spec string) (*Path, error) {
+	if !strings.Contains(spec, "{") {
+		spec = "{" + spec + "}"
+	}
+
+	p := &Path{}
+	rest := spec
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			p.parts = append(p.parts, pathPart{literal: rest})
+			break
+		}
+		if open > 0 {
+			p.parts = append(p.parts, pathPart{literal: rest[:open]})
+		}
+		end := closingBrace(rest[open:])
+		if end < 0 {
+			return nil, fmt.Errorf("invalid jsonpath %q: unclosed {", spec)
+		}
+		expr := strings.TrimSpace(rest[open+1 : open+end])
+		rest = rest[open+end+1:]
+
+		if strings.HasPrefix(expr, `"`) {
+			literal, err := strconv.Unquote(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid jsonpath %q: bad string %s", spec, expr)
+			}
+			p.parts = append(p.parts, pathPart{literal: literal})
+			continue
+		}
+
+		steps, err := parseSteps(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jsonpath %q: %w", spec, err)
+		}
+		p.parts = append(p.parts, pathPart{steps: steps, isPath: true})
+	}
+	return p, nil
+}
+
+// closingBrace returns the index of the } closing the { at the start of s,
+// skipping braces inside quoted strings.
+func closingBrace(s string) int {
+	inString := false
+	for i := 1; i < len(s); i++ {
+		switch {
+		case inString && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			inString = !inString
+		case !inString && s[i] == '}':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseSteps parses a path expression such as $.projects[*].name.
+func parseSteps(expr string) ([]step, error) {
+	expr = strings.TrimPrefix(expr, "$")
+	if expr == "" || expr == "." {
+		return nil, nil
+	}
+	if expr[0] != '.' && expr[0] != '[' {
+		expr = "." + expr
+	}
+
+	var steps []step
+	for expr != "" {
+		switch expr[0] {
+		case '.':
+			expr = expr[1:]
+			end := strings.IndexAny(expr, ".[")
+			if end < 0 {
+				end = len(expr)
+			}
+			field := expr[:end]
+			if field == "" {
+				return nil, fmt.Errorf("empty field name")
+			}
+			if field == "*" {
+				steps = append(steps, step{kind: stepAll})
+			} else {
+				steps = append(steps, step{field: field})
+			}
+			expr = expr[end:]
+		case '[':
+			end := strings.IndexByte(expr, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [")
+			}
+			subscript := strings.TrimSpace(expr[1:end])
+			expr = expr[end+1:]
+			if subscript == "*" {
+				steps = append(steps, step{kind: stepAll})
+				continue
+			}
+			if field, err := strconv.Unquote(strings.ReplaceAll(subscript, "'", `"`)); err == nil {
+				steps = append(steps, step{field: field})
+				continue
+			}
+			index, err := strconv.Atoi(subscript)
+			if err != nil {
+				return nil, fmt.Errorf("invalid subscript [%s]", subscript)
+			}
+			steps = append(steps, step{index: index, kind: stepIndex})
+		default:
+			return nil, fmt.Errorf("unexpected %q", expr[0])
+		}
+	}
+	return steps, nil
+}
+
+// Execute evaluates the template against data, which must be the generic
+// form of a JSON document (maps, slices and scalars). Expressions that
+// match several values print them separated by spaces.
+func (p *Path) Execute(data interface{}) string {
+	var b strings.Builder
+	for _, part := range p.parts {
+		if !part.isPath {
+			b.WriteString(part.literal)
+			continue
+		}
+		for i, value := range evaluate(part.steps, data) {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(formatValue(value))
+		}
+	}
+	return b.String()
+}
+
+// evaluate returns the values selected by steps.
+func evaluate(steps []step, data interface{}) []interface{} {
+	values := []interface{}{data}
+	for _, s := range steps {
+		var next []interface{}
+		for _, value := range values {
+			switch s.kind {
+			case stepField:
+				if object, ok := value.(map[string]interface{}); ok {
+					if member, ok := object[s.field]; ok {
+						next = append(next, member)
+					}
+				}
+			case stepIndex:
+				if list, ok := value.([]interface{}); ok {
+					index := s.index
+					if index < 0 {
+						index += len(list)
+					}
+					if index >= 0 && index < len(list) {
+						next = append(next, list[index])
+					}
+				}
+			case stepAll:
+				switch v := value.(type) {
+				case []interface{}:
+					next = append(next, v...)
+				case map[string]interface{}:
+					for _, name := range slices.Sorted(maps.Keys(v)) {
+						next = append(next, v[name])
+					}
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// formatValue renders a scalar as plain text and anything else as compact
+// JSON. Null renders as the empty string.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
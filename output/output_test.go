@@ -0,0 +1,153 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type task struct {
+	GID       string `json:"gid"`
+	Name      string `json:"name"`
+	Completed bool   `json:"completed"`
+	Assignee  *struct {
+		Name string `json:"name"`
+	} `json:"assignee,omitempty"`
+	Projects []map[string]string `json:"projects,omitempty"`
+}
+
+var testTasks = []task{
+	{GID: "1", Name: "Write report", Projects: []map[string]string{{"name": "Finance"}, {"name": "Q4"}}},
+	{GID: "2", Name: "Review, then send", Completed: true},
+}
+
+var testColumns = []Column{
+	{"GID", "gid"},
+	{"NAME", "name"},
+	{"DONE", "completed"},
+	{"ASSIGNEE", "assignee.name"},
+	{"PROJECTS", "projects[*].name"},
+}
+
+func printList(t *testing.T, spec string, items ...interface{}) string {
+	t.Helper()
+
+	format, err := Parse(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	p := NewPrinter(&buf, format, testColumns)
+	for _, item := range items {
+		if err := p.Print(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestParse(t *testing.T) {
+	for _, spec := range []string{"", "text", "table", "json", "ndjson", "yaml", "csv", "tsv", "template={{.gid}}", "jsonpath={.gid}"} {
+		if _, err := Parse(spec); err != nil {
+			t.Errorf("Parse(%q): %v", spec, err)
+		}
+	}
+	for _, spec := range []string{"xml", "json=x", "template=", "template={{.gid", "jsonpath={.gid"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestListFormats(t *testing.T) {
+	items := []interface{}{testTasks[0], testTasks[1]}
+
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"table", `GID   NAME                DONE    ASSIGNEE   PROJECTS
+1     Write report        false              Finance, Q4
+2     Review, then send   true
+`},
+		{"csv", `GID,NAME,DONE,ASSIGNEE,PROJECTS
+1,Write report,false,,"Finance, Q4"
+2,"Review, then send",true,,
+`},
+		{"tsv", "GID\tNAME\tDONE\tASSIGNEE\tPROJECTS\n1\tWrite report\tfalse\t\tFinance, Q4\n2\tReview, then send\ttrue\t\t\n"},
+		{"ndjson", `{"gid":"1","name":"Write report","completed":false,"projects":[{"name":"Finance"},{"name":"Q4"}]}
+{"gid":"2","name":"Review, then send","completed":true}
+`},
+		{"yaml", `- gid: "1"
+  name: Write report
+  completed: false
+  projects:
+    - name: Finance
+    - name: Q4
+- gid: "2"
+  name: Review, then send
+  completed: true
+`},
+		{"template={{.gid}}: {{.name}}", "1: Write report\n2: Review, then send\n"},
+		{`jsonpath={.gid}{"\t"}{.projects[*].name}`, "1\tFinance Q4\n2\t\n"},
+		{"jsonpath=.projects[-1].name", "Q4\n\n"},
+	}
+	for _, test := range tests {
+		if got := printList(t, test.spec, items...); got != test.want {
+			t.Errorf("%s output:\n%s\nwant:\n%s", test.spec, got, test.want)
+		}
+	}
+}
+
+func TestJSONList(t *testing.T) {
+	out := printList(t, "json", testTasks[0], testTasks[1])
+	var decoded []task
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("Output is not a JSON array: %v\n%s", err, out)
+	}
+	if len(decoded) != 2 || decoded[1].Name != "Review, then send" {
+		t.Errorf("Decoded %+v", decoded)
+	}
+	if !strings.HasPrefix(out, "[\n  {\n    \"gid\"") {
+		t.Errorf("Output is not indented:\n%s", out)
+	}
+}
+
+func TestEmptyList(t *testing.T) {
+	for spec, want := range map[string]string{
+		"json":   "[]\n",
+		"yaml":   "[]\n",
+		"csv":    "GID,NAME,DONE,ASSIGNEE,PROJECTS\n",
+		"ndjson": "",
+	} {
+		if got := printList(t, spec); got != want {
+			t.Errorf("Empty %s list: %q, want %q", spec, got, want)
+		}
+	}
+}
+
+func TestPrintSingle(t *testing.T) {
+	format, _ := Parse("json")
+	var buf bytes.Buffer
+	if err := Print(&buf, format, testColumns, testTasks[1]); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"gid\": \"2\",\n  \"name\": \"Review, then send\",\n  \"completed\": true\n}\n"
+	if buf.String() != want {
+		t.Errorf("JSON output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestColumnPaths(t *testing.T) {
+	for _, columns := range [][]Column{TaskColumns, ProjectColumns, UserColumns, WorkspaceColumns, WebhookColumns, EventColumns, SourcedEventColumns} {
+		for _, column := range columns {
+			if _, err := parseSteps(column.Path); err != nil {
+				t.Errorf("Column %s: %v", column.Header, err)
+			}
+		}
+	}
+}
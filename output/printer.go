@@ -0,0 +1,277 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Printer writes a list of resources as they arrive, so long listings can be
+// streamed page by page. Table output is aligned and therefore held back
+// until Flush or Close.
+type Printer struct {
+	w       io.Writer
+	format  Format
+	columns []Column
+	count   int
+
+	table *tabwriter.Writer
+	csv   *csv.Writer
+}
+
+// NewPrinter returns a Printer writing to w. The columns are used by the
+// table, csv and tsv formats.
+func NewPrinter(w io.Writer, format Format, columns []Column) *Printer {
+	p := &Printer{w: w, format: format, columns: columns}
+	switch format.Name {
+	case Table:
+		p.table = tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	case CSV, TSV:
+		p.csv = csv.NewWriter(w)
+		if format.Name == TSV {
+			p.csv.Comma = '\t'
+		}
+	}
+	return p
+}
+
+// Print writes one element of the list.
+func (p *Printer) Print(v interface{}) error {
+	defer func() { p.count++ }()
+
+	switch p.format.Name {
+	case JSON:
+		data, err := marshalIndent(v, "  ")
+		if err != nil {
+			return err
+		}
+		separator := ",\n  "
+		if p.count == 0 {
+			separator = "[\n  "
+		}
+		_, err = fmt.Fprintf(p.w, "%s%s", separator, data)
+		return err
+	case YAML:
+		node, err := yamlNode(v)
+		if err != nil {
+			return err
+		}
+		return writeYAML(p.w, &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{node}})
+	case Table, CSV, TSV:
+		return p.writeRow(v)
+	}
+	return write(p.w, p.format, v)
+}
+
+// Flush writes buffered output, such as the rows of a table. Rows printed
+// after a flush are aligned separately.
+func (p *Printer) Flush() error {
+	if p.count == 0 {
+		return nil
+	}
+	if p.table != nil {
+		return p.table.Flush()
+	}
+	if p.csv != nil {
+		p.csv.Flush()
+		return p.csv.Error()
+	}
+	return nil
+}
+
+// Close ends the list. A list with no elements prints as an empty JSON or
+// YAML list, or as just the header of a table.
+func (p *Printer) Close() error {
+	switch p.format.Name {
+	case JSON:
+		end := "\n]\n"
+		if p.count == 0 {
+			end = "[]\n"
+		}
+		_, err := io.WriteString(p.w, end)
+		return err
+	case YAML:
+		if p.count == 0 {
+			_, err := io.WriteString(p.w, "[]\n")
+			return err
+		}
+	case Table, CSV, TSV:
+		if p.count == 0 {
+			if err := p.writeHeader(); err != nil {
+				return err
+			}
+			p.count = 1
+		}
+		return p.Flush()
+	}
+	return nil
+}
+
+func (p *Printer) writeHeader() error {
+	headers := make([]string, len(p.columns))
+	for i, column := range p.columns {
+		headers[i] = column.Header
+	}
+	return p.writeCells(headers)
+}
+
+func (p *Printer) writeRow(v interface{}) error {
+	if p.count == 0 {
+		if err := p.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	data, err := generic(v)
+	if err != nil {
+		return err
+	}
+	cells := make([]string, len(p.columns))
+	for i, column := range p.columns {
+		cells[i] = column.value(data)
+	}
+	return p.writeCells(cells)
+}
+
+func (p *Printer) writeCells(cells []string) error {
+	if p.csv != nil {
+		return p.csv.Write(cells)
+	}
+	for i, cell := range cells {
+		// Keep each row on one line and the tab stops intact
+		cells[i] = strings.NewReplacer("\t", " ", "\n", " ", "\r", "").Replace(cell)
+	}
+	// Trailing empty cells would only pad the line with spaces
+	_, err := fmt.Fprintln(p.table, strings.TrimRight(strings.Join(cells, "\t"), "\t"))
+	return err
+}
+
+// Print writes a single resource to w. Table, CSV and TSV output has a
+// header and one row.
+func Print(w io.Writer, format Format, columns []Column, v interface{}) error {
+	switch format.Name {
+	case Table, CSV, TSV:
+		p := NewPrinter(w, format, columns)
+		if err := p.Print(v); err != nil {
+			return err
+		}
+		return p.Close()
+	case JSON:
+		data, err := marshalIndent(v, "")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	case YAML:
+		node, err := yamlNode(v)
+		if err != nil {
+			return err
+		}
+		return writeYAML(w, node)
+	}
+	return write(w, format, v)
+}
+
+// write handles the formats that render each resource on its own, one after
+// the other.
+func write(w io.Writer, format Format, v interface{}) error {
+	switch format.Name {
+	case NDJSON:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	case Template:
+		data, err := generic(v)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := format.template.Execute(&buf, data); err != nil {
+			return err
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		_, err = w.Write(buf.Bytes())
+		return err
+	case JSONPath:
+		data, err := generic(v)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, format.path.Execute(data))
+		return err
+	}
+	return fmt.Errorf("output format %q cannot be written here", format.Name)
+}
+
+// generic converts v to the maps, slices and scalars of its JSON form, which
+// is what templates, JSONPath expressions and columns work on. Field names
+// are therefore the API's, e.g. {{.assignee.name}}.
+func generic(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	err = decoder.Decode(&value)
+	return value, err
+}
+
+func marshalIndent(v interface{}, prefix string) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, prefix, "  "); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlNode converts v to YAML by way of its JSON form, which keeps the
+// field order and the fields preserved from the API that v does not model.
+func yamlNode(v interface{}) (*yaml.Node, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	node := doc.Content[0]
+	blockStyle(node)
+	return node, nil
+}
+
+// blockStyle clears the flow and quoting styles taken over from JSON, so
+// the encoder picks the usual YAML block style.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+func writeYAML(w io.Writer, node *yaml.Node) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
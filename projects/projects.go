@@ -1,8 +1,10 @@
 package projects
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"net/url"
 
 	"github.com/octoberswimmer/utka/client"
@@ -40,6 +42,40 @@ type Project struct {
 	ProjectBrief  *Brief     `json:"project_brief,omitempty"`
 	Team          *Team      `json:"team,omitempty"`
 	Workspace     *Workspace `json:"workspace,omitempty"`
+
+	// Extra holds fields requested with opt_fields that Project does not
+	// model, such as "custom_field_settings" or "completed".
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+func (p *Project) UnmarshalJSON(data []byte) error {
+	type plain Project
+	if err := json.Unmarshal(data, (*plain)(p)); err != nil {
+		return err
+	}
+	extra, err := client.UnknownFields(data, plain{})
+	p.Extra = extra
+	return err
+}
+
+func (p Project) MarshalJSON() ([]byte, error) {
+	type plain Project
+	return client.MarshalWithExtra(plain(p), p.Extra)
+}
+
+// FieldPresets are the project fields requested for each preset. Lists use
+// "default" unless told otherwise, Get uses "full".
+var FieldPresets = client.FieldPresets{
+	client.PresetMinimal: {"name", "archived"},
+	client.PresetDefault: {
+		"name", "archived", "created_at", "modified_at", "due_date", "start_on", "notes", "public", "color",
+		"owner.name", "current_status.title", "current_status.color",
+	},
+	client.PresetFull: {
+		"name", "archived", "created_at", "modified_at", "due_date", "start_on", "notes", "html_notes", "public",
+		"color", "owner.name", "current_status", "team.name", "workspace.name", "followers.name", "members.name",
+		"permalink_url", "default_view", "icon",
+	},
 }
 
 type Status struct {
@@ -59,6 +95,25 @@ type Member struct {
 	GID          string `json:"gid"`
 	ResourceType string `json:"resource_type"`
 	Name         string `json:"name,omitempty"`
+
+	// Extra holds fields requested with opt_fields that Member does not
+	// model, such as "owner.email".
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+func (m *Member) UnmarshalJSON(data []byte) error {
+	type plain Member
+	if err := json.Unmarshal(data, (*plain)(m)); err != nil {
+		return err
+	}
+	extra, err := client.UnknownFields(data, plain{})
+	m.Extra = extra
+	return err
+}
+
+func (m Member) MarshalJSON() ([]byte, error) {
+	type plain Member
+	return client.MarshalWithExtra(plain(m), m.Extra)
 }
 
 type Brief struct {
@@ -78,95 +133,85 @@ type Workspace struct {
 	Name         string `json:"name,omitempty"`
 }
 
-type ProjectsResponse struct {
-	Data     []Project `json:"data"`
-	NextPage *NextPage `json:"next_page,omitempty"`
+type Section struct {
+	GID          string `json:"gid"`
+	ResourceType string `json:"resource_type"`
+	Name         string `json:"name,omitempty"`
 }
 
+type ProjectsResponse = client.Page[Project]
+
 type ProjectResponse struct {
 	Data *Project `json:"data"`
 }
 
-type NextPage struct {
-	Offset string `json:"offset,omitempty"`
-	Path   string `json:"path,omitempty"`
-	URI    string `json:"uri,omitempty"`
+type NextPage = client.NextPage
+
+func (pm *ProjectManager) ListByWorkspace(workspaceGID string, archived bool, limit int, opts ...client.Options) ([]Project, error) {
+	return pm.ListByWorkspaceContext(context.Background(), workspaceGID, archived, limit, opts...)
+}
+
+func (pm *ProjectManager) ListByWorkspaceContext(ctx context.Context, workspaceGID string, archived bool, limit int, opts ...client.Options) ([]Project, error) {
+	return client.Collect(pm.IterByWorkspace(ctx, workspaceGID, archived, limit, opts...))
 }
 
-func (pm *ProjectManager) ListByWorkspace(workspaceGID string, archived bool, limit int) ([]Project, error) {
-	allProjects := []Project{}
+// IterByWorkspace streams projects page by page instead of buffering them all.
+func (pm *ProjectManager) IterByWorkspace(ctx context.Context, workspaceGID string, archived bool, limit int, opts ...client.Options) iter.Seq2[Project, error] {
 	params := url.Values{}
 	params.Add("workspace", workspaceGID)
 	params.Add("archived", fmt.Sprintf("%t", archived))
-	if limit > 0 {
-		params.Add("limit", fmt.Sprintf("%d", limit))
-	}
-	params.Add("opt_fields", "name,archived,created_at,modified_at,due_date,start_on,notes,public,color,owner.name,current_status.title,current_status.color")
-
-	for {
-		endpoint := "/projects"
-		respBody, err := pm.client.Get(endpoint, params)
-		if err != nil {
-			return nil, fmt.Errorf("failed to list projects: %w", err)
-		}
-
-		var response ProjectsResponse
-		if err := json.Unmarshal(respBody, &response); err != nil {
-			return nil, fmt.Errorf("failed to parse response: %w", err)
-		}
 
-		allProjects = append(allProjects, response.Data...)
-
-		if response.NextPage == nil || response.NextPage.Offset == "" {
-			break
-		}
+	return pm.list(ctx, params, limit, opts...)
+}
 
-		params.Set("offset", response.NextPage.Offset)
-	}
+func (pm *ProjectManager) ListByTeam(teamGID string, archived bool, limit int, opts ...client.Options) ([]Project, error) {
+	return pm.ListByTeamContext(context.Background(), teamGID, archived, limit, opts...)
+}
 
-	return allProjects, nil
+func (pm *ProjectManager) ListByTeamContext(ctx context.Context, teamGID string, archived bool, limit int, opts ...client.Options) ([]Project, error) {
+	return client.Collect(pm.IterByTeam(ctx, teamGID, archived, limit, opts...))
 }
 
-func (pm *ProjectManager) ListByTeam(teamGID string, archived bool, limit int) ([]Project, error) {
-	allProjects := []Project{}
+// IterByTeam streams projects page by page instead of buffering them all.
+func (pm *ProjectManager) IterByTeam(ctx context.Context, teamGID string, archived bool, limit int, opts ...client.Options) iter.Seq2[Project, error] {
 	params := url.Values{}
 	params.Add("team", teamGID)
 	params.Add("archived", fmt.Sprintf("%t", archived))
-	if limit > 0 {
-		params.Add("limit", fmt.Sprintf("%d", limit))
-	}
-	params.Add("opt_fields", "name,archived,created_at,modified_at,due_date,start_on,notes,public,color,owner.name,current_status.title,current_status.color")
 
-	for {
-		endpoint := "/projects"
-		respBody, err := pm.client.Get(endpoint, params)
-		if err != nil {
-			return nil, fmt.Errorf("failed to list projects: %w", err)
-		}
-
-		var response ProjectsResponse
-		if err := json.Unmarshal(respBody, &response); err != nil {
-			return nil, fmt.Errorf("failed to parse response: %w", err)
-		}
-
-		allProjects = append(allProjects, response.Data...)
+	return pm.list(ctx, params, limit, opts...)
+}
 
-		if response.NextPage == nil || response.NextPage.Offset == "" {
-			break
+// list pages through /projects with the given filter params. At most limit
+// projects are yielded; zero means no limit.
+func (pm *ProjectManager) list(ctx context.Context, params url.Values, limit int, opts ...client.Options) iter.Seq2[Project, error] {
+	params.Add("opt_fields", FieldPresets.OptFields(client.PresetDefault, opts...))
+
+	paginator := client.NewPaginator[Project](pm.client, "/projects", params)
+	paginator.MaxItems = limit
+
+	return func(yield func(Project, error) bool) {
+		for project, err := range paginator.All(ctx) {
+			if err != nil {
+				yield(Project{}, fmt.Errorf("failed to list projects: %w", err))
+				return
+			}
+			if !yield(project, nil) {
+				return
+			}
 		}
-
-		params.Set("offset", response.NextPage.Offset)
 	}
+}
 
-	return allProjects, nil
+func (pm *ProjectManager) Get(projectGID string, opts ...client.Options) (*Project, error) {
+	return pm.GetContext(context.Background(), projectGID, opts...)
 }
 
-func (pm *ProjectManager) Get(projectGID string) (*Project, error) {
+func (pm *ProjectManager) GetContext(ctx context.Context, projectGID string, opts ...client.Options) (*Project, error) {
 	endpoint := fmt.Sprintf("/projects/%s", projectGID)
 	params := url.Values{}
-	params.Add("opt_fields", "name,archived,created_at,modified_at,due_date,start_on,notes,html_notes,public,color,owner.name,current_status,team.name,workspace.name,followers.name,members.name,permalink_url,default_view,icon")
+	params.Add("opt_fields", FieldPresets.OptFields(client.PresetFull, opts...))
 
-	respBody, err := pm.client.Get(endpoint, params)
+	respBody, err := pm.client.GetContext(ctx, endpoint, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get project: %w", err)
 	}
@@ -178,3 +223,18 @@ func (pm *ProjectManager) Get(projectGID string) (*Project, error) {
 
 	return response.Data, nil
 }
+
+func (pm *ProjectManager) Sections(projectGID string) ([]Section, error) {
+	return pm.SectionsContext(context.Background(), projectGID)
+}
+
+// SectionsContext lists the sections of a project in board order.
+func (pm *ProjectManager) SectionsContext(ctx context.Context, projectGID string) ([]Section, error) {
+	endpoint := fmt.Sprintf("/projects/%s/sections", projectGID)
+	sections, err := client.NewPaginator[Section](pm.client, endpoint, url.Values{}).Collect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sections: %w", err)
+	}
+
+	return sections, nil
+}
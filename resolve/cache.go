@@ -0,0 +1,189 @@
+package resolve
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is how long a resolved name is trusted before it is looked up
+// again. Names can be changed in Asana, so entries must not live forever.
+const DefaultTTL = 24 * time.Hour
+
+// Cache remembers the GIDs that names and emails resolved to, or lists of
+// matches such as the projects of a workspace, in a JSON file shared by all
+// utka invocations. Entries older than the TTL are ignored. A missing or
+// unreadable file is treated as an empty cache.
+type Cache struct {
+	path string
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	loaded  bool
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	GID      string    `json:"gid,omitempty"`
+	Name     string    `json:"name,omitempty"`
+	Matches  []Match   `json:"matches,omitempty"`
+	Resolved time.Time `json:"resolved"`
+}
+
+// CacheDir returns utka under $XDG_CACHE_HOME or the platform's user cache
+// directory.
+func CacheDir() (string, error) {
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if dir == "" {
+		var err error
+		dir, err = os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to determine cache directory: %w", err)
+		}
+	}
+
+	return filepath.Join(dir, "utka"), nil
+}
+
+// DefaultCachePath returns the path of the name cache, names.json in
+// CacheDir.
+func DefaultCachePath() (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "names.json"), nil
+}
+
+// NewCache returns a cache stored at path. The file is read on first use.
+func NewCache(path string, ttl time.Duration) *Cache {
+	return &Cache{path: path, ttl: ttl, now: time.Now}
+}
+
+func (c *Cache) Path() string {
+	return c.path
+}
+
+// Get returns the cached match for key, if it is fresh.
+func (c *Cache) Get(key string) (Match, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.load()
+	entry, ok := c.entries[key]
+	if !ok || c.now().Sub(entry.Resolved) > c.ttl {
+		return Match{}, false
+	}
+	return Match{GID: entry.GID, Name: entry.Name}, true
+}
+
+// GetAll returns the cached list of matches for key, if it is fresh.
+func (c *Cache) GetAll(key string) ([]Match, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.load()
+	entry, ok := c.entries[key]
+	if !ok || c.now().Sub(entry.Resolved) > c.ttl {
+		return nil, false
+	}
+	return entry.Matches, true
+}
+
+// Put records a match for key and writes the cache file. Stale entries are
+// dropped on the way.
+func (c *Cache) Put(key string, match Match) error {
+	return c.put(key, cacheEntry{GID: match.GID, Name: match.Name})
+}
+
+// PutAll records a list of matches for key and writes the cache file.
+func (c *Cache) PutAll(key string, matches []Match) error {
+	if matches == nil {
+		// An empty list is still an answer worth remembering
+		matches = []Match{}
+	}
+	return c.put(key, cacheEntry{Matches: matches})
+}
+
+func (c *Cache) put(key string, entry cacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.load()
+	now := c.now()
+	for k, old := range c.entries {
+		if now.Sub(old.Resolved) > c.ttl {
+			delete(c.entries, k)
+		}
+	}
+	entry.Resolved = now
+	c.entries[key] = entry
+	return c.save()
+}
+
+// Clear removes the cache file.
+func (c *Cache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[string]cacheEntry{}
+	c.loaded = true
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear name cache: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache) load() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	c.entries = map[string]cacheEntry{}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		// A corrupt cache only costs a few lookups
+		c.entries = map[string]cacheEntry{}
+	}
+}
+
+// save replaces the cache file atomically, so concurrent invocations never
+// read a partial file.
+func (c *Cache) save() error {
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal name cache: %w", err)
+	}
+
+	dir := filepath.Dir(c.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+strings.TrimSuffix(filepath.Base(c.path), ".json")+"-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write name cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write name cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write name cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to write name cache: %w", err)
+	}
+	return nil
+}
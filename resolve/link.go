@@ -0,0 +1,96 @@
+package resolve
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Link holds the GIDs found in an Asana app URL. Fields the URL does not
+// mention are empty.
+type Link struct {
+	Workspace string
+	Project   string
+	Task      string
+	User      string
+}
+
+// ParseLink extracts the GIDs from an app.asana.com URL. Both the classic
+// layout and the one Asana introduced in 2024 are understood:
+//
+//	https://app.asana.com/0/<project>/<task>
+//	https://app.asana.com/0/<project>/list
+//	https://app.asana.com/0/profile/<user>
+//	https://app.asana.com/1/<workspace>/project/<project>/task/<task>
+//	https://app.asana.com/1/<workspace>/profile/<user>
+//
+// The second return value is false if s is not an Asana URL.
+func ParseLink(s string) (Link, bool) {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || !strings.EqualFold(u.Hostname(), "app.asana.com") {
+		return Link{}, false
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) < 2 {
+		return Link{}, false
+	}
+
+	var link Link
+	switch segments[0] {
+	case "0":
+		rest := segments[1:]
+		if rest[0] == "profile" {
+			if len(rest) > 1 && IsGID(rest[1]) {
+				link.User = rest[1]
+			}
+			break
+		}
+		// /0/<project>/<task>, where the project is 0 for tasks opened
+		// outside a project and the task is a view name such as "list" for
+		// project pages
+		if IsGID(rest[0]) && rest[0] != "0" {
+			link.Project = rest[0]
+		}
+		if len(rest) > 1 && IsGID(rest[1]) && rest[1] != rest[0] {
+			link.Task = rest[1]
+		}
+	case "1":
+		if IsGID(segments[1]) {
+			link.Workspace = segments[1]
+		}
+		for i := 2; i+1 < len(segments); i++ {
+			if !IsGID(segments[i+1]) {
+				continue
+			}
+			switch segments[i] {
+			case "project":
+				link.Project = segments[i+1]
+			case "task":
+				link.Task = segments[i+1]
+			case "profile":
+				link.User = segments[i+1]
+			}
+		}
+	default:
+		return Link{}, false
+	}
+	return link, true
+}
+
+// IsGID reports whether s looks like an Asana GID, a string of digits.
+func IsGID(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
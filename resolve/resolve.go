@@ -0,0 +1,331 @@
+// Package resolve turns the references people type on the command line into
+// Asana GIDs: raw GIDs, app.asana.com URLs, exact or partial names, email
+// addresses and "me".
+package resolve
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/octoberswimmer/utka/client"
+	"github.com/octoberswimmer/utka/projects"
+	"github.com/octoberswimmer/utka/tasks"
+	"github.com/octoberswimmer/utka/users"
+	"github.com/octoberswimmer/utka/workspaces"
+)
+
+// Kind is the type of resource a reference names.
+type Kind string
+
+const (
+	Workspace Kind = "workspace"
+	Team      Kind = "team"
+	Project   Kind = "project"
+	Section   Kind = "section"
+	Task      Kind = "task"
+	User      Kind = "user"
+
+	// Resource is any resource events can be read for: a task or project
+	// URL, a GID of any type, or the name of a project.
+	Resource Kind = "resource"
+)
+
+// typeaheadCount is how many candidates are fetched for a name. More than
+// a handful of matches means the name needs to be more specific anyway.
+const typeaheadCount = 20
+
+// ErrWorkspaceRequired is returned when a team, project, task or user name
+// is given without a workspace to search.
+var ErrWorkspaceRequired = errors.New("looking up names needs a workspace")
+
+// ErrProjectRequired is returned when a section name is given without the
+// project it is in.
+var ErrProjectRequired = errors.New("looking up section names needs a project")
+
+// Match is a resource a reference resolved to.
+type Match struct {
+	GID  string `json:"gid"`
+	Name string `json:"name,omitempty"`
+}
+
+func (m Match) String() string {
+	return fmt.Sprintf("%s (%s)", m.Name, m.GID)
+}
+
+// NotFoundError is returned when nothing matches a name.
+type NotFoundError struct {
+	Kind Kind
+	Ref  string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("no %s matches %q", e.Kind, e.Ref)
+}
+
+// AmbiguousError is returned when a name matches several resources and no
+// Chooser picked one.
+type AmbiguousError struct {
+	Kind    Kind
+	Ref     string
+	Matches []Match
+}
+
+func (e *AmbiguousError) Error() string {
+	names := make([]string, len(e.Matches))
+	for i, match := range e.Matches {
+		names[i] = match.String()
+	}
+	return fmt.Sprintf("%q matches %d %ss: %s; use a GID or a more specific name", e.Ref, len(e.Matches), e.Kind, strings.Join(names, ", "))
+}
+
+// InvalidError is returned for references that cannot name a resource of
+// the wanted kind, such as a project URL where a task is expected.
+type InvalidError struct {
+	Kind   Kind
+	Ref    string
+	Reason string
+}
+
+func (e *InvalidError) Error() string {
+	return fmt.Sprintf("cannot use %q as a %s: %s", e.Ref, e.Kind, e.Reason)
+}
+
+// Chooser picks one of several resources matching a name, typically by
+// asking the user.
+type Chooser func(kind Kind, ref string, matches []Match) (Match, error)
+
+// Resolver resolves references using the typeahead and users endpoints.
+// Names and emails are cached when a Cache is given; GIDs and URLs need no
+// lookup at all.
+type Resolver struct {
+	workspaces *workspaces.WorkspaceManager
+	users      *users.UserManager
+	projects   *projects.ProjectManager
+	tasks      *tasks.TaskManager
+	cache      *Cache
+
+	// Choose is called when a name is ambiguous. Without it, an
+	// AmbiguousError is returned.
+	Choose Chooser
+}
+
+func NewResolver(c *client.Client, cache *Cache) *Resolver {
+	return &Resolver{
+		workspaces: workspaces.NewWorkspaceManager(c),
+		users:      users.NewUserManager(c),
+		projects:   projects.NewProjectManager(c),
+		tasks:      tasks.NewTaskManager(c),
+		cache:      cache,
+	}
+}
+
+// Resolve returns the GID of the resource of the given kind that ref names.
+// Names of teams, projects, tasks and users are searched for in workspace,
+// which may be empty for GIDs, URLs, emails and "me". Sections are resolved
+// with ResolveSection.
+func (r *Resolver) Resolve(ctx context.Context, kind Kind, ref, workspace string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || IsGID(ref) {
+		return ref, nil
+	}
+
+	if link, ok := ParseLink(ref); ok {
+		return linkGID(kind, ref, link)
+	}
+
+	if kind == User && (strings.EqualFold(ref, "me") || strings.Contains(ref, "@")) {
+		return r.user(ctx, ref)
+	}
+
+	match, err := r.byName(ctx, kind, ref, workspace)
+	if err != nil {
+		return "", err
+	}
+	return match.GID, nil
+}
+
+// ResolveSection returns the GID of the section of project that ref names.
+// Sections have no URLs and typeahead does not search them, so names are
+// matched against the project's sections.
+func (r *Resolver) ResolveSection(ctx context.Context, ref, project string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || IsGID(ref) {
+		return ref, nil
+	}
+	if _, ok := ParseLink(ref); ok {
+		return "", &InvalidError{Kind: Section, Ref: ref, Reason: "sections have no URLs"}
+	}
+	if project == "" {
+		return "", fmt.Errorf("cannot find section %q: %w", ref, ErrProjectRequired)
+	}
+
+	match, err := r.byName(ctx, Section, ref, project)
+	if err != nil {
+		return "", err
+	}
+	return match.GID, nil
+}
+
+// linkGID picks the GID for kind out of a parsed URL.
+func linkGID(kind Kind, ref string, link Link) (string, error) {
+	var gid string
+	switch kind {
+	case Workspace:
+		gid = link.Workspace
+	case Project:
+		gid = link.Project
+	case Task:
+		gid = link.Task
+	case User:
+		gid = link.User
+	case Resource:
+		gid = cmp.Or(link.Task, link.Project, link.User, link.Workspace)
+	}
+	if gid == "" {
+		return "", &InvalidError{Kind: kind, Ref: ref, Reason: fmt.Sprintf("the URL does not link to a %s", kind)}
+	}
+	return gid, nil
+}
+
+// user resolves "me" and email addresses, which GET /users/{gid} accepts
+// directly. "me" depends on the token and is not cached.
+func (r *Resolver) user(ctx context.Context, ref string) (string, error) {
+	me := strings.EqualFold(ref, "me")
+	key := "user:" + strings.ToLower(ref)
+	if !me {
+		if match, ok := r.cached(key); ok {
+			return match.GID, nil
+		}
+	}
+
+	user, err := r.users.GetContext(ctx, strings.ToLower(ref))
+	if client.IsNotFound(err) {
+		return "", &NotFoundError{Kind: User, Ref: ref}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if !me {
+		r.remember(key, Match{GID: user.GID, Name: user.Name})
+	}
+	return user.GID, nil
+}
+
+// byName looks a name up in scope, the workspace or, for sections, the
+// project, and narrows the candidates down: an exact, case-insensitive match
+// wins, otherwise a single partial match is accepted.
+func (r *Resolver) byName(ctx context.Context, kind Kind, ref, scope string) (Match, error) {
+	if kind == Resource {
+		// Events are mostly read for projects, and only projects have
+		// names that are likely to be unique
+		kind = Project
+	}
+	if kind != Workspace && kind != Section && scope == "" {
+		return Match{}, fmt.Errorf("cannot find %s %q: %w", kind, ref, ErrWorkspaceRequired)
+	}
+
+	key := fmt.Sprintf("%s:%s:%s", kind, scope, strings.ToLower(ref))
+	if match, ok := r.cached(key); ok {
+		return match, nil
+	}
+
+	candidates, err := r.candidates(ctx, kind, ref, scope)
+	if err != nil {
+		return Match{}, err
+	}
+
+	var exact []Match
+	for _, candidate := range candidates {
+		if strings.EqualFold(candidate.Name, ref) {
+			exact = append(exact, candidate)
+		}
+	}
+
+	var match Match
+	switch {
+	case len(exact) == 1:
+		match = exact[0]
+	case len(exact) > 1:
+		if match, err = r.choose(kind, ref, exact); err != nil {
+			return Match{}, err
+		}
+	case len(candidates) == 1:
+		match = candidates[0]
+	case len(candidates) == 0:
+		return Match{}, &NotFoundError{Kind: kind, Ref: ref}
+	default:
+		if match, err = r.choose(kind, ref, candidates); err != nil {
+			return Match{}, err
+		}
+	}
+
+	r.remember(key, match)
+	return match, nil
+}
+
+// candidates returns the resources in scope whose names contain ref.
+// Workspaces and the sections of a project are few enough to be listed;
+// everything else is searched with typeahead.
+func (r *Resolver) candidates(ctx context.Context, kind Kind, ref, scope string) ([]Match, error) {
+	var matches []Match
+	contains := func(gid, name string) {
+		if strings.Contains(strings.ToLower(name), strings.ToLower(ref)) {
+			matches = append(matches, Match{GID: gid, Name: name})
+		}
+	}
+	switch kind {
+	case Workspace:
+		all, err := r.workspaces.ListContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, ws := range all {
+			contains(ws.GID, ws.Name)
+		}
+		return matches, nil
+	case Section:
+		sections, err := r.projects.SectionsContext(ctx, scope)
+		if err != nil {
+			return nil, err
+		}
+		for _, section := range sections {
+			contains(section.GID, section.Name)
+		}
+		return matches, nil
+	}
+
+	results, err := r.workspaces.TypeaheadContext(ctx, scope, string(kind), ref, typeaheadCount)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		matches = append(matches, Match{GID: result.GID, Name: result.Name})
+	}
+	return matches, nil
+}
+
+func (r *Resolver) choose(kind Kind, ref string, matches []Match) (Match, error) {
+	if r.Choose == nil {
+		return Match{}, &AmbiguousError{Kind: kind, Ref: ref, Matches: matches}
+	}
+	return r.Choose(kind, ref, matches)
+}
+
+func (r *Resolver) cached(key string) (Match, bool) {
+	if r.cache == nil {
+		return Match{}, false
+	}
+	return r.cache.Get(key)
+}
+
+// remember caches a match. Failing to write the cache only makes the next
+// lookup slower, so the error is dropped.
+func (r *Resolver) remember(key string, match Match) {
+	if r.cache != nil {
+		r.cache.Put(key, match)
+	}
+}
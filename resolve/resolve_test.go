@@ -0,0 +1,255 @@
+package resolve
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/octoberswimmer/utka/asanatest"
+	"github.com/octoberswimmer/utka/client"
+)
+
+func TestParseLink(t *testing.T) {
+	tests := []struct {
+		url  string
+		want Link
+		ok   bool
+	}{
+		{"https://app.asana.com/0/111/222", Link{Project: "111", Task: "222"}, true},
+		{"https://app.asana.com/0/111/222/f", Link{Project: "111", Task: "222"}, true},
+		{"https://app.asana.com/0/111/list", Link{Project: "111"}, true},
+		{"https://app.asana.com/0/111/111", Link{Project: "111"}, true},
+		{"https://app.asana.com/0/0/222", Link{Task: "222"}, true},
+		{"https://app.asana.com/0/profile/333", Link{User: "333"}, true},
+		{"https://app.asana.com/1/999/project/111/task/222?focus=true", Link{Workspace: "999", Project: "111", Task: "222"}, true},
+		{"https://app.asana.com/1/999/project/111/board", Link{Workspace: "999", Project: "111"}, true},
+		{"https://app.asana.com/1/999/task/222", Link{Workspace: "999", Task: "222"}, true},
+		{"https://app.asana.com/1/999/profile/333", Link{Workspace: "999", User: "333"}, true},
+		{"https://example.com/0/111/222", Link{}, false},
+		{"Q4 Roadmap", Link{}, false},
+		{"app.asana.com/0/111/222", Link{}, false},
+	}
+	for _, test := range tests {
+		got, ok := ParseLink(test.url)
+		if got != test.want || ok != test.ok {
+			t.Errorf("ParseLink(%q) = %+v, %v, want %+v, %v", test.url, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	srv := asanatest.NewServer()
+	defer srv.Close()
+
+	ws := srv.AddWorkspace(asanatest.Workspace{Name: "Acme"})
+	srv.AddWorkspace(asanatest.Workspace{Name: "Acme Labs"})
+	alice := srv.AddUser(asanatest.User{Name: "Alice Smith", Email: "alice@example.com"})
+	srv.AddUser(asanatest.User{Name: "Alan Smithee", Email: "alan@example.com"})
+	srv.SetMe(alice.GID)
+	roadmap := srv.AddProject(asanatest.Project{Name: "Q4 Roadmap", Workspace: ws.GID})
+	srv.AddProject(asanatest.Project{Name: "Q4 Roadmap Archive", Workspace: ws.GID})
+	launch := srv.AddProject(asanatest.Project{Name: "Launch", Workspace: ws.GID})
+	task := srv.AddTask(asanatest.Task{Name: "Write announcement", Workspace: ws.GID})
+	team := srv.AddTeam(asanatest.Team{Name: "Marketing", Workspace: ws.GID})
+	srv.AddSection(asanatest.Section{Name: "Done", Project: roadmap.GID})
+	drafts := srv.AddSection(asanatest.Section{Name: "Drafts", Project: launch.GID})
+	srv.AddSection(asanatest.Section{Name: "Done", Project: launch.GID})
+
+	// Typeahead counts against Asana's search limit, which the client's
+	// default throttling would enforce
+	c := srv.Client()
+	c.SetLimiter(nil)
+	r := NewResolver(c, nil)
+	ctx := context.Background()
+
+	tests := []struct {
+		kind Kind
+		ref  string
+		want string
+	}{
+		{Project, "1234", "1234"},
+		{Project, "Q4 Roadmap", roadmap.GID},
+		{Project, "q4 roadmap", roadmap.GID},
+		{Project, "laun", launch.GID},
+		{Project, "https://app.asana.com/0/" + launch.GID + "/list", launch.GID},
+		{Task, "https://app.asana.com/0/" + launch.GID + "/" + task.GID, task.GID},
+		{Task, "announcement", task.GID},
+		{Resource, "https://app.asana.com/0/" + launch.GID + "/" + task.GID, task.GID},
+		{Resource, "Launch", launch.GID},
+		{User, "me", alice.GID},
+		{User, "ALICE@example.com", alice.GID},
+		{User, "Alice Smith", alice.GID},
+		{Team, "market", team.GID},
+		{Workspace, "acme", ws.GID},
+	}
+	for _, test := range tests {
+		got, err := r.Resolve(ctx, test.kind, test.ref, ws.GID)
+		if err != nil {
+			t.Errorf("Resolve(%s, %q): %v", test.kind, test.ref, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Resolve(%s, %q) = %s, want %s", test.kind, test.ref, got, test.want)
+		}
+	}
+
+	for _, ref := range []string{"Drafts", "draft", drafts.GID} {
+		if got, err := r.ResolveSection(ctx, ref, launch.GID); err != nil || got != drafts.GID {
+			t.Errorf("ResolveSection(%q) = %s, %v, want %s", ref, got, err, drafts.GID)
+		}
+	}
+	if _, err := r.ResolveSection(ctx, "Drafts", ""); !errors.Is(err, ErrProjectRequired) {
+		t.Errorf("Resolving a section name without a project: %v", err)
+	}
+
+	var ambiguous *AmbiguousError
+	if _, err := r.Resolve(ctx, User, "smith", ws.GID); !errors.As(err, &ambiguous) || len(ambiguous.Matches) != 2 {
+		t.Errorf("Resolving an ambiguous name: %v", err)
+	}
+	var notFound *NotFoundError
+	if _, err := r.Resolve(ctx, Project, "Nonexistent", ws.GID); !errors.As(err, &notFound) {
+		t.Errorf("Resolving an unknown name: %v", err)
+	}
+	if _, err := r.ResolveSection(ctx, "Drafts", roadmap.GID); !errors.As(err, &notFound) {
+		t.Errorf("Resolving a section of another project: %v", err)
+	}
+	if _, err := r.Resolve(ctx, User, "nobody@example.com", ws.GID); !errors.As(err, &notFound) {
+		t.Errorf("Resolving an unknown email: %v", err)
+	}
+	var invalid *InvalidError
+	if _, err := r.Resolve(ctx, Task, "https://app.asana.com/0/"+launch.GID+"/list", ws.GID); !errors.As(err, &invalid) {
+		t.Errorf("Resolving a project URL as a task: %v", err)
+	}
+	if _, err := r.Resolve(ctx, Project, "Launch", ""); !errors.Is(err, ErrWorkspaceRequired) {
+		t.Errorf("Resolving a name without a workspace: %v", err)
+	}
+
+	r.Choose = func(kind Kind, ref string, matches []Match) (Match, error) {
+		return matches[len(matches)-1], nil
+	}
+	if got, err := r.Resolve(ctx, User, "smith", ws.GID); err != nil || got == alice.GID {
+		t.Errorf("Resolve with a chooser = %s, %v, want the last match", got, err)
+	}
+}
+
+func TestResolveCache(t *testing.T) {
+	srv := asanatest.NewServer()
+	defer srv.Close()
+
+	ws := srv.AddWorkspace(asanatest.Workspace{Name: "Acme"})
+	project := srv.AddProject(asanatest.Project{Name: "Launch", Workspace: ws.GID})
+
+	path := filepath.Join(t.TempDir(), "names.json")
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewCache(path, time.Hour)
+	cache.now = func() time.Time { return now }
+
+	requests := 0
+	c := srv.Client()
+	c.Use(func(next http.RoundTripper) http.RoundTripper {
+		return client.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			requests++
+			return next.RoundTrip(req)
+		})
+	})
+
+	r := NewResolver(c, cache)
+	if got, err := r.Resolve(context.Background(), Project, "Launch", ws.GID); err != nil || got != project.GID {
+		t.Fatalf("Resolve = %s, %v", got, err)
+	}
+	if requests != 1 {
+		t.Fatalf("Resolve sent %d requests, want 1", requests)
+	}
+
+	// A fresh cache read from the same file answers without a request
+	cache = NewCache(path, time.Hour)
+	cache.now = func() time.Time { return now.Add(30 * time.Minute) }
+	r = NewResolver(c, cache)
+	if got, err := r.Resolve(context.Background(), Project, "launch", ws.GID); err != nil || got != project.GID {
+		t.Fatalf("Cached Resolve = %s, %v", got, err)
+	}
+	if requests != 1 {
+		t.Errorf("Cached name was looked up again")
+	}
+
+	// Expired entries are looked up again
+	cache.now = func() time.Time { return now.Add(2 * time.Hour) }
+	if _, err := r.Resolve(context.Background(), Project, "Launch", ws.GID); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("Expired name was not looked up again")
+	}
+}
+
+func TestCacheLists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "completions.json")
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewCache(path, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	projects := []Match{{GID: "1", Name: "Launch"}, {GID: "2", Name: "Retro"}}
+	if err := cache.PutAll("projects:9", projects); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.PutAll("sections:1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	cache = NewCache(path, time.Minute)
+	cache.now = func() time.Time { return now.Add(30 * time.Second) }
+	if got, ok := cache.GetAll("projects:9"); !ok || !slices.Equal(got, projects) {
+		t.Errorf("GetAll = %v, %v, want %v", got, ok, projects)
+	}
+	if got, ok := cache.GetAll("sections:1"); !ok || len(got) != 0 {
+		t.Errorf("GetAll of an empty list = %v, %v, want a cached empty list", got, ok)
+	}
+	cache.now = func() time.Time { return now.Add(2 * time.Minute) }
+	if _, ok := cache.GetAll("projects:9"); ok {
+		t.Errorf("GetAll returned an expired list")
+	}
+}
+
+func TestWorkspaceInference(t *testing.T) {
+	srv := asanatest.NewServer()
+	defer srv.Close()
+
+	ws := srv.AddWorkspace(asanatest.Workspace{Name: "Acme"})
+	project := srv.AddProject(asanatest.Project{Name: "Launch", Workspace: ws.GID})
+	task := srv.AddTask(asanatest.Task{Name: "Draft", Memberships: []asanatest.Membership{{Project: project.GID}}})
+
+	r := NewResolver(srv.Client(), nil)
+	ctx := context.Background()
+
+	if only, ok, err := r.OnlyWorkspace(ctx); err != nil || !ok || only.GID != ws.GID {
+		t.Errorf("OnlyWorkspace = %v, %v, %v, want %s", only, ok, err, ws.GID)
+	}
+	srv.AddWorkspace(asanatest.Workspace{Name: "Personal"})
+	if _, ok, err := r.OnlyWorkspace(ctx); err != nil || ok {
+		t.Errorf("OnlyWorkspace with two workspaces = %v, %v", ok, err)
+	}
+
+	tests := []struct {
+		kind Kind
+		gid  string
+		want string
+	}{
+		{Task, task.GID, ws.GID},
+		{Project, project.GID, ws.GID},
+		{Resource, project.GID, ws.GID},
+		{Resource, task.GID, ws.GID},
+		{Resource, "404404", ""},
+	}
+	for _, test := range tests {
+		if got, err := r.WorkspaceOf(ctx, test.kind, test.gid); err != nil || got != test.want {
+			t.Errorf("WorkspaceOf(%s, %s) = %q, %v, want %q", test.kind, test.gid, got, err, test.want)
+		}
+	}
+	if _, err := r.WorkspaceOf(ctx, Task, "404404"); !client.IsNotFound(err) {
+		t.Errorf("WorkspaceOf an unknown task: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package resolve
+
+import (
+	"context"
+	"strings"
+
+	"github.com/octoberswimmer/utka/client"
+)
+
+// NeedsWorkspace reports whether resolving ref as kind searches a workspace,
+// so callers can avoid working out a workspace that would not be used.
+func NeedsWorkspace(kind Kind, ref string) bool {
+	ref = strings.TrimSpace(ref)
+	if kind == Workspace || kind == Section || ref == "" || IsGID(ref) {
+		return false
+	}
+	if _, ok := ParseLink(ref); ok {
+		return false
+	}
+	return !(kind == User && (strings.EqualFold(ref, "me") || strings.Contains(ref, "@")))
+}
+
+// OnlyWorkspace returns the token's workspace if it has exactly one. The
+// second return value is false if it has several, or none.
+func (r *Resolver) OnlyWorkspace(ctx context.Context) (Match, bool, error) {
+	all, err := r.workspaces.ListContext(ctx)
+	if err != nil {
+		return Match{}, false, err
+	}
+	if len(all) != 1 {
+		return Match{}, false, nil
+	}
+	return Match{GID: all[0].GID, Name: all[0].Name}, true, nil
+}
+
+// WorkspaceOf returns the GID of the workspace a task or project belongs
+// to. For Resource, gid is tried as a project and then as a task, and ""
+// is returned if it is neither.
+func (r *Resolver) WorkspaceOf(ctx context.Context, kind Kind, gid string) (string, error) {
+	// Resources never move between workspaces
+	key := "workspace-of:" + gid
+	if match, ok := r.cached(key); ok {
+		return match.GID, nil
+	}
+
+	var workspace string
+	switch kind {
+	case Project:
+		project, err := r.projects.GetContext(ctx, gid, workspaceField)
+		if err != nil {
+			return "", err
+		}
+		if project.Workspace != nil {
+			workspace = project.Workspace.GID
+		}
+	case Task:
+		task, err := r.tasks.GetContext(ctx, gid, workspaceField)
+		if err != nil {
+			return "", err
+		}
+		if task.Workspace != nil {
+			workspace = task.Workspace.GID
+		}
+	case Resource:
+		for _, kind := range []Kind{Project, Task} {
+			var err error
+			workspace, err = r.WorkspaceOf(ctx, kind, gid)
+			if client.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return "", err
+			}
+			break
+		}
+	}
+
+	if workspace != "" {
+		r.remember(key, Match{GID: workspace})
+	}
+	return workspace, nil
+}
+
+var workspaceField = client.Options{Fields: []string{"workspace"}}
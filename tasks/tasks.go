@@ -1,8 +1,10 @@
 package tasks
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"net/url"
 	"time"
 
@@ -45,12 +47,70 @@ type Task struct {
 	Memberships     []Membership  `json:"memberships,omitempty"`
 	Dependencies    []Dependency  `json:"dependencies,omitempty"`
 	Dependents      []Dependency  `json:"dependents,omitempty"`
+
+	// Extra holds fields requested with opt_fields that Task does not model,
+	// such as "permalink_url". Unmodelled fields of custom fields and users,
+	// such as "custom_fields.number_value", are kept in their own Extra.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+func (t *Task) UnmarshalJSON(data []byte) error {
+	type plain Task
+	if err := json.Unmarshal(data, (*plain)(t)); err != nil {
+		return err
+	}
+	extra, err := client.UnknownFields(data, plain{})
+	t.Extra = extra
+	return err
+}
+
+func (t Task) MarshalJSON() ([]byte, error) {
+	type plain Task
+	return client.MarshalWithExtra(plain(t), t.Extra)
+}
+
+// FieldPresets are the task fields requested for each preset. Lists use
+// "default" unless told otherwise, Get uses "full".
+var FieldPresets = client.FieldPresets{
+	client.PresetMinimal: {"name", "completed", "resource_subtype"},
+	client.PresetDefault: {
+		"name", "completed", "completed_at", "completed_by.name", "created_at", "due_on", "due_at", "notes",
+		"assignee.name", "assignee_section.name", "projects.name", "tags.name", "tags.color", "num_subtasks",
+		"parent.name", "memberships.project.name", "memberships.section.name", "resource_subtype", "start_on",
+		"custom_fields.name", "custom_fields.display_value", "custom_fields.type",
+	},
+	client.PresetFull: {
+		"name", "completed", "completed_at", "completed_by.name", "created_at", "modified_at", "due_on", "due_at",
+		"html_notes", "notes", "assignee.name", "assignee_section.name", "custom_fields.name",
+		"custom_fields.display_value", "custom_fields.type", "followers.name", "parent.name", "projects.name",
+		"tags.name", "tags.color", "workspace.name", "memberships.project.name", "memberships.section.name",
+		"num_subtasks", "resource_subtype", "start_on", "start_at", "dependencies.name", "dependents.name",
+	},
 }
 
 type User struct {
 	GID          string `json:"gid"`
 	ResourceType string `json:"resource_type"`
 	Name         string `json:"name,omitempty"`
+
+	// Extra holds fields requested with opt_fields that User does not
+	// model, such as "assignee.email".
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+func (u *User) UnmarshalJSON(data []byte) error {
+	type plain User
+	if err := json.Unmarshal(data, (*plain)(u)); err != nil {
+		return err
+	}
+	extra, err := client.UnknownFields(data, plain{})
+	u.Extra = extra
+	return err
+}
+
+func (u User) MarshalJSON() ([]byte, error) {
+	type plain User
+	return client.MarshalWithExtra(plain(u), u.Extra)
 }
 
 type Section struct {
@@ -66,6 +126,25 @@ type CustomField struct {
 	DisplayValue string      `json:"display_value,omitempty"`
 	Type         string      `json:"type,omitempty"`
 	Value        interface{} `json:"value,omitempty"`
+
+	// Extra holds fields requested with opt_fields that CustomField does
+	// not model, such as "custom_fields.number_value".
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+func (f *CustomField) UnmarshalJSON(data []byte) error {
+	type plain CustomField
+	if err := json.Unmarshal(data, (*plain)(f)); err != nil {
+		return err
+	}
+	extra, err := client.UnknownFields(data, plain{})
+	f.Extra = extra
+	return err
+}
+
+func (f CustomField) MarshalJSON() ([]byte, error) {
+	type plain CustomField
+	return client.MarshalWithExtra(plain(f), f.Extra)
 }
 
 type Project struct {
@@ -98,178 +177,117 @@ type Dependency struct {
 	Name         string `json:"name,omitempty"`
 }
 
-type TasksResponse struct {
-	Data     []Task    `json:"data"`
-	NextPage *NextPage `json:"next_page,omitempty"`
-}
+type TasksResponse = client.Page[Task]
 
 type TaskResponse struct {
 	Data *Task `json:"data"`
 }
 
-type NextPage struct {
-	Offset string `json:"offset,omitempty"`
-	Path   string `json:"path,omitempty"`
-	URI    string `json:"uri,omitempty"`
+type NextPage = client.NextPage
+
+func (tm *TaskManager) ListByProject(projectGID string, completedDays int, limit int, opts ...client.Options) ([]Task, error) {
+	return tm.ListByProjectContext(context.Background(), projectGID, completedDays, limit, opts...)
+}
+
+func (tm *TaskManager) ListByProjectContext(ctx context.Context, projectGID string, completedDays int, limit int, opts ...client.Options) ([]Task, error) {
+	return client.Collect(tm.IterByProject(ctx, projectGID, completedDays, limit, opts...))
 }
 
-func (tm *TaskManager) ListByProject(projectGID string, completedDays int, limit int) ([]Task, error) {
-	allTasks := []Task{}
+// IterByProject streams tasks page by page instead of buffering them all.
+func (tm *TaskManager) IterByProject(ctx context.Context, projectGID string, completedDays int, limit int, opts ...client.Options) iter.Seq2[Task, error] {
 	params := url.Values{}
 	params.Add("project", projectGID)
+	params.Add("opt_fields", FieldPresets.OptFields(client.PresetDefault, opts...))
 
-	// Calculate completed_since timestamp
-	if completedDays > 0 {
-		// Get tasks completed in the last N days
-		completedSince := time.Now().AddDate(0, 0, -completedDays).UTC().Format(time.RFC3339)
-		params.Add("completed_since", completedSince)
-	} else {
-		// Only get incomplete tasks
-		params.Add("completed_since", "now")
-	}
-
-	if limit > 0 {
-		params.Add("limit", fmt.Sprintf("%d", limit))
-	}
-	params.Add("opt_fields", "name,completed,completed_at,completed_by.name,created_at,due_on,due_at,notes,assignee.name,assignee_section.name,tags.name,tags.color,num_subtasks,parent.name,memberships.section.name,resource_subtype,start_on")
-
-	for {
-		endpoint := "/tasks"
-		respBody, err := tm.client.Get(endpoint, params)
-		if err != nil {
-			return nil, fmt.Errorf("failed to list tasks: %w", err)
-		}
-
-		var response TasksResponse
-		if err := json.Unmarshal(respBody, &response); err != nil {
-			return nil, fmt.Errorf("failed to parse response: %w", err)
-		}
-
-		// When completedDays is 0, filter out completed tasks
-		// When completedDays > 0, include all tasks (completed and incomplete)
-		for _, task := range response.Data {
-			if completedDays > 0 || !task.Completed {
-				allTasks = append(allTasks, task)
-			}
-		}
-
-		if response.NextPage == nil || response.NextPage.Offset == "" {
-			break
-		}
+	return tm.list(ctx, params, completedDays, limit)
+}
 
-		params.Set("offset", response.NextPage.Offset)
-	}
+func (tm *TaskManager) ListByAssignee(assigneeGID string, workspaceGID string, completedDays int, limit int, opts ...client.Options) ([]Task, error) {
+	return tm.ListByAssigneeContext(context.Background(), assigneeGID, workspaceGID, completedDays, limit, opts...)
+}
 
-	return allTasks, nil
+func (tm *TaskManager) ListByAssigneeContext(ctx context.Context, assigneeGID string, workspaceGID string, completedDays int, limit int, opts ...client.Options) ([]Task, error) {
+	return client.Collect(tm.IterByAssignee(ctx, assigneeGID, workspaceGID, completedDays, limit, opts...))
 }
 
-func (tm *TaskManager) ListByAssignee(assigneeGID string, workspaceGID string, completedDays int, limit int) ([]Task, error) {
-	allTasks := []Task{}
+// IterByAssignee streams tasks page by page instead of buffering them all.
+func (tm *TaskManager) IterByAssignee(ctx context.Context, assigneeGID string, workspaceGID string, completedDays int, limit int, opts ...client.Options) iter.Seq2[Task, error] {
 	params := url.Values{}
 	params.Add("assignee", assigneeGID)
 	params.Add("workspace", workspaceGID)
+	params.Add("opt_fields", FieldPresets.OptFields(client.PresetDefault, opts...))
 
-	// Calculate completed_since timestamp
-	if completedDays > 0 {
-		// Get tasks completed in the last N days
-		completedSince := time.Now().AddDate(0, 0, -completedDays).UTC().Format(time.RFC3339)
-		params.Add("completed_since", completedSince)
-	} else {
-		// Only get incomplete tasks
-		params.Add("completed_since", "now")
-	}
-
-	if limit > 0 {
-		params.Add("limit", fmt.Sprintf("%d", limit))
-	}
-	params.Add("opt_fields", "name,completed,completed_at,created_at,due_on,due_at,notes,projects.name,assignee_section.name,tags.name,num_subtasks")
-
-	for {
-		endpoint := "/tasks"
-		respBody, err := tm.client.Get(endpoint, params)
-		if err != nil {
-			return nil, fmt.Errorf("failed to list tasks: %w", err)
-		}
-
-		var response TasksResponse
-		if err := json.Unmarshal(respBody, &response); err != nil {
-			return nil, fmt.Errorf("failed to parse response: %w", err)
-		}
-
-		// When completedDays is 0, filter out completed tasks
-		// When completedDays > 0, include all tasks (completed and incomplete)
-		for _, task := range response.Data {
-			if completedDays > 0 || !task.Completed {
-				allTasks = append(allTasks, task)
-			}
-		}
-
-		if response.NextPage == nil || response.NextPage.Offset == "" {
-			break
-		}
+	return tm.list(ctx, params, completedDays, limit)
+}
 
-		params.Set("offset", response.NextPage.Offset)
-	}
+func (tm *TaskManager) ListBySection(sectionGID string, completedDays int, limit int, opts ...client.Options) ([]Task, error) {
+	return tm.ListBySectionContext(context.Background(), sectionGID, completedDays, limit, opts...)
+}
 
-	return allTasks, nil
+func (tm *TaskManager) ListBySectionContext(ctx context.Context, sectionGID string, completedDays int, limit int, opts ...client.Options) ([]Task, error) {
+	return client.Collect(tm.IterBySection(ctx, sectionGID, completedDays, limit, opts...))
 }
 
-func (tm *TaskManager) ListBySection(sectionGID string, completedDays int, limit int) ([]Task, error) {
-	allTasks := []Task{}
+// IterBySection streams tasks page by page instead of buffering them all.
+func (tm *TaskManager) IterBySection(ctx context.Context, sectionGID string, completedDays int, limit int, opts ...client.Options) iter.Seq2[Task, error] {
 	params := url.Values{}
 	params.Add("section", sectionGID)
+	params.Add("opt_fields", FieldPresets.OptFields(client.PresetDefault, opts...))
+
+	return tm.list(ctx, params, completedDays, limit)
+}
 
+// list pages through /tasks with the given filter params. At most limit tasks
+// are yielded; zero means no limit.
+func (tm *TaskManager) list(ctx context.Context, params url.Values, completedDays int, limit int) iter.Seq2[Task, error] {
 	// Calculate completed_since timestamp
 	if completedDays > 0 {
 		// Get tasks completed in the last N days
 		completedSince := time.Now().AddDate(0, 0, -completedDays).UTC().Format(time.RFC3339)
-		params.Add("completed_since", completedSince)
+		params.Set("completed_since", completedSince)
 	} else {
 		// Only get incomplete tasks
-		params.Add("completed_since", "now")
+		params.Set("completed_since", "now")
 	}
 
-	if limit > 0 {
-		params.Add("limit", fmt.Sprintf("%d", limit))
-	}
-	params.Add("opt_fields", "name,completed,completed_at,created_at,due_on,due_at,notes,assignee.name,tags.name,num_subtasks")
+	paginator := client.NewPaginator[Task](tm.client, "/tasks", params)
 
-	for {
-		endpoint := "/tasks"
-		respBody, err := tm.client.Get(endpoint, params)
-		if err != nil {
-			return nil, fmt.Errorf("failed to list tasks: %w", err)
-		}
+	return func(yield func(Task, error) bool) {
+		count := 0
+		for task, err := range paginator.All(ctx) {
+			if err != nil {
+				yield(Task{}, fmt.Errorf("failed to list tasks: %w", err))
+				return
+			}
 
-		var response TasksResponse
-		if err := json.Unmarshal(respBody, &response); err != nil {
-			return nil, fmt.Errorf("failed to parse response: %w", err)
-		}
+			// When completedDays is 0, filter out completed tasks
+			// When completedDays > 0, include all tasks (completed and incomplete)
+			if completedDays == 0 && task.Completed {
+				continue
+			}
 
-		// When completedDays is 0, filter out completed tasks
-		// When completedDays > 0, include all tasks (completed and incomplete)
-		for _, task := range response.Data {
-			if completedDays > 0 || !task.Completed {
-				allTasks = append(allTasks, task)
+			if !yield(task, nil) {
+				return
 			}
-		}
 
-		if response.NextPage == nil || response.NextPage.Offset == "" {
-			break
+			count++
+			if limit > 0 && count >= limit {
+				return
+			}
 		}
-
-		params.Set("offset", response.NextPage.Offset)
 	}
+}
 
-	return allTasks, nil
+func (tm *TaskManager) Get(taskGID string, opts ...client.Options) (*Task, error) {
+	return tm.GetContext(context.Background(), taskGID, opts...)
 }
 
-func (tm *TaskManager) Get(taskGID string) (*Task, error) {
+func (tm *TaskManager) GetContext(ctx context.Context, taskGID string, opts ...client.Options) (*Task, error) {
 	endpoint := fmt.Sprintf("/tasks/%s", taskGID)
 	params := url.Values{}
-	params.Add("opt_fields", "name,completed,completed_at,completed_by.name,created_at,due_on,due_at,html_notes,notes,assignee.name,assignee_section.name,custom_fields,followers.name,parent.name,projects.name,tags,workspace.name,memberships.project.name,memberships.section.name,num_subtasks,resource_subtype,start_on,start_at,dependencies.name,dependents.name")
+	params.Add("opt_fields", FieldPresets.OptFields(client.PresetFull, opts...))
 
-	respBody, err := tm.client.Get(endpoint, params)
+	respBody, err := tm.client.GetContext(ctx, endpoint, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get task: %w", err)
 	}
@@ -304,10 +322,14 @@ type TaskUpdateRequest struct {
 }
 
 func (tm *TaskManager) Update(taskGID string, update *TaskUpdate) (*Task, error) {
+	return tm.UpdateContext(context.Background(), taskGID, update)
+}
+
+func (tm *TaskManager) UpdateContext(ctx context.Context, taskGID string, update *TaskUpdate) (*Task, error) {
 	endpoint := fmt.Sprintf("/tasks/%s", taskGID)
 
 	reqBody := TaskUpdateRequest{Data: update}
-	respBody, err := tm.client.Put(endpoint, reqBody)
+	respBody, err := tm.client.PutContext(ctx, endpoint, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update task: %w", err)
 	}
@@ -320,30 +342,83 @@ func (tm *TaskManager) Update(taskGID string, update *TaskUpdate) (*Task, error)
 	return response.Data, nil
 }
 
+// BulkResult is the outcome of a bulk operation for a single task.
+type BulkResult struct {
+	GID  string
+	Task *Task
+	Err  error
+}
+
+// UpdateMany applies the same update to every task using Asana's batch API,
+// sending up to ten updates per request. A result is returned for every GID,
+// in order; failures are reported per task rather than aborting the run.
+func (tm *TaskManager) UpdateMany(ctx context.Context, taskGIDs []string, update *TaskUpdate) ([]BulkResult, error) {
+	batch := tm.client.NewBatch()
+	for _, gid := range taskGIDs {
+		batch.Add("PUT", fmt.Sprintf("/tasks/%s", gid), update)
+	}
+
+	batchResults, err := batch.Execute(ctx)
+
+	results := make([]BulkResult, len(batchResults))
+	for i, batchResult := range batchResults {
+		results[i].GID = taskGIDs[i]
+
+		var task Task
+		if err := batchResult.Decode(&task); err != nil {
+			results[i].Err = fmt.Errorf("failed to update task: %w", err)
+			continue
+		}
+		results[i].Task = &task
+	}
+
+	return results, err
+}
+
 func (tm *TaskManager) Complete(taskGID string) (*Task, error) {
+	return tm.CompleteContext(context.Background(), taskGID)
+}
+
+func (tm *TaskManager) CompleteContext(ctx context.Context, taskGID string) (*Task, error) {
 	completed := true
-	return tm.Update(taskGID, &TaskUpdate{Completed: &completed})
+	return tm.UpdateContext(ctx, taskGID, &TaskUpdate{Completed: &completed})
 }
 
 func (tm *TaskManager) Uncomplete(taskGID string) (*Task, error) {
+	return tm.UncompleteContext(context.Background(), taskGID)
+}
+
+func (tm *TaskManager) UncompleteContext(ctx context.Context, taskGID string) (*Task, error) {
 	completed := false
-	return tm.Update(taskGID, &TaskUpdate{Completed: &completed})
+	return tm.UpdateContext(ctx, taskGID, &TaskUpdate{Completed: &completed})
 }
 
 func (tm *TaskManager) SetAssignee(taskGID string, assigneeGID string) (*Task, error) {
-	return tm.Update(taskGID, &TaskUpdate{Assignee: &assigneeGID})
+	return tm.SetAssigneeContext(context.Background(), taskGID, assigneeGID)
+}
+
+func (tm *TaskManager) SetAssigneeContext(ctx context.Context, taskGID string, assigneeGID string) (*Task, error) {
+	return tm.UpdateContext(ctx, taskGID, &TaskUpdate{Assignee: &assigneeGID})
 }
 
 func (tm *TaskManager) SetDueDate(taskGID string, dueDate string) (*Task, error) {
+	return tm.SetDueDateContext(context.Background(), taskGID, dueDate)
+}
+
+func (tm *TaskManager) SetDueDateContext(ctx context.Context, taskGID string, dueDate string) (*Task, error) {
 	// If dueDate is empty, it will remove the due date
 	var dueDatePtr *string
 	if dueDate != "" {
 		dueDatePtr = &dueDate
 	}
-	return tm.Update(taskGID, &TaskUpdate{DueOn: dueDatePtr})
+	return tm.UpdateContext(ctx, taskGID, &TaskUpdate{DueOn: dueDatePtr})
 }
 
 func (tm *TaskManager) AddToProject(taskGID string, projectGID string) (*Task, error) {
+	return tm.AddToProjectContext(context.Background(), taskGID, projectGID)
+}
+
+func (tm *TaskManager) AddToProjectContext(ctx context.Context, taskGID string, projectGID string) (*Task, error) {
 	endpoint := fmt.Sprintf("/tasks/%s/addProject", taskGID)
 
 	reqBody := map[string]interface{}{
@@ -352,7 +427,7 @@ func (tm *TaskManager) AddToProject(taskGID string, projectGID string) (*Task, e
 		},
 	}
 
-	respBody, err := tm.client.Post(endpoint, reqBody)
+	respBody, err := tm.client.PostContext(ctx, endpoint, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to add task to project: %w", err)
 	}
@@ -366,6 +441,10 @@ func (tm *TaskManager) AddToProject(taskGID string, projectGID string) (*Task, e
 }
 
 func (tm *TaskManager) RemoveFromProject(taskGID string, projectGID string) (*Task, error) {
+	return tm.RemoveFromProjectContext(context.Background(), taskGID, projectGID)
+}
+
+func (tm *TaskManager) RemoveFromProjectContext(ctx context.Context, taskGID string, projectGID string) (*Task, error) {
 	endpoint := fmt.Sprintf("/tasks/%s/removeProject", taskGID)
 
 	reqBody := map[string]interface{}{
@@ -374,7 +453,7 @@ func (tm *TaskManager) RemoveFromProject(taskGID string, projectGID string) (*Ta
 		},
 	}
 
-	respBody, err := tm.client.Post(endpoint, reqBody)
+	respBody, err := tm.client.PostContext(ctx, endpoint, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to remove task from project: %w", err)
 	}
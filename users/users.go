@@ -1,6 +1,7 @@
 package users
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -23,21 +24,68 @@ type User struct {
 	Email        string `json:"email"`
 }
 
-type UsersResponse struct {
-	Data []User `json:"data"`
+type UsersResponse = client.Page[User]
+
+type UserResponse struct {
+	Data *User `json:"data"`
 }
 
 func (um *UserManager) ListInWorkspace(workspaceGID string) ([]User, error) {
+	return um.ListInWorkspaceContext(context.Background(), workspaceGID)
+}
+
+func (um *UserManager) ListInWorkspaceContext(ctx context.Context, workspaceGID string) ([]User, error) {
 	endpoint := fmt.Sprintf("/workspaces/%s/users", workspaceGID)
 	params := url.Values{}
 	params.Set("opt_fields", "gid,name,email")
 
-	respBody, err := um.client.Get(endpoint, params)
+	allUsers, err := client.NewPaginator[User](um.client, endpoint, params).Collect(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
 
-	var response UsersResponse
+	return allUsers, nil
+}
+
+func (um *UserManager) Me() (*User, error) {
+	return um.MeContext(context.Background())
+}
+
+// MeContext returns the user the access token belongs to.
+func (um *UserManager) MeContext(ctx context.Context) (*User, error) {
+	params := url.Values{}
+	params.Set("opt_fields", "gid,name,email")
+
+	respBody, err := um.client.GetContext(ctx, "/users/me", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current user: %w", err)
+	}
+
+	var response UserResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return response.Data, nil
+}
+
+func (um *UserManager) Get(user string) (*User, error) {
+	return um.GetContext(context.Background(), user)
+}
+
+// GetContext returns a user by GID, by email address or, for "me", the user
+// the access token belongs to.
+func (um *UserManager) GetContext(ctx context.Context, user string) (*User, error) {
+	endpoint := fmt.Sprintf("/users/%s", url.PathEscape(user))
+	params := url.Values{}
+	params.Set("opt_fields", "gid,name,email")
+
+	respBody, err := um.client.GetContext(ctx, endpoint, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	var response UserResponse
 	if err := json.Unmarshal(respBody, &response); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
@@ -1,6 +1,7 @@
 package webhooks
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -53,11 +54,13 @@ type WebhookResponse struct {
 	Data *Webhook `json:"data"`
 }
 
-type WebhooksListResponse struct {
-	Data []Webhook `json:"data"`
-}
+type WebhooksListResponse = client.Page[Webhook]
 
 func (wm *WebhookManager) Create(resourceGID, targetURL string, filters []WebhookFilter) (*Webhook, error) {
+	return wm.CreateContext(context.Background(), resourceGID, targetURL, filters)
+}
+
+func (wm *WebhookManager) CreateContext(ctx context.Context, resourceGID, targetURL string, filters []WebhookFilter) (*Webhook, error) {
 	// Prepare form data
 	formData := url.Values{}
 	formData.Add("resource", resourceGID)
@@ -72,7 +75,7 @@ func (wm *WebhookManager) Create(resourceGID, targetURL string, filters []Webhoo
 		formData.Add("filters", string(filtersJSON))
 	}
 
-	respBody, err := wm.client.PostForm("/webhooks", formData)
+	respBody, err := wm.client.PostFormContext(ctx, "/webhooks", formData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create webhook: %w", err)
 	}
@@ -86,6 +89,10 @@ func (wm *WebhookManager) Create(resourceGID, targetURL string, filters []Webhoo
 }
 
 func (wm *WebhookManager) List(workspace string, resourceGID string) ([]Webhook, error) {
+	return wm.ListContext(context.Background(), workspace, resourceGID)
+}
+
+func (wm *WebhookManager) ListContext(ctx context.Context, workspace string, resourceGID string) ([]Webhook, error) {
 	params := url.Values{}
 	if workspace != "" {
 		params.Add("workspace", workspace)
@@ -94,22 +101,21 @@ func (wm *WebhookManager) List(workspace string, resourceGID string) ([]Webhook,
 		params.Add("resource", resourceGID)
 	}
 
-	respBody, err := wm.client.Get("/webhooks", params)
+	allWebhooks, err := client.NewPaginator[Webhook](wm.client, "/webhooks", params).Collect(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list webhooks: %w", err)
 	}
 
-	var response WebhooksListResponse
-	if err := json.Unmarshal(respBody, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return response.Data, nil
+	return allWebhooks, nil
 }
 
 func (wm *WebhookManager) Get(webhookGID string) (*Webhook, error) {
+	return wm.GetContext(context.Background(), webhookGID)
+}
+
+func (wm *WebhookManager) GetContext(ctx context.Context, webhookGID string) (*Webhook, error) {
 	endpoint := fmt.Sprintf("/webhooks/%s", webhookGID)
-	respBody, err := wm.client.Get(endpoint, nil)
+	respBody, err := wm.client.GetContext(ctx, endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get webhook: %w", err)
 	}
@@ -123,14 +129,22 @@ func (wm *WebhookManager) Get(webhookGID string) (*Webhook, error) {
 }
 
 func (wm *WebhookManager) Delete(webhookGID string) error {
+	return wm.DeleteContext(context.Background(), webhookGID)
+}
+
+func (wm *WebhookManager) DeleteContext(ctx context.Context, webhookGID string) error {
 	endpoint := fmt.Sprintf("/webhooks/%s", webhookGID)
-	if _, err := wm.client.Delete(endpoint); err != nil {
+	if _, err := wm.client.DeleteContext(ctx, endpoint); err != nil {
 		return fmt.Errorf("failed to delete webhook: %w", err)
 	}
 	return nil
 }
 
 func (wm *WebhookManager) Update(webhookGID string, filters []WebhookFilter) (*Webhook, error) {
+	return wm.UpdateContext(context.Background(), webhookGID, filters)
+}
+
+func (wm *WebhookManager) UpdateContext(ctx context.Context, webhookGID string, filters []WebhookFilter) (*Webhook, error) {
 	endpoint := fmt.Sprintf("/webhooks/%s", webhookGID)
 
 	webhook := &Webhook{
@@ -138,7 +152,7 @@ func (wm *WebhookManager) Update(webhookGID string, filters []WebhookFilter) (*W
 	}
 
 	reqBody := WebhookRequest{Data: webhook}
-	respBody, err := wm.client.Put(endpoint, reqBody)
+	respBody, err := wm.client.PutContext(ctx, endpoint, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update webhook: %w", err)
 	}
@@ -152,6 +166,10 @@ func (wm *WebhookManager) Update(webhookGID string, filters []WebhookFilter) (*W
 }
 
 func (wm *WebhookManager) UpdateFilters(webhookGID string, filters []WebhookFilter) (*Webhook, error) {
+	return wm.UpdateFiltersContext(context.Background(), webhookGID, filters)
+}
+
+func (wm *WebhookManager) UpdateFiltersContext(ctx context.Context, webhookGID string, filters []WebhookFilter) (*Webhook, error) {
 	endpoint := fmt.Sprintf("/webhooks/%s", webhookGID)
 
 	// Create update request with only filters field
@@ -164,7 +182,7 @@ func (wm *WebhookManager) UpdateFilters(webhookGID string, filters []WebhookFilt
 		"data": updateData,
 	}
 
-	respBody, err := wm.client.Put(endpoint, reqBody)
+	respBody, err := wm.client.PutContext(ctx, endpoint, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update webhook filters: %w", err)
 	}
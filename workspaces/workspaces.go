@@ -1,8 +1,11 @@
 package workspaces
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
 
 	"github.com/octoberswimmer/utka/client"
 )
@@ -23,21 +26,50 @@ type Workspace struct {
 	IsOrganization bool     `json:"is_organization"`
 }
 
-type WorkspacesResponse struct {
-	Data []Workspace `json:"data"`
+// TypeaheadResult is a compact resource returned by a typeahead search.
+// Email is only set for users.
+type TypeaheadResult struct {
+	GID          string `json:"gid"`
+	ResourceType string `json:"resource_type"`
+	Name         string `json:"name"`
+	Email        string `json:"email,omitempty"`
 }
 
+type TypeaheadResponse struct {
+	Data []TypeaheadResult `json:"data"`
+}
+
+type WorkspacesResponse = client.Page[Workspace]
+
 type WorkspaceResponse struct {
 	Data *Workspace `json:"data"`
 }
 
 func (wm *WorkspaceManager) List() ([]Workspace, error) {
-	respBody, err := wm.client.Get("/workspaces", nil)
+	return wm.ListContext(context.Background())
+}
+
+func (wm *WorkspaceManager) ListContext(ctx context.Context) ([]Workspace, error) {
+	allWorkspaces, err := client.NewPaginator[Workspace](wm.client, "/workspaces", nil).Collect(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list workspaces: %w", err)
 	}
 
-	var response WorkspacesResponse
+	return allWorkspaces, nil
+}
+
+func (wm *WorkspaceManager) Get(workspaceGID string) (*Workspace, error) {
+	return wm.GetContext(context.Background(), workspaceGID)
+}
+
+func (wm *WorkspaceManager) GetContext(ctx context.Context, workspaceGID string) (*Workspace, error) {
+	endpoint := fmt.Sprintf("/workspaces/%s", workspaceGID)
+	respBody, err := wm.client.GetContext(ctx, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+
+	var response WorkspaceResponse
 	if err := json.Unmarshal(respBody, &response); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
@@ -45,14 +77,33 @@ func (wm *WorkspaceManager) List() ([]Workspace, error) {
 	return response.Data, nil
 }
 
-func (wm *WorkspaceManager) Get(workspaceGID string) (*Workspace, error) {
-	endpoint := fmt.Sprintf("/workspaces/%s", workspaceGID)
-	respBody, err := wm.client.Get(endpoint, nil)
+func (wm *WorkspaceManager) Typeahead(workspaceGID, resourceType, query string, count int) ([]TypeaheadResult, error) {
+	return wm.TypeaheadContext(context.Background(), workspaceGID, resourceType, query, count)
+}
+
+// TypeaheadContext searches a workspace for resources of one type whose names
+// match query, best matches first. Asana returns at most count results, and
+// no more than 100.
+func (wm *WorkspaceManager) TypeaheadContext(ctx context.Context, workspaceGID, resourceType, query string, count int) ([]TypeaheadResult, error) {
+	endpoint := fmt.Sprintf("/workspaces/%s/typeahead", workspaceGID)
+	params := url.Values{}
+	params.Set("resource_type", resourceType)
+	params.Set("query", query)
+	if count > 0 {
+		params.Set("count", strconv.Itoa(count))
+	}
+	fields := "name,resource_type"
+	if resourceType == "user" {
+		fields += ",email"
+	}
+	params.Set("opt_fields", fields)
+
+	respBody, err := wm.client.GetContext(ctx, endpoint, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get workspace: %w", err)
+		return nil, fmt.Errorf("failed to search workspace: %w", err)
 	}
 
-	var response WorkspaceResponse
+	var response TypeaheadResponse
 	if err := json.Unmarshal(respBody, &response); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}